@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS card_vault (
+    token VARCHAR(19) NOT NULL PRIMARY KEY,
+    pan VARCHAR(500) NOT NULL,
+    data_key VARCHAR(500) NOT NULL,
+    pan_index CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
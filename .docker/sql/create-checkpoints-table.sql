@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS checkpoints (
+    name VARCHAR(100) NOT NULL PRIMARY KEY,
+    position VARCHAR(100) NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
\ No newline at end of file
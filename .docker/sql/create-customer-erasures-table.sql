@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS customer_erasures (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    user_document_index CHAR(64) NOT NULL,
+    reason VARCHAR(500) NOT NULL DEFAULT '',
+    key_destroyed BOOLEAN NOT NULL,
+    erased_transactions INT NOT NULL,
+    erased_at TIMESTAMP NOT NULL,
+    INDEX idx_customer_erasures_user_document_index (user_document_index)
+);
\ No newline at end of file
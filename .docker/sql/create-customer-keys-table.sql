@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS customer_keys (
+    user_document_index CHAR(64) NOT NULL PRIMARY KEY,
+    public_key CHAR(64) NOT NULL,
+    private_key VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
@@ -2,5 +2,16 @@ CREATE TABLE IF NOT EXISTS transactions (
     id VARCHAR(36) NOT NULL UNIQUE,
     user_document VARCHAR(500) NOT NULL,
     credit_card_token VARCHAR(500) NOT NULL,
-    `value` DECIMAL(6, 2) NOT NULL
+    `value` DECIMAL(6, 2) NULL,
+    encrypted_value VARCHAR(500) NOT NULL DEFAULT '',
+    value_bucket BIGINT NULL,
+    data_key VARCHAR(500) NOT NULL DEFAULT '',
+    user_document_index CHAR(64) NOT NULL DEFAULT '',
+    credit_card_token_last4_index CHAR(64) NOT NULL DEFAULT '',
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_transactions_user_document_index (user_document_index),
+    INDEX idx_transactions_value_bucket (value_bucket),
+    INDEX idx_transactions_created_at (created_at, id),
+    INDEX idx_transactions_value (`value`, id),
+    INDEX idx_transactions_credit_card_token_last4_index (credit_card_token_last4_index)
 );
\ No newline at end of file
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto-challenge/config"
+	"crypto-challenge/database/repositories"
+	"crypto-challenge/handlers"
+	"crypto-challenge/jobs"
+	"crypto-challenge/providers"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func runCommand(ctx context.Context, args []string, cfg *config.AppConfig) error {
+	switch args[0] {
+	case "reencrypt":
+		reencryptionJobs, closeJobs, err := newReencryptionJobs(cfg)
+		if err != nil {
+			return err
+		}
+
+		defer closeJobs()
+
+		for _, reencryptionJob := range reencryptionJobs {
+			result, err := reencryptionJob.Run(ctx)
+			log.Printf("Re-encryption of the %s finished: %+v\n", reencryptionJob.name, *result)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case "reencrypt-status":
+		reencryptionJobs, closeJobs, err := newReencryptionJobs(cfg)
+		if err != nil {
+			return err
+		}
+
+		defer closeJobs()
+
+		for _, reencryptionJob := range reencryptionJobs {
+			countByKeyID, err := reencryptionJob.CountByKeyID(ctx)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(reencryptionJob.name)
+			printCountByKeyID(countByKeyID)
+		}
+
+		return nil
+	case "kms-server":
+		return runKeyManagementServer(ctx, cfg)
+	case "keys":
+		if len(args) < 2 || args[1] != "split" {
+			return errors.New("unknown keys command, expected: keys split")
+		}
+
+		return splitPrimaryKey(args[2:], cfg)
+	default:
+		return fmt.Errorf("unknown command %q, expected one of: reencrypt, reencrypt-status, kms-server, keys split", args[0])
+	}
+}
+
+// splitPrimaryKey writes each share of the local primary key to its own file
+// in the output directory, "share-<index>", to be handed to a different
+// operator, for a deployment started with Unseal.Enabled to rebuild it.
+func splitPrimaryKey(args []string, cfg *config.AppConfig) error {
+	flags := flag.NewFlagSet("keys split", flag.ContinueOnError)
+	shares := flags.Int("shares", 5, "number of shares to split the key into")
+	threshold := flags.Int("threshold", 3, "number of shares that rebuild the key")
+	outputDir := flags.String("out", "", "directory to write one file per share to")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *outputDir == "" {
+		return errors.New("-out is required, the directory to write one file per share to")
+	}
+
+	if cfg.Kms.Provider != providers.KmsProviderLocal {
+		return errors.New("only the keys of the local KMS can be split")
+	}
+
+	keyring, err := newLocalKeyring(cfg)
+	if err != nil {
+		return err
+	}
+
+	cfg.ClearKeys()
+
+	keyID, primaryKey := keyring.PrimaryKey()
+
+	keyShares, err := providers.SplitKey(primaryKey, *shares, *threshold)
+	if err != nil {
+		return err
+	}
+
+	for i, keyShare := range keyShares {
+		if err := writeKeyShare(filepath.Join(*outputDir, fmt.Sprintf("share-%d", i+1)), keyShare); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Key %s split into %d shares in %s, any %d of them rebuild it.\n", keyID, *shares, *outputDir,
+		*threshold)
+
+	return nil
+}
+
+// writeKeyShare writes the share to a new file only its owner can read, it
+// never replaces the share of a previous split.
+func writeKeyShare(path, keyShare string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(file, keyShare); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// newLocalKeyring loads the local keyring, rebuilding its primary key from
+// the shares read from the standard input when Unseal.Enabled is set.
+func newLocalKeyring(cfg *config.AppConfig) (*providers.Keyring, error) {
+	if !cfg.Unseal.Enabled {
+		return providers.NewLocalKeyringFromConfig(cfg)
+	}
+
+	return unsealKeyring(cfg, os.Stdin)
+}
+
+// unsealKeyring submits the key shares read from the reader, one per line, to
+// the same unsealer the sealed server uses, until they rebuild the primary
+// key. The shares that are refused are reported and skipped.
+func unsealKeyring(cfg *config.AppConfig, reader io.Reader) (*providers.Keyring, error) {
+	var keyring *providers.Keyring
+
+	unsealer := providers.NewShamirUnsealer(func(primaryKey []byte) error {
+		var err error
+		keyring, err = providers.NewUnsealedKeyringFromConfig(cfg, primaryKey)
+
+		return err
+	})
+
+	log.Println("🔒 Sealed, enter the key shares, one per line")
+
+	scanner := bufio.NewScanner(reader)
+
+	for keyring == nil && scanner.Scan() {
+		keyShare := strings.TrimSpace(scanner.Text())
+		if keyShare == "" {
+			continue
+		}
+
+		status, err := unsealer.SubmitKeyShare(keyShare)
+
+		switch {
+		case errors.Is(err, providers.ErrInvalidKeyShare), errors.Is(err, providers.ErrKeySharesMismatch),
+			errors.Is(err, providers.ErrKeyShareAlreadySubmitted):
+			log.Printf("Key share refused: %v\n", err)
+		case err != nil:
+			return nil, err
+		case status.Sealed:
+			log.Printf("Key share accepted, %d of %d\n", status.Progress, status.Threshold)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if keyring == nil {
+		return nil, errors.New("the key shares ended before rebuilding the primary key")
+	}
+
+	log.Println("🔓 Unsealed")
+
+	return keyring, nil
+}
+
+func printCountByKeyID(countByKeyID map[string]int) {
+	keyIDs := make([]string, 0, len(countByKeyID))
+	for keyID := range countByKeyID {
+		keyIDs = append(keyIDs, keyID)
+	}
+
+	sort.Strings(keyIDs)
+
+	for _, keyID := range keyIDs {
+		fmt.Printf("\t%s\t%d\n", keyID, countByKeyID[keyID])
+	}
+}
+
+type reencryptionJob interface {
+	Run(ctx context.Context) (*jobs.ReencryptionResult, error)
+	CountByKeyID(ctx context.Context) (map[string]int, error)
+}
+
+type namedReencryptionJob struct {
+	reencryptionJob
+	name string
+}
+
+// newReencryptionJobs returns, in the order they must run, the jobs bringing
+// the transactions' data keys under their customer's key and the customer
+// keys and the card vault under the primary master key.
+func newReencryptionJobs(cfg *config.AppConfig) ([]namedReencryptionJob, func(), error) {
+	keyManagementService, keyring, err := newKeyManagementService(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys, err := newIndexKeys(cfg, keyring)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	primaryKey, err := keyManagementService.KeyMetadata("")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db := openDatabase(cfg)
+
+	customerKeyRepository := repositories.NewCustomerKeyMySqlRepository(db)
+	customerKeys := providers.NewCustomerKeys(customerKeyRepository, keyManagementService)
+
+	transactionCryptoProvider, err := newTransactionCryptoProvider(cfg, keyManagementService, customerKeys, keys)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	cardVaultRepository := repositories.NewCardVaultMySqlRepository(db)
+
+	cardTokenizer := newCardTokenizer(cfg, keyManagementService, cardVaultRepository, keys)
+
+	transactionRepository := repositories.NewTransactionMySqlRepository(db)
+	transactionRepository.UseTimeouts(cfg.Database.ReadTimeout, cfg.Database.WriteTimeout)
+
+	transactionReencryptionJob := jobs.NewReencryptionJob(
+		transactionRepository,
+		repositories.NewCheckpointMySqlRepository(db),
+		transactionCryptoProvider,
+		providers.CustomerKeyID,
+		cfg.Jobs.ReencryptionChunkSize,
+	)
+
+	if keys.deterministicKeyring != nil {
+		deterministicKeyID, _ := keys.deterministicKeyring.PrimaryKey()
+		transactionReencryptionJob.UseDeterministicKeyID(deterministicKeyID)
+	}
+
+	if cfg.Cryptography.EncryptValue {
+		transactionReencryptionJob.EncryptValues()
+	}
+
+	reencryptionJobs := []namedReencryptionJob{
+		{transactionReencryptionJob, "transactions"},
+		{
+			jobs.NewCustomerKeyReencryptionJob(customerKeyRepository, customerKeys, primaryKey.KeyID,
+				cfg.Jobs.ReencryptionChunkSize),
+			"customer keys",
+		},
+		{
+			jobs.NewCardVaultReencryptionJob(cardVaultRepository, cardTokenizer, primaryKey.KeyID,
+				cfg.Jobs.ReencryptionChunkSize),
+			"card vault",
+		},
+	}
+
+	cfg.ClearKeys()
+
+	return reencryptionJobs, func() { db.Close() }, nil
+}
+
+// runKeyManagementServer serves the local keyring through the KMS HTTP API,
+// standing in for a managed KMS.
+func runKeyManagementServer(ctx context.Context, cfg *config.AppConfig) error {
+	keyring, err := newLocalKeyring(cfg)
+	if err != nil {
+		return err
+	}
+
+	cfg.ClearKeys()
+
+	r := chi.NewRouter()
+	r.Use(middleware.Logger)
+
+	r.Mount("/", handlers.NewKeyManagementRouter(providers.NewLocalKeyManagementServiceFromConfig(cfg, keyring), cfg.Kms.Token))
+
+	log.Printf("🔑 KMS running at: %s\n", cfg.Kms.ServerAddress)
+
+	return listenAndServe(ctx, cfg.Kms.ServerAddress, r)
+}
@@ -1,29 +1,152 @@
 package config
 
 import (
-	"encoding/hex"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/cristalhq/aconfig"
 	"github.com/cristalhq/aconfig/aconfigdotenv"
 )
 
+// AppConfig is read from the environment and the .env file. The secrets can
+// also be read from files, such as Docker secrets, named by their _FILE
+// variant: CRYPTOGRAPHY_SECRET_KEY_FILE for CRYPTOGRAPHY_SECRET_KEY.
 type AppConfig struct {
 	Database struct {
-		User     string
-		Password string
-		Host     string `default:"localhost"`
-		Port     int    `default:"3306"`
-		DbName   string `env:"NAME"`
+		User         string
+		Password     string
+		PasswordFile string
+		Host         string `default:"localhost"`
+		Port         int    `default:"3306"`
+		DbName       string `env:"NAME"`
+
+		// ReadTimeout and WriteTimeout bound each query reading and each
+		// statement writing transactions, no timeout when zero.
+		ReadTimeout  time.Duration `default:"5s"`
+		WriteTimeout time.Duration `default:"5s"`
 	}
 
 	Cryptography struct {
-		SecretKey string
+		SecretKey         string
+		SecretKeyFile     string
+		SecretKeyID       string `default:"k1"`
+		DecryptOnlyKeys   map[string]string
+		LegacyKeyID       string
+		BlindIndexKey     string
+		BlindIndexKeyFile string
+		Algorithm         string `default:"aes-256-gcm"`
+
+		// BlindIndexKeyID names the master key of the local KMS the blind
+		// index key is derived from, without BlindIndexKey. It must be kept,
+		// as decrypt-only, after a rotation: the indexes can't be rotated.
+		BlindIndexKeyID string `default:"k1"`
+
+		// DecryptOnlyKeysDir holds one "<key id>.key" file with the
+		// hex-encoded key for each decrypt-only key, instead of DecryptOnlyKeys.
+		DecryptOnlyKeysDir string
+
+		DeterministicKey     string
+		DeterministicKeyFile string
+		DeterministicKeyID   string `default:"d1"`
+		DeterministicFields  []string
+
+		// EncryptOnly disables every decryption, for deployments that only
+		// write transactions and must not hold any key that can read them.
+		EncryptOnly bool
+
+		// Workers bounds the transactions decrypted at once in a listing,
+		// GOMAXPROCS when zero.
+		Workers int
+
+		// RejectUnbound stops decrypting the ciphertexts from before
+		// envelope version 2, not bound to their row and column, once the
+		// reencrypt command brought them all to version 2.
+		RejectUnbound bool
+
+		// EncryptValue encrypts the transactions' value too, ValueBucketWidth
+		// stores the range of that width it falls in, in clear, when positive.
+		EncryptValue     bool
+		ValueBucketWidth float64
+	}
+
+	Kms struct {
+		Provider      string `default:"local"`
+		KeysDir       string
+		URL           string
+		Token         string
+		TokenFile     string
+		Timeout       time.Duration `default:"5s"`
+		ServerAddress string        `default:":3001"`
+
+		PublicKey   string
+		PublicKeyID string `default:"pk1"`
+		PrivateKeys map[string]string
+
+		// PrivateKeysDir holds one "<key id>.key" file with the hex-encoded
+		// private key for each key ID, instead of PrivateKeys.
+		PrivateKeysDir string
+	}
+
+	// Masking reads the caller's role from RoleHeader, which a gateway must
+	// set after authenticating them, stripping the one they sent.
+	Masking struct {
+		DefaultPolicy string `default:"masked"`
+		RolePolicies  map[string]string
+		RoleHeader    string `default:"X-Caller-Role"`
+	}
+
+	Customers struct {
+		// ForgetToken is the bearer token required to forget customers,
+		// erasure is disabled without one.
+		ForgetToken     string
+		ForgetTokenFile string
+	}
+
+	Vault struct {
+		// TokenizeToken is the bearer token required to tokenize card
+		// numbers, tokenization is disabled without one.
+		TokenizeToken     string
+		TokenizeTokenFile string
+
+		// DetokenizeToken is the bearer token required to detokenize card
+		// numbers, detokenization is disabled without one.
+		DetokenizeToken     string
+		DetokenizeTokenFile string
+	}
+
+	Unseal struct {
+		// Enabled starts the server sealed, without the primary key, until
+		// enough of its shares are submitted to rebuild it.
+		Enabled   bool
+		Token     string
+		TokenFile string
+	}
+
+	Jobs struct {
+		ReencryptionChunkSize int `default:"500"`
 	}
 }
 
-func GetAppConfig(configFilePath string) *AppConfig {
+const keyFileExtension = ".key"
+
+// reservedKeyID is providers.CustomerKeyID, the key ID of the data keys
+// wrapped by customer keys, which no master key may take.
+const reservedKeyID = "customer"
+
+var (
+	keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+	// hexKeyPattern matches what looks like a key in the loading errors.
+	hexKeyPattern = regexp.MustCompile(`[0-9A-Fa-f]{16,}`)
+)
+
+// GetAppConfig loads and validates the config for the given command, the
+// server when empty.
+func GetAppConfig(configFilePath, command string) *AppConfig {
 	var cfg AppConfig
 
 	loader := aconfig.LoaderFor(&cfg, aconfig.Config{
@@ -34,11 +157,12 @@ func GetAppConfig(configFilePath string) *AppConfig {
 		Files: []string{configFilePath},
 	})
 
+	// The loading errors may quote the malformed values, keys included.
 	if err := loader.Load(); err != nil {
-		panic(err)
+		panic(hexKeyPattern.ReplaceAllString(err.Error(), "[REDACTED]"))
 	}
 
-	validationResult := validateAppConfig(&cfg)
+	validationResult := validateAppConfig(&cfg, command)
 
 	if len(validationResult) > 0 {
 		for k, v := range validationResult {
@@ -53,14 +177,17 @@ func GetAppConfig(configFilePath string) *AppConfig {
 	return &cfg
 }
 
-func validateAppConfig(cfg *AppConfig) map[string]*[]string {
+func validateAppConfig(cfg *AppConfig, command string) map[string]*[]string {
 	validationErrors := make(map[string]*[]string)
 
+	// The secrets are validated the same wherever they were read from.
+	readSecretFiles(cfg, validationErrors)
+	readSecretDirs(cfg, validationErrors)
+
 	fieldsToMakeBlankValidation := map[string]string{
-		"Database.User":          cfg.Database.User,
-		"Database.Password":      cfg.Database.Password,
-		"Database.DbName":        cfg.Database.DbName,
-		"Cryptography.SecretKey": cfg.Cryptography.SecretKey,
+		"Database.User":     cfg.Database.User,
+		"Database.Password": cfg.Database.Password,
+		"Database.DbName":   cfg.Database.DbName,
 	}
 
 	blankFieldValidationResults := validateBlankFields(fieldsToMakeBlankValidation)
@@ -75,28 +202,387 @@ func validateAppConfig(cfg *AppConfig) map[string]*[]string {
 		}
 	}
 
-	secretKeyValidationErrors := []string{}
+	if cfg.Database.ReadTimeout < 0 {
+		addValidationErrors(validationErrors, "Database.ReadTimeout", "Must not be negative.")
+	}
 
-	if decodedSecretKey, err := hex.DecodeString(cfg.Cryptography.SecretKey); err != nil {
-		secretKeyValidationErrors = append(secretKeyValidationErrors, err.Error())
-	} else if len(decodedSecretKey) != 32 {
-		secretKeyValidationErrors = append(secretKeyValidationErrors, "Must represent exactly 32 bytes (64 hex-characters).")
+	if cfg.Database.WriteTimeout < 0 {
+		addValidationErrors(validationErrors, "Database.WriteTimeout", "Must not be negative.")
 	}
 
-	if len(secretKeyValidationErrors) > 0 {
-		key := "Cryptography.SecretKey"
+	switch cfg.Kms.Provider {
+	case "local":
+		// The keys directory is validated when loaded.
+		if cfg.Kms.KeysDir == "" {
+			validateCryptographyKeys(cfg, validationErrors)
+		}
+	case "http":
+		if _, err := url.ParseRequestURI(cfg.Kms.URL); err != nil {
+			addValidationErrors(validationErrors, "Kms.URL", "Must be a valid URL when Kms.Provider is http.")
+		}
 
-		if _, ok := validationErrors[key]; !ok {
-			validationErrors[key] = &secretKeyValidationErrors
-		} else {
-			concatenedErrors := append(*validationErrors[key], secretKeyValidationErrors...)
-			validationErrors[key] = &concatenedErrors
+		if strings.TrimSpace(cfg.Kms.Token) == "" {
+			addValidationErrors(validationErrors, "Kms.Token", "Must be a non-blank string when Kms.Provider is http.")
 		}
+	case "public-key":
+		validatePublicKeys(cfg, validationErrors)
+	default:
+		addValidationErrors(validationErrors, "Kms.Provider", "Must be one of: local, http, public-key.")
+	}
+
+	// The KMS server unwraps any data key for whoever holds its token.
+	if command == "kms-server" && strings.TrimSpace(cfg.Kms.Token) == "" {
+		addValidationErrors(validationErrors, "Kms.Token", "Must be a non-blank string to run kms-server.")
+	}
+
+	if cfg.Cryptography.EncryptOnly {
+		validateEncryptOnly(cfg, validationErrors)
+	}
+
+	if cfg.Unseal.Enabled {
+		validateUnseal(cfg, validationErrors)
+	}
+
+	switch cfg.Cryptography.Algorithm {
+	case "aes-256-gcm", "chacha20-poly1305", "xchacha20-poly1305":
+	default:
+		addValidationErrors(validationErrors, "Cryptography.Algorithm",
+			"Must be one of: aes-256-gcm, chacha20-poly1305, xchacha20-poly1305.")
+	}
+
+	// The local KMS derives the blind index and deterministic keys from its
+	// master keys, unless they are given. The other KMSs never hand them out.
+	derivesKeys := cfg.Kms.Provider == "local"
+
+	if cfg.Cryptography.BlindIndexKey != "" || !derivesKeys {
+		addValidationErrors(validationErrors, "Cryptography.BlindIndexKey",
+			validateSecretKey(cfg.Cryptography.BlindIndexKey)...)
+	} else {
+		validateBlindIndexKeyID(cfg, validationErrors)
+	}
+
+	if cfg.Cryptography.BlindIndexKey != "" && cfg.Cryptography.BlindIndexKey == cfg.Cryptography.SecretKey {
+		addValidationErrors(validationErrors, "Cryptography.BlindIndexKey", "Must differ from Cryptography.SecretKey.")
+	}
+
+	if len(cfg.Cryptography.DeterministicFields) > 0 {
+		validateDeterministicEncryption(cfg, derivesKeys, validationErrors)
+	}
+
+	validateMaskingPolicy(validationErrors, "Masking.DefaultPolicy", cfg.Masking.DefaultPolicy)
+
+	for role, policy := range cfg.Masking.RolePolicies {
+		validateMaskingPolicy(validationErrors, fmt.Sprintf("Masking.RolePolicies[%s]", role), policy)
+	}
+
+	if strings.TrimSpace(cfg.Masking.RoleHeader) == "" {
+		addValidationErrors(validationErrors, "Masking.RoleHeader", "Must be a non-blank string.")
+	}
+
+	if cfg.Cryptography.Workers < 0 {
+		addValidationErrors(validationErrors, "Cryptography.Workers", "Must not be negative.")
+	}
+
+	if cfg.Cryptography.ValueBucketWidth < 0 {
+		addValidationErrors(validationErrors, "Cryptography.ValueBucketWidth", "Must not be negative.")
+	}
+
+	if cfg.Cryptography.ValueBucketWidth > 0 && !cfg.Cryptography.EncryptValue {
+		addValidationErrors(validationErrors, "Cryptography.ValueBucketWidth", "Requires Cryptography.EncryptValue to be set.")
+	}
+
+	if cfg.Jobs.ReencryptionChunkSize <= 0 {
+		addValidationErrors(validationErrors, "Jobs.ReencryptionChunkSize", "Must be greater than zero.")
 	}
 
 	return validationErrors
 }
 
+// readSecretFiles reads the secrets whose _FILE variant is set, leaving out
+// the trailing line break.
+func readSecretFiles(cfg *AppConfig, validationErrors map[string]*[]string) {
+	secretFiles := []struct {
+		key    string
+		path   string
+		secret *string
+	}{
+		{"Database.Password", cfg.Database.PasswordFile, &cfg.Database.Password},
+		{"Cryptography.SecretKey", cfg.Cryptography.SecretKeyFile, &cfg.Cryptography.SecretKey},
+		{"Cryptography.BlindIndexKey", cfg.Cryptography.BlindIndexKeyFile, &cfg.Cryptography.BlindIndexKey},
+		{"Cryptography.DeterministicKey", cfg.Cryptography.DeterministicKeyFile, &cfg.Cryptography.DeterministicKey},
+		{"Kms.Token", cfg.Kms.TokenFile, &cfg.Kms.Token},
+		{"Customers.ForgetToken", cfg.Customers.ForgetTokenFile, &cfg.Customers.ForgetToken},
+		{"Vault.TokenizeToken", cfg.Vault.TokenizeTokenFile, &cfg.Vault.TokenizeToken},
+		{"Vault.DetokenizeToken", cfg.Vault.DetokenizeTokenFile, &cfg.Vault.DetokenizeToken},
+		{"Unseal.Token", cfg.Unseal.TokenFile, &cfg.Unseal.Token},
+	}
+
+	for _, secretFile := range secretFiles {
+		if secretFile.path == "" {
+			continue
+		}
+
+		if *secretFile.secret != "" {
+			addValidationErrors(validationErrors, secretFile.key,
+				fmt.Sprintf("Must not be set along with %sFile.", secretFile.key))
+			continue
+		}
+
+		secret, err := os.ReadFile(secretFile.path)
+		if err != nil {
+			addValidationErrors(validationErrors, secretFile.key+"File", err.Error())
+			continue
+		}
+
+		*secretFile.secret = strings.TrimRight(string(secret), "\r\n")
+	}
+}
+
+// readSecretDirs reads the keys of the maps whose directory is set, one
+// "<key id>.key" file per key, leaving out the trailing line break.
+func readSecretDirs(cfg *AppConfig, validationErrors map[string]*[]string) {
+	secretDirs := []struct {
+		key     string
+		path    string
+		secrets *map[string]string
+	}{
+		{"Cryptography.DecryptOnlyKeys", cfg.Cryptography.DecryptOnlyKeysDir, &cfg.Cryptography.DecryptOnlyKeys},
+		{"Kms.PrivateKeys", cfg.Kms.PrivateKeysDir, &cfg.Kms.PrivateKeys},
+	}
+
+	for _, secretDir := range secretDirs {
+		if secretDir.path == "" {
+			continue
+		}
+
+		if len(*secretDir.secrets) > 0 {
+			addValidationErrors(validationErrors, secretDir.key,
+				fmt.Sprintf("Must not be set along with %sDir.", secretDir.key))
+			continue
+		}
+
+		paths, err := filepath.Glob(filepath.Join(secretDir.path, "*"+keyFileExtension))
+		if err == nil && len(paths) == 0 {
+			_, err = os.Stat(secretDir.path)
+		}
+
+		if err != nil {
+			addValidationErrors(validationErrors, secretDir.key+"Dir", err.Error())
+			continue
+		}
+
+		secrets := make(map[string]string, len(paths))
+
+		for _, path := range paths {
+			secret, err := os.ReadFile(path)
+			if err != nil {
+				addValidationErrors(validationErrors, secretDir.key+"Dir", err.Error())
+				continue
+			}
+
+			secrets[strings.TrimSuffix(filepath.Base(path), keyFileExtension)] = strings.TrimRight(string(secret), "\r\n")
+		}
+
+		*secretDir.secrets = secrets
+	}
+}
+
+// ClearKeys drops the hex-encoded keys once they are decoded, along with the
+// environment variables they may have been read from. Go strings can't be
+// zeroed, dropping them only lets their memory be reused sooner.
+func (cfg *AppConfig) ClearKeys() {
+	cfg.Cryptography.SecretKey = ""
+	cfg.Cryptography.DecryptOnlyKeys = nil
+	cfg.Cryptography.BlindIndexKey = ""
+	cfg.Cryptography.DeterministicKey = ""
+	cfg.Kms.PrivateKeys = nil
+
+	for _, name := range []string{
+		"CRYPTOGRAPHY_SECRET_KEY",
+		"CRYPTOGRAPHY_DECRYPT_ONLY_KEYS",
+		"CRYPTOGRAPHY_BLIND_INDEX_KEY",
+		"CRYPTOGRAPHY_DETERMINISTIC_KEY",
+		"KMS_PRIVATE_KEYS",
+	} {
+		os.Unsetenv(name)
+	}
+}
+
+func validateCryptographyKeys(cfg *AppConfig, validationErrors map[string]*[]string) {
+	// A sealed deployment rebuilds the primary key from its shares.
+	if !cfg.Unseal.Enabled {
+		if len(strings.TrimSpace(cfg.Cryptography.SecretKey)) == 0 {
+			addValidationErrors(validationErrors, "Cryptography.SecretKey", "Must be a non-blank string.")
+		}
+
+		addValidationErrors(validationErrors, "Cryptography.SecretKey",
+			validateSecretKey(cfg.Cryptography.SecretKey)...)
+	}
+	addValidationErrors(validationErrors, "Cryptography.SecretKeyID",
+		validateKeyID(cfg.Cryptography.SecretKeyID)...)
+
+	for id, secretKey := range cfg.Cryptography.DecryptOnlyKeys {
+		key := fmt.Sprintf("Cryptography.DecryptOnlyKeys[%s]", id)
+
+		addValidationErrors(validationErrors, key, validateKeyID(id)...)
+		addValidationErrors(validationErrors, key, validateSecretKey(secretKey)...)
+
+		if id == cfg.Cryptography.SecretKeyID {
+			addValidationErrors(validationErrors, key, "Must not reuse the ID of the primary key (Cryptography.SecretKeyID).")
+		}
+	}
+
+	if legacyKeyID := cfg.Cryptography.LegacyKeyID; legacyKeyID != "" {
+		if _, ok := cfg.Cryptography.DecryptOnlyKeys[legacyKeyID]; !ok && legacyKeyID != cfg.Cryptography.SecretKeyID {
+			addValidationErrors(validationErrors, "Cryptography.LegacyKeyID",
+				"Must be the ID of the primary key or of one of the decrypt-only keys.")
+		}
+	}
+}
+
+func validatePublicKeys(cfg *AppConfig, validationErrors map[string]*[]string) {
+	addValidationErrors(validationErrors, "Kms.PublicKey", validateSecretKey(cfg.Kms.PublicKey)...)
+	addValidationErrors(validationErrors, "Kms.PublicKeyID", validateKeyID(cfg.Kms.PublicKeyID)...)
+
+	for id, privateKey := range cfg.Kms.PrivateKeys {
+		key := fmt.Sprintf("Kms.PrivateKeys[%s]", id)
+
+		addValidationErrors(validationErrors, key, validateKeyID(id)...)
+		addValidationErrors(validationErrors, key, validateSecretKey(privateKey)...)
+	}
+}
+
+// validateEncryptOnly makes sure an encrypt-only deployment doesn't hold any
+// key that decrypts: only the KMS public key can wrap data keys without being
+// able to unwrap them, and the deterministic key decrypts what it encrypts.
+func validateEncryptOnly(cfg *AppConfig, validationErrors map[string]*[]string) {
+	if cfg.Kms.Provider != "public-key" {
+		addValidationErrors(validationErrors, "Cryptography.EncryptOnly", "Requires Kms.Provider to be public-key.")
+	}
+
+	if len(cfg.Kms.PrivateKeys) > 0 {
+		addValidationErrors(validationErrors, "Kms.PrivateKeys", "Must be empty when Cryptography.EncryptOnly is set.")
+	}
+
+	if len(cfg.Cryptography.DeterministicFields) > 0 {
+		addValidationErrors(validationErrors, "Cryptography.DeterministicFields",
+			"Must be empty when Cryptography.EncryptOnly is set.")
+	}
+
+	if cfg.Vault.DetokenizeToken != "" {
+		addValidationErrors(validationErrors, "Vault.DetokenizeToken", "Must be empty when Cryptography.EncryptOnly is set.")
+	}
+}
+
+// validateUnseal makes sure a sealed deployment holds no copy of the primary
+// key, which only the local KMS can rebuild from its shares.
+func validateUnseal(cfg *AppConfig, validationErrors map[string]*[]string) {
+	if cfg.Kms.Provider != "local" || cfg.Kms.KeysDir != "" {
+		addValidationErrors(validationErrors, "Unseal.Enabled", "Requires Kms.Provider to be local, without Kms.KeysDir.")
+	}
+
+	if cfg.Cryptography.SecretKey != "" {
+		addValidationErrors(validationErrors, "Cryptography.SecretKey", "Must be empty when Unseal.Enabled is set.")
+	}
+
+	if strings.TrimSpace(cfg.Unseal.Token) == "" {
+		addValidationErrors(validationErrors, "Unseal.Token", "Must be a non-blank string when Unseal.Enabled is set.")
+	}
+}
+
+func validateDeterministicEncryption(cfg *AppConfig, derivesKeys bool, validationErrors map[string]*[]string) {
+	for _, field := range cfg.Cryptography.DeterministicFields {
+		if field != "user_document" && field != "credit_card_token" {
+			addValidationErrors(validationErrors, "Cryptography.DeterministicFields",
+				fmt.Sprintf("Unknown field %q, must be one of: user_document, credit_card_token.", field))
+		}
+	}
+
+	if derivesKeys && cfg.Cryptography.DeterministicKey == "" {
+		return
+	}
+
+	if size, ok := hexKeySize(cfg.Cryptography.DeterministicKey); !ok || size != 64 {
+		addValidationErrors(validationErrors, "Cryptography.DeterministicKey",
+			"Must represent exactly 64 bytes (128 hex-characters) when Cryptography.DeterministicFields is set.")
+	}
+
+	addValidationErrors(validationErrors, "Cryptography.DeterministicKeyID",
+		validateKeyID(cfg.Cryptography.DeterministicKeyID)...)
+}
+
+func validateBlindIndexKeyID(cfg *AppConfig, validationErrors map[string]*[]string) {
+	addValidationErrors(validationErrors, "Cryptography.BlindIndexKeyID",
+		validateKeyID(cfg.Cryptography.BlindIndexKeyID)...)
+
+	// The keys directory is validated when loaded.
+	if cfg.Kms.KeysDir != "" {
+		return
+	}
+
+	blindIndexKeyID := cfg.Cryptography.BlindIndexKeyID
+	if _, ok := cfg.Cryptography.DecryptOnlyKeys[blindIndexKeyID]; !ok && blindIndexKeyID != cfg.Cryptography.SecretKeyID {
+		addValidationErrors(validationErrors, "Cryptography.BlindIndexKeyID",
+			"Must be the ID of the primary key or of one of the decrypt-only keys.")
+	}
+}
+
+func validateMaskingPolicy(validationErrors map[string]*[]string, key, policy string) {
+	switch policy {
+	case "full", "masked", "last-4", "hidden":
+	default:
+		addValidationErrors(validationErrors, key, "Must be one of: full, masked, last-4, hidden.")
+	}
+}
+
+func validateSecretKey(secretKey string) []string {
+	if size, ok := hexKeySize(secretKey); !ok {
+		return []string{"Must be a hex-string."}
+	} else if size != 32 {
+		return []string{"Must represent exactly 32 bytes (64 hex-characters)."}
+	}
+
+	return nil
+}
+
+// hexKeySize returns the size of a hex-encoded key without decoding it, so
+// validating keys leaves no copies of them behind. Its result never depends
+// on which characters are invalid, for the messages not to reveal them.
+func hexKeySize(hexKey string) (int, bool) {
+	valid := len(hexKey)%2 == 0
+
+	for i := 0; i < len(hexKey); i++ {
+		c := hexKey[i]
+		valid = valid && ('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F')
+	}
+
+	return len(hexKey) / 2, valid
+}
+
+func validateKeyID(keyID string) []string {
+	if !keyIDPattern.MatchString(keyID) {
+		return []string{"Must have up to 64 characters among letters, digits, '.', '_' and '-'."}
+	}
+
+	if keyID == reservedKeyID {
+		return []string{"Must not be \"" + reservedKeyID + "\", which is reserved for the customer keys."}
+	}
+
+	return nil
+}
+
+func addValidationErrors(validationErrors map[string]*[]string, key string, errors ...string) {
+	if len(errors) == 0 {
+		return
+	}
+
+	if _, ok := validationErrors[key]; !ok {
+		validationErrors[key] = &[]string{}
+	}
+
+	*validationErrors[key] = append(*validationErrors[key], errors...)
+}
+
 func validateBlankFields(fieldNameAndValue map[string]string) map[string]string {
 	validationResult := make(map[string]string)
 
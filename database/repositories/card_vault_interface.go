@@ -0,0 +1,11 @@
+package repositories
+
+import "crypto-challenge/entities"
+
+type CardVaultRepository interface {
+	Create(newEntry *entities.CardVaultEntry) (bool, error)
+	FindByToken(token string) (*entities.CardVaultEntry, error)
+	FindByPanIndex(panIndex string) (*entities.CardVaultEntry, error)
+	FindAfterToken(afterToken string, limit int) ([]*entities.CardVaultEntry, error)
+	ReplaceDataKey(current, updated *entities.CardVaultEntry) (bool, error)
+}
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"crypto-challenge/entities"
+	"database/sql"
+	"log"
+)
+
+const cardVaultColumns = "token, pan, data_key, pan_index"
+
+type CardVaultMySqlRepository struct {
+	db *sql.DB
+}
+
+func NewCardVaultMySqlRepository(db *sql.DB) *CardVaultMySqlRepository {
+	return &CardVaultMySqlRepository{db}
+}
+
+// Create returns false, without storing anything, when the token or the card
+// number are already in the vault.
+func (r *CardVaultMySqlRepository) Create(newEntry *entities.CardVaultEntry) (bool, error) {
+	query := "INSERT IGNORE INTO card_vault (" + cardVaultColumns + ") VALUES (?, ?, ?, ?)"
+
+	result, err := r.db.Exec(query, newEntry.Token, newEntry.Pan, newEntry.DataKey, newEntry.PanIndex)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affectedRows == 1, nil
+}
+
+func (r *CardVaultMySqlRepository) FindByToken(token string) (*entities.CardVaultEntry, error) {
+	query := "SELECT " + cardVaultColumns + " FROM card_vault WHERE token = ?"
+
+	return r.findOne(query, token)
+}
+
+func (r *CardVaultMySqlRepository) FindByPanIndex(panIndex string) (*entities.CardVaultEntry, error) {
+	query := "SELECT " + cardVaultColumns + " FROM card_vault WHERE pan_index = ?"
+
+	return r.findOne(query, panIndex)
+}
+
+func (r *CardVaultMySqlRepository) FindAfterToken(afterToken string, limit int) ([]*entities.CardVaultEntry, error) {
+	query := "SELECT " + cardVaultColumns + " FROM card_vault WHERE token > ? ORDER BY token LIMIT ?"
+
+	rows, err := r.db.Query(query, afterToken, limit)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	foundEntries := make([]*entities.CardVaultEntry, 0, limit)
+
+	for rows.Next() {
+		foundEntry, err := scanCardVaultEntry(rows)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		foundEntries = append(foundEntries, foundEntry)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return foundEntries, nil
+}
+
+// ReplaceDataKey swaps the wrapped data key of an entry only if it is still
+// the one in current.
+func (r *CardVaultMySqlRepository) ReplaceDataKey(current, updated *entities.CardVaultEntry) (bool, error) {
+	query := "UPDATE card_vault SET data_key = ? WHERE token = ? AND data_key = ?"
+
+	result, err := r.db.Exec(query, updated.DataKey, current.Token, current.DataKey)
+	if err != nil {
+		return false, err
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affectedRows == 1, nil
+}
+
+func (r *CardVaultMySqlRepository) findOne(query string, args ...any) (*entities.CardVaultEntry, error) {
+	foundEntry, err := scanCardVaultEntry(r.db.QueryRow(query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		log.Println(err)
+		return nil, err
+	}
+
+	return foundEntry, nil
+}
+
+func scanCardVaultEntry(row rowScanner) (*entities.CardVaultEntry, error) {
+	foundEntry := &entities.CardVaultEntry{}
+
+	err := row.Scan(&foundEntry.Token, &foundEntry.Pan, &foundEntry.DataKey, &foundEntry.PanIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundEntry, nil
+}
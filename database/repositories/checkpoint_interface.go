@@ -0,0 +1,7 @@
+package repositories
+
+type CheckpointRepository interface {
+	FindByName(name string) (string, error)
+	Save(name, position string) error
+	DeleteByName(name string) error
+}
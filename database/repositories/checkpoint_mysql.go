@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"database/sql"
+	"log"
+)
+
+type CheckpointMySqlRepository struct {
+	db *sql.DB
+}
+
+func NewCheckpointMySqlRepository(db *sql.DB) *CheckpointMySqlRepository {
+	return &CheckpointMySqlRepository{db}
+}
+
+func (r *CheckpointMySqlRepository) FindByName(name string) (string, error) {
+	query := "SELECT position FROM checkpoints WHERE name = ?"
+
+	var position string
+
+	err := r.db.QueryRow(query, name).Scan(&position)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+
+		log.Println(err)
+		return "", err
+	}
+
+	return position, nil
+}
+
+func (r *CheckpointMySqlRepository) Save(name, position string) error {
+	query := "INSERT INTO checkpoints (name, position) VALUES (?, ?) ON DUPLICATE KEY UPDATE position = VALUES(position)"
+
+	_, err := r.db.Exec(query, name, position)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return err
+}
+
+func (r *CheckpointMySqlRepository) DeleteByName(name string) error {
+	query := "DELETE FROM checkpoints WHERE name = ?"
+
+	_, err := r.db.Exec(query, name)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+package repositories
+
+import "crypto-challenge/entities"
+
+type CustomerKeyRepository interface {
+	FindByUserDocumentIndex(userDocumentIndex string) (*entities.CustomerKey, error)
+	Create(newCustomerKey *entities.CustomerKey) error
+	FindAfterUserDocumentIndex(afterUserDocumentIndex string, limit int) ([]*entities.CustomerKey, error)
+	ReplacePrivateKey(current, updated *entities.CustomerKey) (bool, error)
+	Erase(erasure *entities.CustomerErasure) error
+}
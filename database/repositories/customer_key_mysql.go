@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"crypto-challenge/entities"
+	"database/sql"
+	"log"
+)
+
+type CustomerKeyMySqlRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerKeyMySqlRepository(db *sql.DB) *CustomerKeyMySqlRepository {
+	return &CustomerKeyMySqlRepository{db}
+}
+
+func (r *CustomerKeyMySqlRepository) FindByUserDocumentIndex(userDocumentIndex string) (*entities.CustomerKey, error) {
+	query := "SELECT user_document_index, public_key, private_key FROM customer_keys WHERE user_document_index = ?"
+
+	foundCustomerKey := &entities.CustomerKey{}
+
+	err := r.db.QueryRow(query, userDocumentIndex).Scan(&foundCustomerKey.UserDocumentIndex,
+		&foundCustomerKey.PublicKey, &foundCustomerKey.PrivateKey)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		log.Println(err)
+		return nil, err
+	}
+
+	return foundCustomerKey, nil
+}
+
+// Create keeps the customer's existing key, if another one was created in
+// the meantime.
+func (r *CustomerKeyMySqlRepository) Create(newCustomerKey *entities.CustomerKey) error {
+	query := "INSERT IGNORE INTO customer_keys (user_document_index, public_key, private_key) VALUES (?, ?, ?)"
+
+	_, err := r.db.Exec(query, newCustomerKey.UserDocumentIndex, newCustomerKey.PublicKey, newCustomerKey.PrivateKey)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return err
+}
+
+func (r *CustomerKeyMySqlRepository) FindAfterUserDocumentIndex(afterUserDocumentIndex string, limit int) ([]*entities.CustomerKey, error) {
+	query := "SELECT user_document_index, public_key, private_key FROM customer_keys " +
+		"WHERE user_document_index > ? ORDER BY user_document_index LIMIT ?"
+
+	rows, err := r.db.Query(query, afterUserDocumentIndex, limit)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	foundCustomerKeys := make([]*entities.CustomerKey, 0, limit)
+
+	for rows.Next() {
+		foundCustomerKey := &entities.CustomerKey{}
+
+		err := rows.Scan(&foundCustomerKey.UserDocumentIndex, &foundCustomerKey.PublicKey, &foundCustomerKey.PrivateKey)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		foundCustomerKeys = append(foundCustomerKeys, foundCustomerKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return foundCustomerKeys, nil
+}
+
+// ReplacePrivateKey swaps the wrapped private key of a customer only if it
+// is still the one in current, it returns false when the key was erased in
+// the meantime.
+func (r *CustomerKeyMySqlRepository) ReplacePrivateKey(current, updated *entities.CustomerKey) (bool, error) {
+	query := "UPDATE customer_keys SET private_key = ? WHERE user_document_index = ? AND private_key = ?"
+
+	result, err := r.db.Exec(query, updated.PrivateKey, current.UserDocumentIndex, current.PrivateKey)
+	if err != nil {
+		return false, err
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affectedRows == 1, nil
+}
+
+// Erase destroys the customer's key, blanks the encrypted fields of their
+// transactions, which also covers the ones not under their key yet, and
+// records the erasure, all or nothing. It fills in KeyDestroyed and
+// ErasedTransactions. The backups taken before keep both the key and the
+// fields, readable with the master key.
+func (r *CustomerKeyMySqlRepository) Erase(erasure *entities.CustomerErasure) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM customer_keys WHERE user_document_index = ?", erasure.UserDocumentIndex)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	destroyedKeys, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	result, err = tx.Exec("UPDATE transactions SET user_document = '', credit_card_token = '', encrypted_value = '', "+
+		"data_key = '', user_document_index = '', credit_card_token_last4_index = '' "+
+		"WHERE user_document_index = ?", erasure.UserDocumentIndex)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	erasedTransactions, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	erasure.KeyDestroyed = destroyedKeys == 1
+	erasure.ErasedTransactions = erasedTransactions
+
+	query := "INSERT INTO customer_erasures (id, user_document_index, reason, key_destroyed, erased_transactions, erased_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?)"
+
+	_, err = tx.Exec(query, erasure.ID, erasure.UserDocumentIndex, erasure.Reason, erasure.KeyDestroyed,
+		erasure.ErasedTransactions, erasure.ErasedAt)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,166 @@
+package repositories_test
+
+import (
+	"context"
+	"crypto-challenge/config"
+	"crypto-challenge/database/repositories"
+	"crypto-challenge/entities"
+	"crypto-challenge/testhelpers"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type CustomerKeyMySqlIntTestSuite struct {
+	suite.Suite
+	terminateMySqlContainer *func()
+	db                      *sql.DB
+	underTest               *repositories.CustomerKeyMySqlRepository
+}
+
+func (ts *CustomerKeyMySqlIntTestSuite) SetupSuite() {
+	dotenvFilePath, err := filepath.Abs(filepath.Join("..", "..", ".env"))
+	if err != nil {
+		ts.T().Fatal(err)
+	}
+
+	cfg := config.GetAppConfig(dotenvFilePath, "")
+
+	migrationsFolderPath, err := filepath.Abs(filepath.Join("..", "..", ".docker", "sql"))
+	if err != nil {
+		ts.T().Fatal(err)
+	}
+
+	mySqlC, terminateMySqlC, ctxMySqlC := testhelpers.SetupMySqlContainer(cfg, migrationsFolderPath)
+
+	ts.terminateMySqlContainer = terminateMySqlC
+
+	db := testhelpers.GetMySqlContainerDB(ts.T(), mySqlC, ctxMySqlC, cfg)
+
+	ts.db = db
+
+	ts.underTest = repositories.NewCustomerKeyMySqlRepository(db)
+}
+
+func (ts *CustomerKeyMySqlIntTestSuite) TearDownSuite() {
+	(*ts.terminateMySqlContainer)()
+	ts.db.Close()
+}
+
+func (ts *CustomerKeyMySqlIntTestSuite) SetupTest() {
+	for _, table := range []string{"customer_keys", "customer_erasures", "transactions"} {
+		_, err := ts.db.Exec("DELETE FROM " + table)
+		ts.Nil(err)
+	}
+}
+
+func (ts *CustomerKeyMySqlIntTestSuite) TestCreate_KeepsExistingKey() {
+	//given
+	existing := createCustomerKey("index")
+	ts.Nil(ts.underTest.Create(existing))
+
+	//when
+	err := ts.underTest.Create(createCustomerKey("index"))
+
+	//then
+	ts.Nil(err)
+
+	actual, err := ts.underTest.FindByUserDocumentIndex("index")
+	ts.Nil(err)
+	ts.Equal(existing, actual)
+}
+
+func (ts *CustomerKeyMySqlIntTestSuite) TestFindByUserDocumentIndex_WhenNotFound() {
+	//when
+	actual, err := ts.underTest.FindByUserDocumentIndex("index")
+
+	//then
+	ts.Nil(err)
+	ts.Nil(actual)
+}
+
+func (ts *CustomerKeyMySqlIntTestSuite) TestReplacePrivateKey() {
+	//given
+	current := createCustomerKey("index")
+	ts.Nil(ts.underTest.Create(current))
+
+	updated := *current
+	updated.PrivateKey = "v2:k2:aes-256-gcm:00:00"
+
+	//when
+	replaced, err := ts.underTest.ReplacePrivateKey(current, &updated)
+	ts.Nil(err)
+
+	replacedAgain, err := ts.underTest.ReplacePrivateKey(current, &updated)
+	ts.Nil(err)
+
+	//then
+	ts.True(replaced)
+	ts.False(replacedAgain)
+
+	actual, err := ts.underTest.FindByUserDocumentIndex("index")
+	ts.Nil(err)
+	ts.Equal(&updated, actual)
+}
+
+func (ts *CustomerKeyMySqlIntTestSuite) TestErase() {
+	//given
+	ts.Nil(ts.underTest.Create(createCustomerKey("index")))
+
+	transactions := repositories.NewTransactionMySqlRepository(ts.db)
+
+	forgotten, kept := createTransaction(), createTransaction()
+	forgotten.DataKey, forgotten.UserDocumentIndex = "v2:customer:x25519-aes-256-gcm:00:00", "index"
+	kept.DataKey, kept.UserDocumentIndex = "v2:customer:x25519-aes-256-gcm:00:00", "another-index"
+	ts.Nil(transactions.Create(context.Background(), &forgotten))
+	ts.Nil(transactions.Create(context.Background(), &kept))
+
+	erasure := &entities.CustomerErasure{
+		ID:                uuid.NewString(),
+		UserDocumentIndex: "index",
+		Reason:            "LGPD request",
+		ErasedAt:          time.Now().UTC(),
+	}
+
+	//when
+	err := ts.underTest.Erase(erasure)
+
+	//then
+	ts.Nil(err)
+	ts.True(erasure.KeyDestroyed)
+	ts.EqualValues(1, erasure.ErasedTransactions)
+
+	customerKey, err := ts.underTest.FindByUserDocumentIndex("index")
+	ts.Nil(err)
+	ts.Nil(customerKey)
+
+	actualForgotten, err := transactions.FindByID(context.Background(), forgotten.ID)
+	ts.Nil(err)
+	ts.Equal(&entities.Transaction{ID: forgotten.ID, Value: forgotten.Value, CreatedAt: forgotten.CreatedAt}, actualForgotten)
+
+	actualKept, err := transactions.FindByID(context.Background(), kept.ID)
+	ts.Nil(err)
+	ts.Equal(&kept, actualKept)
+
+	var audited int
+	err = ts.db.QueryRow("SELECT COUNT(*) FROM customer_erasures WHERE id = ? AND key_destroyed AND erased_transactions = 1",
+		erasure.ID).Scan(&audited)
+	ts.Nil(err)
+	ts.Equal(1, audited)
+}
+
+func TestCustomerKeyMySqlIntTestSuite(t *testing.T) {
+	suite.Run(t, new(CustomerKeyMySqlIntTestSuite))
+}
+
+func createCustomerKey(userDocumentIndex string) *entities.CustomerKey {
+	return &entities.CustomerKey{
+		UserDocumentIndex: userDocumentIndex,
+		PublicKey:         "de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f",
+		PrivateKey:        "v2:k1:aes-256-gcm:" + uuid.NewString(),
+	}
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+type TransactionSortField string
+
+const (
+	SortByCreatedAt TransactionSortField = "createdAt"
+	SortByValue     TransactionSortField = "value"
+)
+
+// TransactionFilter narrows and orders the transactions found by
+// FindByFilter, the zero filter finding every transaction by creation time.
+// The bounds are inclusive and the unset ones, nil or empty, don't filter.
+type TransactionFilter struct {
+	UserDocumentIndex         string
+	CreditCardTokenLast4Index string
+
+	// The encrypted values can't be compared by the database, so the value
+	// bounds let through every transaction whose value is encrypted, or only
+	// the ones in the buckets holding the bounds when ValueBucketWidth is the
+	// width they were bucketed by. See MatchesValue.
+	MinValue         *float64
+	MaxValue         *float64
+	ValueBucketWidth float64
+
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+
+	SortBy     TransactionSortField
+	Descending bool
+}
+
+// HasValueBounds tells whether the transactions found must be filtered by
+// MatchesValue once decrypted.
+func (f *TransactionFilter) HasValueBounds() bool {
+	return f.MinValue != nil || f.MaxValue != nil
+}
+
+func (f *TransactionFilter) MatchesValue(value float64) bool {
+	return (f.MinValue == nil || value >= *f.MinValue) && (f.MaxValue == nil || value <= *f.MaxValue)
+}
+
+// where returns the conditions of the filter, with their arguments, and of
+// the page after the cursor, when there's one.
+func (f *TransactionFilter) where(after *TransactionCursor) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if f.UserDocumentIndex != "" {
+		conditions = append(conditions, "user_document_index = ?")
+		args = append(args, f.UserDocumentIndex)
+	}
+
+	if f.CreditCardTokenLast4Index != "" {
+		conditions = append(conditions, "credit_card_token_last4_index = ?")
+		args = append(args, f.CreditCardTokenLast4Index)
+	}
+
+	if f.MinValue != nil {
+		condition, valueArgs := f.valueBound(">=", *f.MinValue)
+		conditions = append(conditions, condition)
+		args = append(args, valueArgs...)
+	}
+
+	if f.MaxValue != nil {
+		condition, valueArgs := f.valueBound("<=", *f.MaxValue)
+		conditions = append(conditions, condition)
+		args = append(args, valueArgs...)
+	}
+
+	if f.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *f.CreatedFrom)
+	}
+
+	if f.CreatedTo != nil {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, *f.CreatedTo)
+	}
+
+	if after != nil {
+		condition, afterArgs := f.after(after)
+		conditions = append(conditions, condition)
+		args = append(args, afterArgs...)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (f *TransactionFilter) valueBound(comparison string, bound float64) (string, []any) {
+	if f.ValueBucketWidth <= 0 {
+		return "(`value` " + comparison + " ? OR `value` IS NULL)", []any{bound}
+	}
+
+	bucket := int64(math.Floor(bound / f.ValueBucketWidth))
+
+	return "(`value` " + comparison + " ? OR (`value` IS NULL AND (value_bucket IS NULL OR value_bucket " +
+		comparison + " ?)))", []any{bound, bucket}
+}
+
+// after returns the condition of the transactions following the cursor. The
+// values are sorted as MySQL sorts them, NULL before any value, so the NULL
+// values, encrypted or erased, have their own branch: they only follow each
+// other, by ID, and come before the others in ascending order, after them in
+// descending order.
+func (f *TransactionFilter) after(cursor *TransactionCursor) (string, []any) {
+	column, comparison := f.sortColumn(), ">"
+	if f.Descending {
+		comparison = "<"
+	}
+
+	if f.SortBy != SortByValue {
+		return "(" + column + " " + comparison + " ? OR (" + column + " = ? AND id " + comparison + " ?))",
+			[]any{cursor.CreatedAt, cursor.CreatedAt, cursor.ID}
+	}
+
+	switch {
+	case cursor.NullValue && f.Descending:
+		return "(" + column + " IS NULL AND id < ?)", []any{cursor.ID}
+	case cursor.NullValue:
+		return "(" + column + " IS NOT NULL OR id > ?)", []any{cursor.ID}
+	case f.Descending:
+		return "(" + column + " < ? OR (" + column + " = ? AND id < ?) OR " + column + " IS NULL)",
+			[]any{cursor.Value, cursor.Value, cursor.ID}
+	default:
+		return "(" + column + " > ? OR (" + column + " = ? AND id > ?))", []any{cursor.Value, cursor.Value, cursor.ID}
+	}
+}
+
+// orderBy matches the indexes on (created_at, id) and (value, id), the ID
+// keeping the order stable among equal keys.
+func (f *TransactionFilter) orderBy() string {
+	direction := ""
+	if f.Descending {
+		direction = " DESC"
+	}
+
+	return " ORDER BY " + f.sortColumn() + direction + ", id" + direction
+}
+
+func (f *TransactionFilter) sortColumn() string {
+	if f.SortBy == SortByValue {
+		return "`value`"
+	}
+
+	return "created_at"
+}
@@ -1,11 +1,42 @@
 package repositories
 
-import "crypto-challenge/entities"
+import (
+	"context"
+	"crypto-challenge/entities"
+	"time"
+)
+
+// TransactionCursor is the position of a transaction in the listing order, by
+// creation time or value, then ID. NullValue places it among the transactions
+// whose value column is NULL, the ones whose value is encrypted or was erased.
+type TransactionCursor struct {
+	CreatedAt time.Time
+	Value     float64
+	NullValue bool
+	ID        string
+}
+
+// TransactionIterator reads the transactions found one at a time, as
+// sql.Rows does, and must be closed.
+type TransactionIterator interface {
+	Next() bool
+	Transaction() *entities.Transaction
+	Err() error
+	Close() error
+}
 
 type TransactionRepository interface {
-	Create(newTransaction *entities.Transaction) error
-	FindByID(idToSearch string) (*entities.Transaction, error)
-	FindAll() ([]*entities.Transaction, error)
-	UpdateByID(updatedTransaction *entities.Transaction) error
-	DeleteByID(idToDelete string) error
+	Create(ctx context.Context, newTransaction *entities.Transaction) error
+	FindByID(ctx context.Context, idToSearch string) (*entities.Transaction, error)
+	FindAll(ctx context.Context, after *TransactionCursor, limit int) ([]*entities.Transaction, error)
+	FindByUserDocument(ctx context.Context, userDocumentIndex string, after *TransactionCursor,
+		limit int) ([]*entities.Transaction, error)
+	FindByFilter(ctx context.Context, filter *TransactionFilter, after *TransactionCursor,
+		limit int) ([]*entities.Transaction, error)
+	IterateByFilter(ctx context.Context, filter *TransactionFilter) (TransactionIterator, error)
+	FindAfterID(ctx context.Context, afterID string, limit int) ([]*entities.Transaction, error)
+	UpdateByID(ctx context.Context, updatedTransaction *entities.Transaction) error
+	ReplaceEncryptedFieldsByID(ctx context.Context, current, updated *entities.Transaction) (bool, error)
+	CountByValueBucket(ctx context.Context, bucketWidth float64) (map[int64]int, error)
+	DeleteByID(ctx context.Context, idToDelete string) error
 }
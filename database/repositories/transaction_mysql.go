@@ -1,24 +1,50 @@
 package repositories
 
 import (
+	"context"
 	"crypto-challenge/entities"
 	"database/sql"
 	"log"
+	"time"
+)
+
+const (
+	transactionColumns = "id, user_document, credit_card_token, `value`, encrypted_value, value_bucket, data_key, " +
+		"user_document_index, credit_card_token_last4_index, created_at"
 )
 
 type TransactionMySqlRepository struct {
-	db *sql.DB
+	db           *sql.DB
+	readTimeout  time.Duration
+	writeTimeout time.Duration
 }
 
 func NewTransactionMySqlRepository(db *sql.DB) *TransactionMySqlRepository {
-	return &TransactionMySqlRepository{db}
+	return &TransactionMySqlRepository{db: db}
+}
+
+// UseTimeouts bounds each query reading and each statement writing
+// transactions, on top of the caller's context. A timeout that isn't positive
+// leaves the context alone.
+func (r *TransactionMySqlRepository) UseTimeouts(readTimeout, writeTimeout time.Duration) {
+	r.readTimeout = readTimeout
+	r.writeTimeout = writeTimeout
 }
 
-func (r *TransactionMySqlRepository) Create(newTransaction *entities.Transaction) error {
-	query := "INSERT INTO transactions (id, user_document, credit_card_token, `value`) VALUES (?, ?, ?, ?)"
+func (r *TransactionMySqlRepository) Create(ctx context.Context, newTransaction *entities.Transaction) error {
+	query := "INSERT INTO transactions (" + transactionColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 
-	_, err := r.db.Exec(query, newTransaction.ID, newTransaction.UserDocument,
-		newTransaction.CreditCardToken, newTransaction.Value)
+	// Always stamped here, so no caller can backdate a transaction or place it
+	// anywhere in the listings. Truncated to the precision of created_at, so the
+	// cursors built from the transaction match the stored one.
+	newTransaction.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	ctx, cancel := withTimeout(ctx, r.writeTimeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, newTransaction.ID, newTransaction.UserDocument, newTransaction.CreditCardToken,
+		clearValue(newTransaction), newTransaction.EncryptedValue, newTransaction.ValueBucket, newTransaction.DataKey,
+		newTransaction.UserDocumentIndex, newTransaction.CreditCardTokenLast4Index, newTransaction.CreatedAt)
 	if err != nil {
 		log.Println(err)
 	}
@@ -26,15 +52,13 @@ func (r *TransactionMySqlRepository) Create(newTransaction *entities.Transaction
 	return err
 }
 
-func (r *TransactionMySqlRepository) FindByID(idToSearch string) (*entities.Transaction, error) {
-	query := "SELECT id, user_document, credit_card_token, `value` FROM transactions WHERE id = ?"
+func (r *TransactionMySqlRepository) FindByID(ctx context.Context, idToSearch string) (*entities.Transaction, error) {
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE id = ?"
 
-	var (
-		id, userDocument, creditCardToken string
-		value                             float64
-	)
+	ctx, cancel := withTimeout(ctx, r.readTimeout)
+	defer cancel()
 
-	err := r.db.QueryRow(query, idToSearch).Scan(&id, &userDocument, &creditCardToken, &value)
+	foundTransaction, err := scanTransaction(r.db.QueryRowContext(ctx, query, idToSearch))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -44,18 +68,110 @@ func (r *TransactionMySqlRepository) FindByID(idToSearch string) (*entities.Tran
 		return nil, err
 	}
 
-	return &entities.Transaction{
-		ID:              id,
-		UserDocument:    userDocument,
-		CreditCardToken: creditCardToken,
-		Value:           value,
-	}, nil
+	return foundTransaction, nil
+}
+
+// FindAll returns up to limit transactions, in creation order, after the
+// cursor or from the first one when it's nil.
+func (r *TransactionMySqlRepository) FindAll(ctx context.Context, after *TransactionCursor,
+	limit int) ([]*entities.Transaction, error) {
+	return r.FindByFilter(ctx, &TransactionFilter{}, after, limit)
+}
+
+func (r *TransactionMySqlRepository) FindByUserDocument(ctx context.Context, userDocumentIndex string,
+	after *TransactionCursor, limit int) ([]*entities.Transaction, error) {
+	return r.FindByFilter(ctx, &TransactionFilter{UserDocumentIndex: userDocumentIndex}, after, limit)
+}
+
+// FindByFilter returns up to limit transactions matching the filter, in its
+// order, after the cursor or from the first one when it's nil.
+func (r *TransactionMySqlRepository) FindByFilter(ctx context.Context, filter *TransactionFilter,
+	after *TransactionCursor, limit int) ([]*entities.Transaction, error) {
+	where, args := filter.where(after)
+	query := "SELECT " + transactionColumns + " FROM transactions" + where + filter.orderBy() + " LIMIT ?"
+
+	return r.findMany(ctx, query, limit, append(args, limit)...)
+}
+
+// IterateByFilter finds every transaction matching the filter, in its order,
+// reading them as they're iterated. The read timeout doesn't apply, since the
+// iteration lasts as long as the caller takes, ctx bounds it instead.
+func (r *TransactionMySqlRepository) IterateByFilter(ctx context.Context, filter *TransactionFilter) (TransactionIterator, error) {
+	where, args := filter.where(nil)
+	query := "SELECT " + transactionColumns + " FROM transactions" + where + filter.orderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return &transactionRows{rows: rows}, nil
+}
+
+func (r *TransactionMySqlRepository) FindAfterID(ctx context.Context, afterID string, limit int) ([]*entities.Transaction, error) {
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE id > ? ORDER BY id LIMIT ?"
+
+	return r.findMany(ctx, query, limit, afterID, limit)
+}
+
+func (r *TransactionMySqlRepository) UpdateByID(ctx context.Context, updatedTransaction *entities.Transaction) error {
+	query := "UPDATE transactions SET user_document = ?, credit_card_token = ?, `value` = ?, encrypted_value = ?, " +
+		"value_bucket = ?, data_key = ?, user_document_index = ?, credit_card_token_last4_index = ?  WHERE id = ?"
+
+	ctx, cancel := withTimeout(ctx, r.writeTimeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, updatedTransaction.UserDocument, updatedTransaction.CreditCardToken,
+		clearValue(updatedTransaction), updatedTransaction.EncryptedValue, updatedTransaction.ValueBucket,
+		updatedTransaction.DataKey, updatedTransaction.UserDocumentIndex, updatedTransaction.CreditCardTokenLast4Index,
+		updatedTransaction.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReplaceEncryptedFieldsByID swaps the encrypted fields of a transaction only if
+// they still hold the values in current, it returns false when they were
+// changed or the transaction was deleted in the meantime. The value and its
+// bucket are replaced too, since re-encrypting encrypts the values left in
+// clear when values are encrypted.
+func (r *TransactionMySqlRepository) ReplaceEncryptedFieldsByID(ctx context.Context, current, updated *entities.Transaction) (bool, error) {
+	query := "UPDATE transactions SET user_document = ?, credit_card_token = ?, `value` = ?, encrypted_value = ?, " +
+		"value_bucket = ?, data_key = ?, user_document_index = ?, credit_card_token_last4_index = ? " +
+		"WHERE id = ? AND user_document = ? AND credit_card_token = ? AND encrypted_value = ? AND data_key = ?"
+
+	ctx, cancel := withTimeout(ctx, r.writeTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, updated.UserDocument, updated.CreditCardToken, clearValue(updated),
+		updated.EncryptedValue, updated.ValueBucket, updated.DataKey, updated.UserDocumentIndex,
+		updated.CreditCardTokenLast4Index, current.ID, current.UserDocument, current.CreditCardToken, current.EncryptedValue, current.DataKey)
+	if err != nil {
+		return false, err
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affectedRows == 1, nil
 }
 
-func (r *TransactionMySqlRepository) FindAll() ([]*entities.Transaction, error) {
-	query := "SELECT id, user_document, credit_card_token, `value` FROM transactions"
+// CountByValueBucket counts the transactions by the bucket of the given width
+// their value falls in, whether the value is encrypted or not. The buckets of
+// the encrypted values are the ones stored when they were encrypted.
+func (r *TransactionMySqlRepository) CountByValueBucket(ctx context.Context, bucketWidth float64) (map[int64]int, error) {
+	query := "SELECT COALESCE(value_bucket, FLOOR(`value` / ?)) AS bucket, COUNT(*) FROM transactions " +
+		"WHERE value_bucket IS NOT NULL OR `value` IS NOT NULL GROUP BY bucket"
+
+	ctx, cancel := withTimeout(ctx, r.readTimeout)
+	defer cancel()
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query, bucketWidth)
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -63,22 +179,66 @@ func (r *TransactionMySqlRepository) FindAll() ([]*entities.Transaction, error)
 
 	defer rows.Close()
 
-	foundTransactions := make([]*entities.Transaction, 0, 5)
+	countByBucket := make(map[int64]int)
 
 	for rows.Next() {
-		var (
-			id, userDocument, creditCardToken string
-			value                             float64
-		)
+		var bucket int64
+		var count int
+
+		if err := rows.Scan(&bucket, &count); err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		countByBucket[bucket] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return countByBucket, nil
+}
+
+func (r *TransactionMySqlRepository) DeleteByID(ctx context.Context, idToDelete string) error {
+	query := "DELETE FROM transactions WHERE id = ?"
+
+	ctx, cancel := withTimeout(ctx, r.writeTimeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, idToDelete)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// findMany reads every row within the read timeout, not only the query.
+func (r *TransactionMySqlRepository) findMany(ctx context.Context, query string, expectedCount int,
+	args ...any) ([]*entities.Transaction, error) {
+	ctx, cancel := withTimeout(ctx, r.readTimeout)
+	defer cancel()
 
-		err = rows.Scan(&id, &userDocument, &creditCardToken, &value)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	foundTransactions := make([]*entities.Transaction, 0, expectedCount)
+
+	for rows.Next() {
+		foundTransaction, err := scanTransaction(rows)
 		if err != nil {
 			log.Println(err)
 			return nil, err
 		}
 
-		foundTransactions = append(foundTransactions,
-			&entities.Transaction{ID: id, UserDocument: userDocument, CreditCardToken: creditCardToken, Value: value})
+		foundTransactions = append(foundTransactions, foundTransaction)
 	}
 
 	rows.Close()
@@ -91,24 +251,91 @@ func (r *TransactionMySqlRepository) FindAll() ([]*entities.Transaction, error)
 	return foundTransactions, nil
 }
 
-func (r *TransactionMySqlRepository) UpdateByID(updatedTransaction *entities.Transaction) error {
-	query := "UPDATE transactions SET user_document = ?, credit_card_token = ?, `value` = ?  WHERE id = ?"
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
 
-	_, err := r.db.Exec(query, updatedTransaction.UserDocument, updatedTransaction.CreditCardToken, updatedTransaction.Value, updatedTransaction.ID)
-	if err != nil {
-		return err
+	return context.WithTimeout(ctx, timeout)
+}
+
+type transactionRows struct {
+	rows    *sql.Rows
+	current *entities.Transaction
+	err     error
+}
+
+func (tr *transactionRows) Next() bool {
+	if tr.err != nil || !tr.rows.Next() {
+		tr.current = nil
+		return false
 	}
 
-	return nil
+	tr.current, tr.err = scanTransaction(tr.rows)
+
+	return tr.err == nil
 }
 
-func (r *TransactionMySqlRepository) DeleteByID(idToDelete string) error {
-	query := "DELETE FROM transactions WHERE id = ?"
+func (tr *transactionRows) Transaction() *entities.Transaction {
+	return tr.current
+}
+
+func (tr *transactionRows) Err() error {
+	if tr.err != nil {
+		return tr.err
+	}
+
+	return tr.rows.Err()
+}
+
+func (tr *transactionRows) Close() error {
+	return tr.rows.Close()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
-	_, err := r.db.Exec(query, idToDelete)
+func scanTransaction(row rowScanner) (*entities.Transaction, error) {
+	var (
+		id, userDocument, creditCardToken, encryptedValue, dataKey string
+		userDocumentIndex, creditCardTokenLast4Index               string
+		value                                                      sql.NullFloat64
+		valueBucket                                                sql.NullInt64
+		createdAt                                                  time.Time
+	)
+
+	err := row.Scan(&id, &userDocument, &creditCardToken, &value, &encryptedValue, &valueBucket, &dataKey,
+		&userDocumentIndex, &creditCardTokenLast4Index, &createdAt)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	transaction := &entities.Transaction{
+		ID:                        id,
+		UserDocument:              userDocument,
+		CreditCardToken:           creditCardToken,
+		Value:                     value.Float64,
+		EncryptedValue:            encryptedValue,
+		DataKey:                   dataKey,
+		UserDocumentIndex:         userDocumentIndex,
+		CreatedAt:                 createdAt,
+		CreditCardTokenLast4Index: creditCardTokenLast4Index,
+		NullValue:                 !value.Valid,
+	}
+
+	if valueBucket.Valid {
+		transaction.ValueBucket = &valueBucket.Int64
+	}
+
+	return transaction, nil
+}
+
+// clearValue returns the value to store in clear, none when it's encrypted.
+func clearValue(transaction *entities.Transaction) *float64 {
+	if transaction.EncryptedValue != "" {
+		return nil
+	}
+
+	return &transaction.Value
 }
@@ -1,6 +1,7 @@
 package repositories_test
 
 import (
+	"context"
 	"crypto-challenge/config"
 	"crypto-challenge/database/repositories"
 	"crypto-challenge/entities"
@@ -8,6 +9,7 @@ import (
 	"database/sql"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
@@ -26,7 +28,7 @@ func (ts *TransactionMySqlIntTestSuite) SetupSuite() {
 		ts.T().Fatal(err)
 	}
 
-	cfg := config.GetAppConfig(dotenvFilePath)
+	cfg := config.GetAppConfig(dotenvFilePath, "")
 
 	migrationsFolderPath, err := filepath.Abs(filepath.Join("..", "..", ".docker", "sql"))
 	if err != nil {
@@ -59,16 +61,17 @@ func (ts *TransactionMySqlIntTestSuite) TestCreate() {
 	expected := createTransaction()
 
 	//when
-	err := ts.underTest.Create(&expected)
+	err := ts.underTest.Create(context.Background(), &expected)
 	ts.Nil(err)
 
 	//then
 	actual := entities.Transaction{}
-	err = ts.db.QueryRow("SELECT id, user_document, credit_card_token, `value` FROM transactions WHERE id = ?", expected.ID).Scan(
+	err = ts.db.QueryRow("SELECT id, user_document, credit_card_token, `value`, created_at FROM transactions WHERE id = ?", expected.ID).Scan(
 		&actual.ID,
 		&actual.UserDocument,
 		&actual.CreditCardToken,
 		&actual.Value,
+		&actual.CreatedAt,
 	)
 	ts.Nil(err)
 
@@ -78,12 +81,12 @@ func (ts *TransactionMySqlIntTestSuite) TestCreate() {
 func (ts *TransactionMySqlIntTestSuite) TestFindByID() {
 	//given
 	expected := createTransaction()
-	_, err := ts.db.Exec("INSERT INTO transactions (id, user_document, credit_card_token, `value`) VALUES (?, ?, ?, ?)",
-		expected.ID, expected.UserDocument, expected.CreditCardToken, expected.Value)
+	_, err := ts.db.Exec("INSERT INTO transactions (id, user_document, credit_card_token, `value`, created_at) VALUES (?, ?, ?, ?, ?)",
+		expected.ID, expected.UserDocument, expected.CreditCardToken, expected.Value, expected.CreatedAt)
 	ts.Nil(err)
 
 	//when
-	actual, err := ts.underTest.FindByID(expected.ID)
+	actual, err := ts.underTest.FindByID(context.Background(), expected.ID)
 	ts.Nil(err)
 
 	//then
@@ -95,7 +98,7 @@ func (ts *TransactionMySqlIntTestSuite) TestFindByID_WhenNotFound() {
 	idToSearch := uuid.NewString()
 
 	//when
-	actual, err := ts.underTest.FindByID(idToSearch)
+	actual, err := ts.underTest.FindByID(context.Background(), idToSearch)
 	ts.Nil(err)
 
 	//then
@@ -112,7 +115,7 @@ func (ts *TransactionMySqlIntTestSuite) TestFindAll() {
 	ts.Nil(err)
 
 	//when
-	actual, err := ts.underTest.FindAll()
+	actual, err := ts.underTest.FindAll(context.Background(), nil, 10)
 	ts.Nil(err)
 
 	//then
@@ -125,15 +128,300 @@ func (ts *TransactionMySqlIntTestSuite) TestFindAll() {
 	}
 }
 
+func (ts *TransactionMySqlIntTestSuite) TestFindAll_WithCursor() {
+	//given
+	createdAt := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+	expected1, expected2, expected3 := createTransaction(), createTransaction(), createTransaction()
+	expected1.CreatedAt = createdAt
+	expected2.CreatedAt, expected3.CreatedAt = createdAt.Add(time.Second), createdAt.Add(time.Second)
+
+	if expected3.ID < expected2.ID {
+		expected2, expected3 = expected3, expected2
+	}
+
+	for _, transaction := range []*entities.Transaction{&expected3, &expected1, &expected2} {
+		ts.createAt(transaction, transaction.CreatedAt)
+	}
+
+	//when
+	firstPage, err := ts.underTest.FindAll(context.Background(), nil, 2)
+	ts.Nil(err)
+
+	secondPage, err := ts.underTest.FindAll(context.Background(), &repositories.TransactionCursor{
+		CreatedAt: firstPage[1].CreatedAt,
+		ID:        firstPage[1].ID,
+	}, 2)
+	ts.Nil(err)
+
+	//then
+	ts.Equal([]*entities.Transaction{&expected1, &expected2}, firstPage)
+	ts.Equal([]*entities.Transaction{&expected3}, secondPage)
+}
+
+func (ts *TransactionMySqlIntTestSuite) TestFindByFilter() {
+	//given
+	createdAt := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	bucket := int64(2)
+
+	inRange, outOfRange, encrypted, otherCard, old := createTransaction(), createTransaction(), createTransaction(),
+		createTransaction(), createTransaction()
+	inRange.Value, outOfRange.Value, otherCard.Value, old.Value = 120, 180, 130, 130
+	encrypted.Value, encrypted.EncryptedValue, encrypted.ValueBucket, encrypted.NullValue = 0, "v2:...", &bucket, true
+	otherCard.CreditCardTokenLast4Index = "other index"
+	old.CreatedAt = createdAt.AddDate(0, -1, 0)
+
+	for i, transaction := range []*entities.Transaction{&inRange, &outOfRange, &encrypted, &otherCard, &old} {
+		if transaction.CreditCardTokenLast4Index == "" {
+			transaction.CreditCardTokenLast4Index = "index"
+		}
+
+		if transaction != &old {
+			transaction.CreatedAt = createdAt.Add(time.Duration(i) * time.Second)
+		}
+
+		ts.createAt(transaction, transaction.CreatedAt)
+	}
+
+	minValue, maxValue := 110.0, 150.0
+	filter := &repositories.TransactionFilter{
+		CreditCardTokenLast4Index: "index",
+		MinValue:                  &minValue,
+		MaxValue:                  &maxValue,
+		ValueBucketWidth:          50,
+		CreatedFrom:               &createdAt,
+	}
+
+	//when
+	actual, err := ts.underTest.FindByFilter(context.Background(), filter, nil, 10)
+	ts.Nil(err)
+
+	//then
+	ts.Equal([]*entities.Transaction{&inRange, &encrypted}, actual)
+}
+
+func (ts *TransactionMySqlIntTestSuite) TestFindByFilter_SortedByValue() {
+	//given
+	cheap, expensive, mid := createTransaction(), createTransaction(), createTransaction()
+	cheap.Value, mid.Value, expensive.Value = 10, 500, 1000
+
+	for _, transaction := range []*entities.Transaction{&cheap, &expensive, &mid} {
+		ts.Nil(ts.underTest.Create(context.Background(), transaction))
+	}
+
+	filter := &repositories.TransactionFilter{SortBy: repositories.SortByValue, Descending: true}
+
+	//when
+	firstPage, err := ts.underTest.FindByFilter(context.Background(), filter, nil, 2)
+	ts.Nil(err)
+
+	secondPage, err := ts.underTest.FindByFilter(context.Background(), filter, &repositories.TransactionCursor{
+		Value: firstPage[1].Value,
+		ID:    firstPage[1].ID,
+	}, 2)
+	ts.Nil(err)
+
+	//then
+	ts.Equal([]*entities.Transaction{&expensive, &mid}, firstPage)
+	ts.Equal([]*entities.Transaction{&cheap}, secondPage)
+}
+
+func (ts *TransactionMySqlIntTestSuite) TestFindByFilter_SortedByValueWithNullValues() {
+	//given
+	cheap, expensive, encrypted, otherEncrypted := createTransaction(), createTransaction(), createTransaction(),
+		createTransaction()
+	cheap.Value, expensive.Value = 10, 1000
+
+	for _, transaction := range []*entities.Transaction{&encrypted, &otherEncrypted} {
+		transaction.Value, transaction.EncryptedValue, transaction.NullValue = 0, "v2:...", true
+	}
+
+	if otherEncrypted.ID < encrypted.ID {
+		encrypted, otherEncrypted = otherEncrypted, encrypted
+	}
+
+	for _, transaction := range []*entities.Transaction{&cheap, &encrypted, &expensive, &otherEncrypted} {
+		ts.Nil(ts.underTest.Create(context.Background(), transaction))
+	}
+
+	ascending := &repositories.TransactionFilter{SortBy: repositories.SortByValue}
+	descending := &repositories.TransactionFilter{SortBy: repositories.SortByValue, Descending: true}
+
+	//when
+	firstAscending, err := ts.underTest.FindByFilter(context.Background(), ascending, nil, 2)
+	ts.Nil(err)
+
+	secondAscending, err := ts.underTest.FindByFilter(context.Background(), ascending, valueCursor(firstAscending[1]), 2)
+	ts.Nil(err)
+
+	firstDescending, err := ts.underTest.FindByFilter(context.Background(), descending, nil, 2)
+	ts.Nil(err)
+
+	secondDescending, err := ts.underTest.FindByFilter(context.Background(), descending, valueCursor(firstDescending[1]), 1)
+	ts.Nil(err)
+
+	thirdDescending, err := ts.underTest.FindByFilter(context.Background(), descending, valueCursor(secondDescending[0]), 2)
+	ts.Nil(err)
+
+	//then
+	ts.Equal([]*entities.Transaction{&encrypted, &otherEncrypted}, firstAscending)
+	ts.Equal([]*entities.Transaction{&cheap, &expensive}, secondAscending)
+	ts.Equal([]*entities.Transaction{&expensive, &cheap}, firstDescending)
+	ts.Equal([]*entities.Transaction{&otherEncrypted}, secondDescending)
+	ts.Equal([]*entities.Transaction{&encrypted}, thirdDescending)
+}
+
+func (ts *TransactionMySqlIntTestSuite) TestIterateByFilter() {
+	//given
+	cheap, expensive, other := createTransaction(), createTransaction(), createTransaction()
+	cheap.Value, expensive.Value = 10, 1000
+	other.UserDocumentIndex = "other index"
+
+	for _, transaction := range []*entities.Transaction{&expensive, &other, &cheap} {
+		if transaction.UserDocumentIndex == "" {
+			transaction.UserDocumentIndex = "index"
+		}
+
+		ts.Nil(ts.underTest.Create(context.Background(), transaction))
+	}
+
+	filter := &repositories.TransactionFilter{UserDocumentIndex: "index", SortBy: repositories.SortByValue}
+
+	//when
+	transactions, err := ts.underTest.IterateByFilter(context.Background(), filter)
+	ts.Nil(err)
+
+	var actual []*entities.Transaction
+	for transactions.Next() {
+		actual = append(actual, transactions.Transaction())
+	}
+
+	//then
+	ts.Nil(transactions.Err())
+	ts.Nil(transactions.Close())
+	ts.Equal([]*entities.Transaction{&cheap, &expensive}, actual)
+}
+
 func (ts *TransactionMySqlIntTestSuite) TestFindAll_WhenEmpty() {
 	//when
-	actual, err := ts.underTest.FindAll()
+	actual, err := ts.underTest.FindAll(context.Background(), nil, 10)
 	ts.Nil(err)
 
 	//then
 	ts.Empty(actual)
 }
 
+func (ts *TransactionMySqlIntTestSuite) TestFindAll_WhenTimedOut() {
+	//given
+	underTest := repositories.NewTransactionMySqlRepository(ts.db)
+	underTest.UseTimeouts(time.Nanosecond, time.Nanosecond)
+
+	//when
+	actual, err := underTest.FindAll(context.Background(), nil, 10)
+
+	//then
+	ts.ErrorIs(err, context.DeadlineExceeded)
+	ts.Nil(actual)
+}
+
+func (ts *TransactionMySqlIntTestSuite) TestCreate_WithCanceledContext() {
+	//given
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	newTransaction := createTransaction()
+
+	//when
+	err := ts.underTest.Create(ctx, &newTransaction)
+
+	//then
+	ts.ErrorIs(err, context.Canceled)
+
+	actual, err := ts.underTest.FindByID(context.Background(), newTransaction.ID)
+	ts.Nil(err)
+	ts.Nil(actual)
+}
+
+func (ts *TransactionMySqlIntTestSuite) TestFindByUserDocument() {
+	//given
+	expected, other := createTransaction(), createTransaction()
+	expected.UserDocumentIndex = "index"
+	other.UserDocumentIndex = "other index"
+
+	ts.Nil(ts.underTest.Create(context.Background(), &expected))
+	ts.Nil(ts.underTest.Create(context.Background(), &other))
+
+	//when
+	actual, err := ts.underTest.FindByUserDocument(context.Background(), "index", nil, 10)
+	ts.Nil(err)
+
+	//then
+	ts.Equal([]*entities.Transaction{&expected}, actual)
+
+	//when
+	actual, err = ts.underTest.FindByUserDocument(context.Background(), "index", &repositories.TransactionCursor{
+		CreatedAt: expected.CreatedAt,
+		ID:        expected.ID,
+	}, 10)
+	ts.Nil(err)
+
+	//then
+	ts.Empty(actual)
+}
+
+func (ts *TransactionMySqlIntTestSuite) TestFindAfterID() {
+	//given
+	expected1, expected2, expected3 := createTransaction(), createTransaction(), createTransaction()
+
+	_, err := ts.db.Exec("INSERT INTO transactions (id, user_document, credit_card_token, `value`) VALUES (?, ?, ?, ?), (?, ?, ?, ?), (?, ?, ?, ?)",
+		expected1.ID, expected1.UserDocument, expected1.CreditCardToken, expected1.Value,
+		expected2.ID, expected2.UserDocument, expected2.CreditCardToken, expected2.Value,
+		expected3.ID, expected3.UserDocument, expected3.CreditCardToken, expected3.Value)
+	ts.Nil(err)
+
+	//when
+	firstChunk, err := ts.underTest.FindAfterID(context.Background(), "", 2)
+	ts.Nil(err)
+
+	secondChunk, err := ts.underTest.FindAfterID(context.Background(), firstChunk[1].ID, 2)
+	ts.Nil(err)
+
+	//then
+	ts.Len(firstChunk, 2)
+	ts.Len(secondChunk, 1)
+	ts.Less(firstChunk[0].ID, firstChunk[1].ID)
+	ts.Less(firstChunk[1].ID, secondChunk[0].ID)
+}
+
+func (ts *TransactionMySqlIntTestSuite) TestReplaceEncryptedFieldsByID() {
+	//given
+	current := createTransaction()
+
+	_, err := ts.db.Exec("INSERT INTO transactions (id, user_document, credit_card_token, `value`, created_at) VALUES (?, ?, ?, ?, ?)",
+		current.ID, current.UserDocument, current.CreditCardToken, current.Value, current.CreatedAt)
+	ts.Nil(err)
+
+	updated := current
+	updated.UserDocument = "54321"
+	updated.CreditCardToken = "557"
+
+	//when
+	replaced, err := ts.underTest.ReplaceEncryptedFieldsByID(context.Background(), &current, &updated)
+	ts.Nil(err)
+
+	replacedAgain, err := ts.underTest.ReplaceEncryptedFieldsByID(context.Background(), &current, &updated)
+	ts.Nil(err)
+
+	//then
+	ts.True(replaced)
+	ts.False(replacedAgain)
+
+	actual, err := ts.underTest.FindByID(context.Background(), current.ID)
+	ts.Nil(err)
+
+	ts.Equal(updated, *actual)
+}
+
 func (ts *TransactionMySqlIntTestSuite) TestUpdateByID() {
 	//given
 	newTransaction := createTransaction()
@@ -150,7 +438,7 @@ func (ts *TransactionMySqlIntTestSuite) TestUpdateByID() {
 	}
 
 	//when
-	err = ts.underTest.UpdateByID(&expected)
+	err = ts.underTest.UpdateByID(context.Background(), &expected)
 	ts.Nil(err)
 
 	//then
@@ -166,6 +454,46 @@ func (ts *TransactionMySqlIntTestSuite) TestUpdateByID() {
 	ts.Equal(expected, actual)
 }
 
+func (ts *TransactionMySqlIntTestSuite) TestCreate_WithEncryptedValue() {
+	//given
+	bucket := int64(12)
+	expected := createTransaction()
+	expected.Value = 0
+	expected.EncryptedValue = "v2:dek/encrypted_value:aes-256-gcm:00:00"
+	expected.ValueBucket = &bucket
+	expected.NullValue = true
+
+	//when
+	err := ts.underTest.Create(context.Background(), &expected)
+	ts.Nil(err)
+
+	//then
+	var value sql.NullFloat64
+	err = ts.db.QueryRow("SELECT `value` FROM transactions WHERE id = ?", expected.ID).Scan(&value)
+	ts.Nil(err)
+	ts.False(value.Valid)
+
+	actual, err := ts.underTest.FindByID(context.Background(), expected.ID)
+	ts.Nil(err)
+	ts.Equal(expected, *actual)
+}
+
+func (ts *TransactionMySqlIntTestSuite) TestCountByValueBucket() {
+	//given
+	_, err := ts.db.Exec("INSERT INTO transactions (id, user_document, credit_card_token, `value`, encrypted_value, value_bucket) "+
+		"VALUES (?, '', '', 1250.00, '', NULL), (?, '', '', NULL, 'v2:...', 12), (?, '', '', 35.50, '', NULL), "+
+		"(?, '', '', NULL, 'v2:...', NULL)",
+		uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString())
+	ts.Nil(err)
+
+	//when
+	actual, err := ts.underTest.CountByValueBucket(context.Background(), 100)
+	ts.Nil(err)
+
+	//then
+	ts.Equal(map[int64]int{0: 1, 12: 2}, actual)
+}
+
 func (ts *TransactionMySqlIntTestSuite) TestDeleteByID() {
 	//given
 	newTransaction := createTransaction()
@@ -175,7 +503,7 @@ func (ts *TransactionMySqlIntTestSuite) TestDeleteByID() {
 	ts.Nil(err)
 
 	//when
-	err = ts.underTest.DeleteByID(newTransaction.ID)
+	err = ts.underTest.DeleteByID(context.Background(), newTransaction.ID)
 	ts.Nil(err)
 
 	//then
@@ -187,15 +515,47 @@ func (ts *TransactionMySqlIntTestSuite) TestDeleteByID() {
 	ts.Zero(actual)
 }
 
+func (ts *TransactionMySqlIntTestSuite) TestCreate_IgnoresCreatedAt() {
+	//given
+	expected := createTransaction()
+	expected.CreatedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Now().UTC().Truncate(time.Microsecond)
+
+	//when
+	err := ts.underTest.Create(context.Background(), &expected)
+	ts.Nil(err)
+
+	//then
+	var createdAt time.Time
+	ts.Nil(ts.db.QueryRow("SELECT created_at FROM transactions WHERE id = ?", expected.ID).Scan(&createdAt))
+	ts.Equal(expected.CreatedAt, createdAt)
+	ts.False(createdAt.Before(before))
+}
+
 func TestTransactionMySqlIntTestSuite(t *testing.T) {
 	suite.Run(t, new(TransactionMySqlIntTestSuite))
 }
 
+// createAt creates the transaction and backdates it, which Create never does.
+func (ts *TransactionMySqlIntTestSuite) createAt(transaction *entities.Transaction, createdAt time.Time) {
+	ts.Nil(ts.underTest.Create(context.Background(), transaction))
+
+	_, err := ts.db.Exec("UPDATE transactions SET created_at = ? WHERE id = ?", createdAt, transaction.ID)
+	ts.Nil(err)
+
+	transaction.CreatedAt = createdAt
+}
+
+func valueCursor(transaction *entities.Transaction) *repositories.TransactionCursor {
+	return &repositories.TransactionCursor{Value: transaction.Value, NullValue: transaction.NullValue, ID: transaction.ID}
+}
+
 func createTransaction() entities.Transaction {
 	return entities.Transaction{
 		ID:              uuid.NewString(),
 		UserDocument:    "12345",
 		CreditCardToken: "755",
 		Value:           9999.99,
+		CreatedAt:       time.Now().UTC().Truncate(time.Microsecond),
 	}
 }
@@ -0,0 +1,10 @@
+package entities
+
+// CardVaultEntry maps a card token to the encrypted card number (PAN) it
+// stands for.
+type CardVaultEntry struct {
+	Token    string `encrypt:"id"`
+	Pan      string `encrypt:"aead,blind-index=PanIndex"`
+	DataKey  string `encrypt:"data-key"`
+	PanIndex string
+}
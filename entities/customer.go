@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// CustomerKey is the X25519 key pair of a customer, identified by the blind
+// index of their CPF: the public key wraps the data keys of their
+// transactions and the private key is stored wrapped by the KMS.
+type CustomerKey struct {
+	UserDocumentIndex string
+	PublicKey         string
+	PrivateKey        string
+}
+
+// CustomerErasure is the audit record of a customer forgotten at their
+// request.
+type CustomerErasure struct {
+	ID                 string    `json:"id"`
+	UserDocumentIndex  string    `json:"-"`
+	Reason             string    `json:"reason"`
+	KeyDestroyed       bool      `json:"keyDestroyed"`
+	ErasedTransactions int64     `json:"erasedTransactions"`
+	ErasedAt           time.Time `json:"erasedAt"`
+}
@@ -1,8 +1,19 @@
 package entities
 
+import "time"
+
 type Transaction struct {
-	ID              string  `json:"id"`
-	UserDocument    string  `json:"cpf"`
-	CreditCardToken string  `json:"creditCardToken"`
-	Value           float64 `json:"value"`
+	ID                        string    `json:"id" encrypt:"id"`
+	UserDocument              string    `json:"cpf" encrypt:"aead,blind-index=UserDocumentIndex"`
+	CreditCardToken           string    `json:"creditCardToken" encrypt:"aead,blind-index=CreditCardTokenLast4Index"`
+	Value                     float64   `json:"value"`
+	EncryptedValue            string    `json:"-" encrypt:"aead,omitempty"`
+	ValueBucket               *int64    `json:"-"`
+	NullValue                 bool      `json:"-"`
+	DataKey                   string    `json:"-" encrypt:"data-key"`
+	UserDocumentIndex         string    `json:"-"`
+	CreditCardTokenLast4Index string    `json:"-"`
+	CreatedAt                 time.Time `json:"createdAt"`
+	Erased                    bool      `json:"erased,omitempty"`
+	DecryptionError           string    `json:"decryptionError,omitempty"`
 }
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"crypto-challenge/providers"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type CardVaultHandler struct {
+	cardTokenizer providers.CardTokenizer
+}
+
+type tokenizeCardRequest struct {
+	Pan string `json:"pan"`
+}
+
+type detokenizeCardRequest struct {
+	Token string `json:"token"`
+}
+
+func (h *CardVaultHandler) Tokenize(w http.ResponseWriter, r *http.Request) {
+	var request tokenizeCardRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	token, err := h.cardTokenizer.Tokenize(request.Pan)
+	if errors.Is(err, providers.ErrInvalidCardNumber) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "Invalid card number.",
+		})
+		return
+	}
+
+	if err != nil {
+		setupInternalServerErrorResponse(w)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"token": token,
+	})
+}
+
+func (h *CardVaultHandler) Detokenize(w http.ResponseWriter, r *http.Request) {
+	var request detokenizeCardRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.Token == "" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	pan, err := h.cardTokenizer.Detokenize(request.Token)
+	if errors.Is(err, providers.ErrCardTokenNotFound) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "Card token not found.",
+		})
+		return
+	}
+
+	if err != nil {
+		setupInternalServerErrorResponse(w)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Header().Add("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]any{
+		"pan": pan,
+	})
+}
+
+func tokenizationDisabled(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": "Card tokenization is disabled in this deployment.",
+	})
+}
+
+func detokenizationDisabled(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": "Card detokenization is disabled in this deployment.",
+	})
+}
+
+// NewCardVaultRouter serves the card vault routes, to be mounted at /cards.
+// Tokenizing and detokenizing each require their own bearer token and are
+// disabled without one: a caller able to tokenize could tell whether a card
+// number is in the vault, since a card always gets the same token.
+func NewCardVaultRouter(cardTokenizer providers.CardTokenizer, tokenizeToken, detokenizeToken string) *chi.Mux {
+	r := chi.NewRouter()
+
+	handler := &CardVaultHandler{cardTokenizer}
+
+	if tokenizeToken == "" {
+		r.Post("/tokenize", tokenizationDisabled)
+	} else {
+		r.With(requireBearerToken(tokenizeToken)).Post("/tokenize", handler.Tokenize)
+	}
+
+	if detokenizeToken == "" {
+		r.Post("/detokenize", detokenizationDisabled)
+	} else {
+		r.With(requireBearerToken(detokenizeToken)).Post("/detokenize", handler.Detokenize)
+	}
+
+	return r
+}
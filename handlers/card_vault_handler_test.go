@@ -0,0 +1,158 @@
+package handlers_test
+
+import (
+	"crypto-challenge/handlers"
+	"crypto-challenge/mocks/crypto-challenge/providers"
+	cryptoproviders "crypto-challenge/providers"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	tokenizeToken   = "tokenize-token"
+	detokenizeToken = "detokenize-token"
+)
+
+type CardVaultHandlerTestSuite struct {
+	suite.Suite
+	router            *chi.Mux
+	cardTokenizerMock *providers.MockCardTokenizer
+}
+
+func (ts *CardVaultHandlerTestSuite) SetupTest() {
+	ts.router = chi.NewRouter()
+
+	ts.cardTokenizerMock = providers.NewMockCardTokenizer(ts.T())
+
+	ts.router.Mount("/cards", handlers.NewCardVaultRouter(ts.cardTokenizerMock, tokenizeToken, detokenizeToken))
+}
+
+func (ts *CardVaultHandlerTestSuite) TestTokenize() {
+	// given
+	ts.cardTokenizerMock.EXPECT().Tokenize("4111111111111111").Return("8372950164821111", nil).Once()
+
+	// when
+	res := ts.tokenize(`{"pan":"4111111111111111"}`, "Bearer "+tokenizeToken)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+	ts.Require().JSONEq(`{"token":"8372950164821111"}`, res.Body.String())
+}
+
+func (ts *CardVaultHandlerTestSuite) TestTokenize_WithInvalidCardNumber() {
+	// given
+	ts.cardTokenizerMock.EXPECT().Tokenize("4111").Return("", cryptoproviders.ErrInvalidCardNumber).Once()
+
+	// when
+	res := ts.tokenize(`{"pan":"4111"}`, "Bearer "+tokenizeToken)
+
+	// then
+	ts.Require().Equal(http.StatusUnprocessableEntity, res.Code)
+	requireValidJSON(ts.T(), res.Body.Bytes(), InvalidJSONResponsePayload, res.Body.String())
+}
+
+func (ts *CardVaultHandlerTestSuite) TestTokenize_WithoutToken() {
+	// when
+	res := ts.tokenize(`{"pan":"4111111111111111"}`, "")
+
+	// then
+	ts.Require().Equal(http.StatusUnauthorized, res.Code)
+}
+
+func (ts *CardVaultHandlerTestSuite) TestTokenize_WithDetokenizeToken() {
+	// when
+	res := ts.tokenize(`{"pan":"4111111111111111"}`, "Bearer "+detokenizeToken)
+
+	// then
+	ts.Require().Equal(http.StatusUnauthorized, res.Code)
+}
+
+func (ts *CardVaultHandlerTestSuite) TestTokenize_WhenDisabled() {
+	// given
+	router := handlers.NewCardVaultRouter(ts.cardTokenizerMock, "", detokenizeToken)
+
+	// when
+	res := makeRequest(router, http.MethodPost, "/tokenize", strings.NewReader(`{"pan":"4111111111111111"}`))
+
+	// then
+	ts.Require().Equal(http.StatusForbidden, res.Code)
+	requireValidJSON(ts.T(), res.Body.Bytes(), InvalidJSONResponsePayload, res.Body.String())
+}
+
+func (ts *CardVaultHandlerTestSuite) TestDetokenize() {
+	// given
+	ts.cardTokenizerMock.EXPECT().Detokenize("8372950164821111").Return("4111111111111111", nil).Once()
+
+	// when
+	res := ts.detokenize(`{"token":"8372950164821111"}`, "Bearer "+detokenizeToken)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+	ts.Require().Equal("no-store", res.Header().Get("Cache-Control"))
+
+	var body map[string]string
+	ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &body))
+	ts.Require().Equal("4111111111111111", body["pan"])
+}
+
+func (ts *CardVaultHandlerTestSuite) TestDetokenize_WithoutToken() {
+	// when
+	res := ts.detokenize(`{"token":"8372950164821111"}`, "")
+
+	// then
+	ts.Require().Equal(http.StatusUnauthorized, res.Code)
+}
+
+func (ts *CardVaultHandlerTestSuite) TestDetokenize_WhenNotFound() {
+	// given
+	ts.cardTokenizerMock.EXPECT().Detokenize("8372950164821111").Return("", cryptoproviders.ErrCardTokenNotFound).Once()
+
+	// when
+	res := ts.detokenize(`{"token":"8372950164821111"}`, "Bearer "+detokenizeToken)
+
+	// then
+	ts.Require().Equal(http.StatusNotFound, res.Code)
+	requireValidJSON(ts.T(), res.Body.Bytes(), InvalidJSONResponsePayload, res.Body.String())
+}
+
+func (ts *CardVaultHandlerTestSuite) TestDetokenize_WhenDisabled() {
+	// given
+	router := handlers.NewCardVaultRouter(ts.cardTokenizerMock, tokenizeToken, "")
+
+	// when
+	res := makeRequest(router, http.MethodPost, "/detokenize", strings.NewReader(`{"token":"8372950164821111"}`))
+
+	// then
+	ts.Require().Equal(http.StatusForbidden, res.Code)
+}
+
+func TestCardVaultHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(CardVaultHandlerTestSuite))
+}
+
+func (ts *CardVaultHandlerTestSuite) tokenize(body, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/cards/tokenize", strings.NewReader(body))
+	req.Header.Set("Authorization", authorization)
+
+	rr := httptest.NewRecorder()
+	ts.router.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func (ts *CardVaultHandlerTestSuite) detokenize(body, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/cards/detokenize", strings.NewReader(body))
+	req.Header.Set("Authorization", authorization)
+
+	rr := httptest.NewRecorder()
+	ts.router.ServeHTTP(rr, req)
+
+	return rr
+}
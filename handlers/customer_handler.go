@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"crypto-challenge/database/repositories"
+	"crypto-challenge/entities"
+	"crypto-challenge/providers"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type CustomerHandler struct {
+	repository                repositories.CustomerKeyRepository
+	transactionCryptoProvider providers.TransactionCryptoProvider
+}
+
+type forgetCustomerRequest struct {
+	UserDocument string `json:"cpf"`
+	Reason       string `json:"reason"`
+}
+
+// Forget erases a customer at their request: their key is destroyed and
+// their transactions are blanked, which can't be undone. The erasure is
+// recorded and returned, without the CPF.
+func (h *CustomerHandler) Forget(w http.ResponseWriter, r *http.Request) {
+	var request forgetCustomerRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.UserDocument == "" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	userDocumentIndex, err := h.transactionCryptoProvider.UserDocumentIndex(request.UserDocument)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	erasure := &entities.CustomerErasure{
+		ID:                uuid.NewString(),
+		UserDocumentIndex: userDocumentIndex,
+		Reason:            request.Reason,
+		ErasedAt:          time.Now().UTC(),
+	}
+
+	err = h.repository.Erase(erasure)
+	if err != nil {
+		setupInternalServerErrorResponse(w)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(erasure)
+}
+
+func erasureDisabled(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": "Customer erasure is disabled in this deployment.",
+	})
+}
+
+// NewCustomerRouter serves the customer routes, to be mounted at /customers.
+// Forgetting a customer requires the given bearer token and is disabled
+// without one.
+func NewCustomerRouter(repository repositories.CustomerKeyRepository, transactionCryptoProvider providers.TransactionCryptoProvider,
+	forgetToken string) *chi.Mux {
+	r := chi.NewRouter()
+
+	handler := &CustomerHandler{repository, transactionCryptoProvider}
+
+	if forgetToken == "" {
+		r.Post("/forget", erasureDisabled)
+	} else {
+		r.With(requireBearerToken(forgetToken)).Post("/forget", handler.Forget)
+	}
+
+	return r
+}
@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"crypto-challenge/entities"
+	"crypto-challenge/handlers"
+	"crypto-challenge/mocks/crypto-challenge/database/repositories"
+	"crypto-challenge/mocks/crypto-challenge/providers"
+	cryptoproviders "crypto-challenge/providers"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const forgetToken = "forget-token"
+
+type CustomerHandlerTestSuite struct {
+	suite.Suite
+	router             *chi.Mux
+	repositoryMock     *repositories.MockCustomerKeyRepository
+	cryptoProviderMock *providers.MockTransactionCryptoProvider
+}
+
+func (ts *CustomerHandlerTestSuite) SetupTest() {
+	ts.router = chi.NewRouter()
+
+	ts.repositoryMock = repositories.NewMockCustomerKeyRepository(ts.T())
+	ts.cryptoProviderMock = providers.NewMockTransactionCryptoProvider(ts.T())
+
+	ts.router.Mount("/customers", handlers.NewCustomerRouter(ts.repositoryMock, ts.cryptoProviderMock, forgetToken))
+}
+
+func (ts *CustomerHandlerTestSuite) TestForget() {
+	// given
+	ts.cryptoProviderMock.EXPECT().UserDocumentIndex("50277613433").Return("index", nil).Once()
+	ts.repositoryMock.EXPECT().Erase(mock.MatchedBy(func(erasure *entities.CustomerErasure) bool {
+		return erasure.ID != "" && erasure.UserDocumentIndex == "index" && erasure.Reason == "LGPD request" &&
+			!erasure.ErasedAt.IsZero()
+	})).RunAndReturn(func(erasure *entities.CustomerErasure) error {
+		erasure.KeyDestroyed = true
+		erasure.ErasedTransactions = 2
+		return nil
+	}).Once()
+
+	// when
+	res := ts.forget(`{"cpf":"50277613433","reason":"LGPD request"}`, "Bearer "+forgetToken)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+	ts.Require().NotContains(res.Body.String(), "index")
+
+	var actualErasure entities.CustomerErasure
+	ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &actualErasure))
+	ts.Require().True(actualErasure.KeyDestroyed)
+	ts.Require().EqualValues(2, actualErasure.ErasedTransactions)
+}
+
+func (ts *CustomerHandlerTestSuite) TestForget_WithoutUserDocument() {
+	// when
+	res := ts.forget(`{"reason":"LGPD request"}`, "Bearer "+forgetToken)
+
+	// then
+	ts.Require().Equal(http.StatusUnprocessableEntity, res.Code)
+	ts.Require().Empty(res.Body.Bytes())
+}
+
+func (ts *CustomerHandlerTestSuite) TestForget_WithInvalidUserDocument() {
+	// given
+	ts.cryptoProviderMock.EXPECT().UserDocumentIndex("502776134").Return("", cryptoproviders.ErrInvalidUserDocument).Once()
+
+	// when
+	res := ts.forget(`{"cpf":"502776134","reason":"LGPD request"}`, "Bearer "+forgetToken)
+
+	// then
+	ts.Require().Equal(http.StatusUnprocessableEntity, res.Code)
+	ts.Require().Empty(res.Body.Bytes())
+	ts.repositoryMock.AssertNotCalled(ts.T(), "Erase", mock.Anything)
+}
+
+func (ts *CustomerHandlerTestSuite) TestForget_WithErrorOnErase() {
+	// given
+	ts.cryptoProviderMock.EXPECT().UserDocumentIndex("50277613433").Return("index", nil).Once()
+	ts.repositoryMock.EXPECT().Erase(mock.AnythingOfType("*entities.CustomerErasure")).Return(errorOnMethod("Erase")).Once()
+
+	// when
+	res := ts.forget(`{"cpf":"50277613433"}`, "Bearer "+forgetToken)
+
+	// then
+	ts.Require().Equal(http.StatusInternalServerError, res.Code)
+	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+	requireValidJSON(ts.T(), res.Body.Bytes(), InvalidJSONResponsePayload, res.Body.String())
+}
+
+func (ts *CustomerHandlerTestSuite) TestForget_WithoutToken() {
+	// when
+	res := ts.forget(`{"cpf":"50277613433"}`, "")
+
+	// then
+	ts.Require().Equal(http.StatusUnauthorized, res.Code)
+}
+
+func (ts *CustomerHandlerTestSuite) TestForget_WhenDisabled() {
+	// given
+	router := handlers.NewCustomerRouter(ts.repositoryMock, ts.cryptoProviderMock, "")
+
+	// when
+	res := makeRequest(router, http.MethodPost, "/forget", strings.NewReader(`{"cpf":"50277613433"}`))
+
+	// then
+	ts.Require().Equal(http.StatusForbidden, res.Code)
+	requireValidJSON(ts.T(), res.Body.Bytes(), InvalidJSONResponsePayload, res.Body.String())
+}
+
+func TestCustomerHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(CustomerHandlerTestSuite))
+}
+
+func (ts *CustomerHandlerTestSuite) forget(body, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/customers/forget", strings.NewReader(body))
+	req.Header.Set("Authorization", authorization)
+
+	rr := httptest.NewRecorder()
+	ts.router.ServeHTTP(rr, req)
+
+	return rr
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"crypto-challenge/entities"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// exportBatchSize bounds the transactions held in memory by an export, the
+	// ones decrypted at once.
+	exportBatchSize = 100
+
+	contentTypeNDJSON = "application/x-ndjson"
+	contentTypeCSV    = "text/csv"
+)
+
+var csvHeader = []string{"id", "cpf", "creditCardToken", "value", "createdAt", "erased", "decryptionError"}
+
+type transactionExporter interface {
+	write(transaction *entities.Transaction) error
+	flush() error
+}
+
+// Export streams every transaction matching the filters of the listing,
+// decrypted and masked as listed, as NDJSON or CSV according to the Accept
+// header. The transactions are read, decrypted and written in batches, so the
+// memory used doesn't grow with the export.
+func (h *TransactionHandler) Export(w http.ResponseWriter, r *http.Request) {
+	contentType, ok := negotiateExportType(r.Header.Get("Accept"))
+	if !ok {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotAcceptable)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "Transactions are exported as " + contentTypeNDJSON + " or " + contentTypeCSV + ".",
+		})
+		return
+	}
+
+	maskingPolicy, ok := h.maskingPolicy(w, r)
+	if !ok {
+		return
+	}
+
+	filter, ok := h.parseFilter(w, r)
+	if !ok {
+		return
+	}
+
+	transactions, err := h.repository.IterateByFilter(r.Context(), filter)
+	if err != nil {
+		setupInternalServerErrorResponse(w)
+		return
+	}
+
+	defer transactions.Close()
+
+	var exporter transactionExporter
+
+	for {
+		batch := make([]*entities.Transaction, 0, exportBatchSize)
+		for len(batch) < exportBatchSize && transactions.Next() {
+			batch = append(batch, transactions.Transaction())
+		}
+
+		if err := transactions.Err(); err != nil {
+			failExport(w, exporter != nil, err)
+			return
+		}
+
+		matching, err := h.decryptMatching(batch, filter, maskingPolicy)
+		if err != nil {
+			failExport(w, exporter != nil, err)
+			return
+		}
+
+		if exporter == nil {
+			exporter = newTransactionExporter(w, contentType)
+		}
+
+		for _, transaction := range matching {
+			if err := exporter.write(transaction); err != nil {
+				return
+			}
+		}
+
+		// A failed flush means the client went away.
+		if err := exporter.flush(); err != nil || len(batch) < exportBatchSize {
+			return
+		}
+	}
+}
+
+// failExport answers 500 when nothing was exported yet. Otherwise, the status
+// was already sent, so the connection is aborted for the export not to look
+// complete.
+func failExport(w http.ResponseWriter, started bool, err error) {
+	if !started {
+		setupInternalServerErrorResponse(w)
+		return
+	}
+
+	log.Println("Export aborted:", err)
+	panic(http.ErrAbortHandler)
+}
+
+// negotiateExportType returns the export type of highest quality in the
+// Accept header, NDJSON when it is empty. Each type takes the quality of the
+// most specific media range matching it, the types of quality 0 are refused
+// and ties go to the type listed first.
+func negotiateExportType(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return contentTypeNDJSON, true
+	}
+
+	mediaRanges := strings.Split(accept, ",")
+
+	var (
+		best         string
+		bestQuality  float64
+		bestPosition int
+	)
+
+	for _, exportType := range []string{contentTypeNDJSON, contentTypeCSV} {
+		contentType, quality, position := exportTypeQuality(exportType, mediaRanges)
+
+		if quality > bestQuality || (quality == bestQuality && quality > 0 && position < bestPosition) {
+			best, bestQuality, bestPosition = contentType, quality, position
+		}
+	}
+
+	return best, bestQuality > 0
+}
+
+// exportTypeQuality returns the quality given to the export type by the most
+// specific of the media ranges matching it, with the position of that range
+// and the content type answered, NDJSON keeping the name it was asked by.
+func exportTypeQuality(exportType string, mediaRanges []string) (string, float64, int) {
+	contentType, quality, position, specificity := exportType, 0.0, 0, 0
+
+	mainType, _, _ := strings.Cut(exportType, "/")
+
+	for i, mediaRange := range mediaRanges {
+		mediaType, params, _ := strings.Cut(mediaRange, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+		var rangeSpecificity int
+
+		switch {
+		case mediaType == exportType || (exportType == contentTypeNDJSON && mediaType == "application/ndjson"):
+			rangeSpecificity = 3
+		case mediaType == mainType+"/*":
+			rangeSpecificity = 2
+		case mediaType == "*/*":
+			rangeSpecificity = 1
+		default:
+			continue
+		}
+
+		if rangeSpecificity <= specificity {
+			continue
+		}
+
+		specificity, quality, position = rangeSpecificity, mediaRangeQuality(params), i
+		if rangeSpecificity == 3 {
+			contentType = mediaType
+		}
+	}
+
+	return contentType, quality, position
+}
+
+// mediaRangeQuality returns the q parameter of a media range, 1 when it has
+// none and 0 when it isn't a valid quality.
+func mediaRangeQuality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(param, "=")
+		if !strings.EqualFold(strings.TrimSpace(name), "q") {
+			continue
+		}
+
+		quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || !(quality >= 0 && quality <= 1) {
+			return 0
+		}
+
+		return quality
+	}
+
+	return 1
+}
+
+func newTransactionExporter(w http.ResponseWriter, contentType string) transactionExporter {
+	extension := "ndjson"
+	if contentType == contentTypeCSV {
+		extension = "csv"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="transactions.`+extension+`"`)
+
+	if contentType == contentTypeCSV {
+		csvWriter := csv.NewWriter(w)
+		csvWriter.Write(csvHeader)
+
+		return &csvExporter{csvWriter, http.NewResponseController(w)}
+	}
+
+	return &ndjsonExporter{json.NewEncoder(w), http.NewResponseController(w)}
+}
+
+type ndjsonExporter struct {
+	encoder    *json.Encoder
+	controller *http.ResponseController
+}
+
+func (e *ndjsonExporter) write(transaction *entities.Transaction) error {
+	return e.encoder.Encode(transaction)
+}
+
+func (e *ndjsonExporter) flush() error {
+	return flushResponse(e.controller)
+}
+
+type csvExporter struct {
+	writer     *csv.Writer
+	controller *http.ResponseController
+}
+
+func (e *csvExporter) write(transaction *entities.Transaction) error {
+	return e.writer.Write([]string{
+		transaction.ID,
+		csvCell(transaction.UserDocument),
+		csvCell(transaction.CreditCardToken),
+		strconv.FormatFloat(transaction.Value, 'f', -1, 64),
+		transaction.CreatedAt.Format(time.RFC3339Nano),
+		strconv.FormatBool(transaction.Erased),
+		transaction.DecryptionError,
+	})
+}
+
+func (e *csvExporter) flush() error {
+	e.writer.Flush()
+	if err := e.writer.Error(); err != nil {
+		return err
+	}
+
+	return flushResponse(e.controller)
+}
+
+// flushResponse sends what was written so far to the client, the writers
+// that can't flush sending it when the handler returns.
+func flushResponse(controller *http.ResponseController) error {
+	if err := controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	return nil
+}
+
+// csvCell escapes the values that spreadsheets would run as formulas.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"crypto-challenge/database/repositories"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// parseFilter reads the filter and sort query parameters of the transaction
+// listing. It answers 400 when they are invalid.
+func (h *TransactionHandler) parseFilter(w http.ResponseWriter, r *http.Request) (*repositories.TransactionFilter, bool) {
+	query := r.URL.Query()
+	filter := &repositories.TransactionFilter{ValueBucketWidth: h.valueBucketWidth}
+
+	if userDocument := query.Get("cpf"); userDocument != "" {
+		userDocumentIndex, err := h.transactionCryptoProvider.UserDocumentIndex(userDocument)
+		if err != nil {
+			setupBadRequestResponse(w, "The cpf must have 11 digits.")
+			return nil, false
+		}
+
+		filter.UserDocumentIndex = userDocumentIndex
+	}
+
+	if last4 := query.Get("cardLast4"); last4 != "" {
+		if len(last4) != 4 || strings.Trim(last4, "0123456789") != "" {
+			setupBadRequestResponse(w, "The cardLast4 must be 4 digits.")
+			return nil, false
+		}
+
+		filter.CreditCardTokenLast4Index = h.transactionCryptoProvider.CreditCardTokenLast4Index(last4)
+	}
+
+	var ok bool
+
+	if filter.MinValue, ok = parseValue(query.Get("minValue")); !ok {
+		setupBadRequestResponse(w, "The minValue must be a number.")
+		return nil, false
+	}
+
+	if filter.MaxValue, ok = parseValue(query.Get("maxValue")); !ok {
+		setupBadRequestResponse(w, "The maxValue must be a number.")
+		return nil, false
+	}
+
+	if filter.CreatedFrom, ok = parseDate(query.Get("createdFrom"), false); !ok {
+		setupBadRequestResponse(w, "The createdFrom must be a date, as 2024-05-01, or an RFC 3339 timestamp.")
+		return nil, false
+	}
+
+	if filter.CreatedTo, ok = parseDate(query.Get("createdTo"), true); !ok {
+		setupBadRequestResponse(w, "The createdTo must be a date, as 2024-05-31, or an RFC 3339 timestamp.")
+		return nil, false
+	}
+
+	switch sortBy := repositories.TransactionSortField(query.Get("sort")); sortBy {
+	case "", repositories.SortByCreatedAt:
+		filter.SortBy = repositories.SortByCreatedAt
+	case repositories.SortByValue:
+		if h.encryptedValues {
+			setupBadRequestResponse(w, "Transactions can't be sorted by value, since their values are encrypted.")
+			return nil, false
+		}
+
+		filter.SortBy = sortBy
+	default:
+		setupBadRequestResponse(w, "The sort must be createdAt or value.")
+		return nil, false
+	}
+
+	switch query.Get("direction") {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		setupBadRequestResponse(w, "The direction must be asc or desc.")
+		return nil, false
+	}
+
+	return filter, true
+}
+
+func parseValue(rawValue string) (*float64, bool) {
+	if rawValue == "" {
+		return nil, true
+	}
+
+	value, err := strconv.ParseFloat(rawValue, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, false
+	}
+
+	return &value, true
+}
+
+// parseDate reads a timestamp or a date, in UTC, which stands for the start of
+// the day or its end when endOfDay is true.
+func parseDate(rawDate string, endOfDay bool) (*time.Time, bool) {
+	if rawDate == "" {
+		return nil, true
+	}
+
+	if date, err := time.Parse(time.RFC3339Nano, rawDate); err == nil {
+		date = date.UTC()
+		return &date, true
+	}
+
+	date, err := time.Parse(dateLayout, rawDate)
+	if err != nil {
+		return nil, false
+	}
+
+	if endOfDay {
+		date = date.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+
+	return &date, true
+}
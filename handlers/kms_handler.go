@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"crypto-challenge/providers"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// KeyManagementHandler serves a KeyManagementService over HTTP, to stand in
+// for a managed KMS in development and tests.
+type KeyManagementHandler struct {
+	kms providers.KeyManagementService
+}
+
+func (h *KeyManagementHandler) WrapKey(w http.ResponseWriter, r *http.Request) {
+	var req providers.KmsWrapKeyRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || len(req.PlaintextKey) == 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	wrappedKey, err := h.kms.WrapKey(req.PlaintextKey, req.AdditionalData)
+	if err != nil {
+		setupInternalServerErrorResponse(w)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&providers.KmsKeyResponse{WrappedKey: wrappedKey})
+}
+
+func (h *KeyManagementHandler) UnwrapKey(w http.ResponseWriter, r *http.Request) {
+	var req providers.KmsUnwrapKeyRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.WrappedKey == "" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	plaintextKey, err := h.kms.UnwrapKey(req.WrappedKey, req.AdditionalData)
+	if reason := providers.DecryptionErrorReason(err); reason != "" {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(&providers.KmsErrorResponse{
+			Error:  "The wrapped key can't be unwrapped.",
+			Reason: reason,
+		})
+		return
+	}
+
+	if err != nil {
+		setupInternalServerErrorResponse(w)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&providers.KmsKeyResponse{PlaintextKey: plaintextKey})
+}
+
+func (h *KeyManagementHandler) GenerateDataKey(w http.ResponseWriter, r *http.Request) {
+	var req providers.KmsWrapKeyRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	plaintextKey, wrappedKey, err := h.kms.GenerateDataKey(req.AdditionalData)
+	if err != nil {
+		setupInternalServerErrorResponse(w)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&providers.KmsKeyResponse{PlaintextKey: plaintextKey, WrappedKey: wrappedKey})
+}
+
+func (h *KeyManagementHandler) KeyMetadata(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "id")
+	if keyID == "primary" {
+		keyID = ""
+	}
+
+	keyMetadata, err := h.kms.KeyMetadata(keyID)
+
+	w.Header().Add("Content-Type", "application/json")
+
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":      "Key not found with specified ID.",
+			"searchedId": keyID,
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(keyMetadata)
+}
+
+func NewKeyManagementRouter(kms providers.KeyManagementService, token string) *chi.Mux {
+	r := chi.NewRouter()
+
+	handler := &KeyManagementHandler{kms}
+
+	r.Route("/keys", func(r chi.Router) {
+		r.Use(requireBearerToken(token))
+
+		r.Post("/wrap", handler.WrapKey)
+		r.Post("/unwrap", handler.UnwrapKey)
+		r.Post("/generate-data-key", handler.GenerateDataKey)
+		r.Get("/{id}", handler.KeyMetadata)
+	})
+
+	return r
+}
+
+// requireBearerToken rejects the requests without the given bearer token, all
+// of them when the token is empty.
+func requireBearerToken(token string) func(http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actual := []byte(r.Header.Get("Authorization"))
+
+			if token == "" || subtle.ConstantTimeCompare(expected, actual) != 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"crypto-challenge/entities"
+	"crypto-challenge/providers"
+	"fmt"
+	"strings"
+)
+
+// MaskingPolicy tells how much of the decrypted CPF and card token a caller
+// gets to see.
+type MaskingPolicy string
+
+const (
+	MaskingPolicyFull   MaskingPolicy = "full"
+	MaskingPolicyMasked MaskingPolicy = "masked"
+	MaskingPolicyLast4  MaskingPolicy = "last-4"
+	MaskingPolicyHidden MaskingPolicy = "hidden"
+
+	DefaultRoleHeader = "X-Caller-Role"
+)
+
+// maskingPolicies is ordered from the most to the least revealing.
+var maskingPolicies = []MaskingPolicy{MaskingPolicyFull, MaskingPolicyMasked, MaskingPolicyLast4, MaskingPolicyHidden}
+
+func ParseMaskingPolicy(name string) (MaskingPolicy, error) {
+	for _, policy := range maskingPolicies {
+		if string(policy) == name {
+			return policy, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown masking policy %q", name)
+}
+
+// reveals tells whether the policy reveals at least as much as another one.
+func (p MaskingPolicy) reveals(other MaskingPolicy) bool {
+	return p.rank() <= other.rank()
+}
+
+func (p MaskingPolicy) rank() int {
+	for i, policy := range maskingPolicies {
+		if policy == p {
+			return i
+		}
+	}
+
+	return len(maskingPolicies)
+}
+
+func (p MaskingPolicy) apply(transaction *entities.Transaction) {
+	switch p {
+	case MaskingPolicyFull:
+	case MaskingPolicyMasked:
+		transaction.UserDocument = maskUserDocument(transaction.UserDocument)
+		transaction.CreditCardToken = maskAllButLast4(transaction.CreditCardToken)
+	case MaskingPolicyLast4:
+		transaction.UserDocument = last4(providers.DigitsOnly(transaction.UserDocument))
+		transaction.CreditCardToken = last4(transaction.CreditCardToken)
+	default:
+		transaction.UserDocument = ""
+		transaction.CreditCardToken = ""
+	}
+}
+
+// maskUserDocument keeps only the 7th to 9th digits of a CPF, the ones the
+// Brazilian government shows when publishing it: ***.***.789-**.
+func maskUserDocument(userDocument string) string {
+	digits := providers.DigitsOnly(userDocument)
+	if len(digits) != 11 {
+		return strings.Repeat("*", len(userDocument))
+	}
+
+	return "***.***." + digits[6:9] + "-**"
+}
+
+func maskAllButLast4(value string) string {
+	if len(value) <= 4 {
+		return strings.Repeat("*", len(value))
+	}
+
+	return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+}
+
+func last4(value string) string {
+	if len(value) <= 4 {
+		return value
+	}
+
+	return value[len(value)-4:]
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"crypto-challenge/database/repositories"
+	"crypto-challenge/entities"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageSize = 50
+	// MaxPageSize bounds the transactions read and decrypted per request,
+	// whatever the limit asked for.
+	MaxPageSize = 500
+
+	// nullCursorValue stands for a NULL value column in the cursors.
+	nullCursorValue = "null"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+type transactionPage struct {
+	Transactions []*entities.Transaction `json:"transactions"`
+	NextCursor   string                  `json:"nextCursor,omitempty"`
+}
+
+// parsePage reads the limit and cursor query parameters, the cursor being nil
+// for the first page. It answers 400 when they are invalid.
+func parsePage(w http.ResponseWriter, r *http.Request) (*repositories.TransactionCursor, int, bool) {
+	limit := DefaultPageSize
+
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		parsedLimit, err := strconv.Atoi(rawLimit)
+		if err != nil || parsedLimit < 1 {
+			setupBadRequestResponse(w, "The limit must be a positive integer.")
+			return nil, 0, false
+		}
+
+		limit = min(parsedLimit, MaxPageSize)
+	}
+
+	rawCursor := r.URL.Query().Get("cursor")
+	if rawCursor == "" {
+		return nil, limit, true
+	}
+
+	cursor, err := decodeCursor(rawCursor)
+	if err != nil {
+		setupBadRequestResponse(w, "Invalid cursor.")
+		return nil, 0, false
+	}
+
+	return cursor, limit, true
+}
+
+func setupBadRequestResponse(w http.ResponseWriter, message string) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": message,
+	})
+}
+
+// encodeCursor returns the opaque cursor of the page following the given
+// transaction, holding its keys for every sort order. It must be encoded
+// before the transaction is decrypted, while its value is the stored one.
+func encodeCursor(transaction *entities.Transaction) string {
+	value := nullCursorValue
+	if !transaction.NullValue {
+		value = strconv.FormatFloat(transaction.Value, 'f', -1, 64)
+	}
+
+	position := strconv.FormatInt(transaction.CreatedAt.UnixMicro(), 10) + ":" + value + ":" + transaction.ID
+
+	return base64.RawURLEncoding.EncodeToString([]byte(position))
+}
+
+func decodeCursor(cursor string) (*repositories.TransactionCursor, error) {
+	position, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(string(position), ":", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return nil, errInvalidCursor
+	}
+
+	createdAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := &repositories.TransactionCursor{CreatedAt: time.UnixMicro(createdAt).UTC(), ID: parts[2]}
+
+	if parts[1] == nullCursorValue {
+		decoded.NullValue = true
+		return decoded, nil
+	}
+
+	value, ok := parseValue(parts[1])
+	if !ok || value == nil {
+		return nil, errInvalidCursor
+	}
+
+	decoded.Value = *value
+
+	return decoded, nil
+}
@@ -5,7 +5,10 @@ import (
 	"crypto-challenge/entities"
 	"crypto-challenge/providers"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -14,6 +17,15 @@ import (
 type TransactionHandler struct {
 	repository                repositories.TransactionRepository
 	transactionCryptoProvider providers.TransactionCryptoProvider
+	masking                   maskingConfig
+	valueBucketWidth          float64
+	encryptedValues           bool
+}
+
+type maskingConfig struct {
+	roleHeader    string
+	defaultPolicy MaskingPolicy
+	rolePolicies  map[string]MaskingPolicy
 }
 
 func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -25,15 +37,22 @@ func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The creation date is the server's, set when the transaction is stored.
 	newTransaction.ID = uuid.NewString()
+	newTransaction.CreatedAt = time.Time{}
 
 	err = h.transactionCryptoProvider.Encrypt(&newTransaction)
+	if errors.Is(err, providers.ErrInvalidUserDocument) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
 	if err != nil {
 		setupInternalServerErrorResponse(w)
 		return
 	}
 
-	err = h.repository.Create(&newTransaction)
+	err = h.repository.Create(r.Context(), &newTransaction)
 	if err != nil {
 		setupInternalServerErrorResponse(w)
 		return
@@ -45,7 +64,12 @@ func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
 func (h *TransactionHandler) FindByID(w http.ResponseWriter, r *http.Request) {
 	idToSearchBy := chi.URLParam(r, "id")
 
-	searchedTransaction, err := h.repository.FindByID(idToSearchBy)
+	maskingPolicy, ok := h.maskingPolicy(w, r)
+	if !ok {
+		return
+	}
+
+	searchedTransaction, err := h.repository.FindByID(r.Context(), idToSearchBy)
 	if err != nil {
 		setupInternalServerErrorResponse(w)
 		return
@@ -63,37 +87,81 @@ func (h *TransactionHandler) FindByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = h.transactionCryptoProvider.Decrypt(searchedTransaction)
+	if errors.Is(err, providers.ErrErased) {
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":      "Transaction erased at the customer's request.",
+			"searchedId": idToSearchBy,
+			"status":     "erased",
+		})
+		return
+	}
+
+	if reason := providers.DecryptionErrorReason(err); reason != "" {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":      "Transaction corrupted, it can't be decrypted.",
+			"searchedId": idToSearchBy,
+			"status":     "corrupted",
+			"reason":     reason,
+		})
+		return
+	}
+
 	if err != nil {
 		setupInternalServerErrorResponse(w)
 		return
 	}
 
+	maskingPolicy.apply(searchedTransaction)
+
 	json.NewEncoder(w).Encode(searchedTransaction)
 }
 
 func (h *TransactionHandler) FindAll(w http.ResponseWriter, r *http.Request) {
-	transactions, err := h.repository.FindAll()
+	maskingPolicy, ok := h.maskingPolicy(w, r)
+	if !ok {
+		return
+	}
+
+	filter, ok := h.parseFilter(w, r)
+	if !ok {
+		return
+	}
+
+	cursor, limit, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	// One more transaction than asked for tells whether there's a next page.
+	transactions, err := h.repository.FindByFilter(r.Context(), filter, cursor, limit+1)
 	if err != nil {
 		setupInternalServerErrorResponse(w)
 		return
 	}
 
-	for _, transaction := range transactions {
-		err := h.transactionCryptoProvider.Decrypt(transaction)
-		if err != nil {
-			setupInternalServerErrorResponse(w)
-			return
-		}
+	var nextCursor string
+
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		nextCursor = encodeCursor(transactions[limit-1])
+	}
+
+	transactions, err = h.decryptMatching(transactions, filter, maskingPolicy)
+	if err != nil {
+		setupInternalServerErrorResponse(w)
+		return
 	}
 
 	w.Header().Add("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(transactions)
+	json.NewEncoder(w).Encode(transactionPage{transactions, nextCursor})
 }
 
 func (h *TransactionHandler) UpdateByID(w http.ResponseWriter, r *http.Request) {
 	idToUpdate := chi.URLParam(r, "id")
 
-	searchedTransaction, err := h.repository.FindByID(idToUpdate)
+	searchedTransaction, err := h.repository.FindByID(r.Context(), idToUpdate)
 	if err != nil {
 		setupInternalServerErrorResponse(w)
 		return
@@ -119,14 +187,20 @@ func (h *TransactionHandler) UpdateByID(w http.ResponseWriter, r *http.Request)
 	}
 
 	updatedTransaction.ID = searchedTransaction.ID
+	updatedTransaction.CreatedAt = searchedTransaction.CreatedAt
 
 	err = h.transactionCryptoProvider.Encrypt(&updatedTransaction)
+	if errors.Is(err, providers.ErrInvalidUserDocument) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
 	if err != nil {
 		setupInternalServerErrorResponse(w)
 		return
 	}
 
-	err = h.repository.UpdateByID(&updatedTransaction)
+	err = h.repository.UpdateByID(r.Context(), &updatedTransaction)
 	if err != nil {
 		setupInternalServerErrorResponse(w)
 		return
@@ -136,7 +210,7 @@ func (h *TransactionHandler) UpdateByID(w http.ResponseWriter, r *http.Request)
 func (h *TransactionHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
 	var idToBeDeleted = chi.URLParam(r, "id")
 
-	searchedTransaction, err := h.repository.FindByID(idToBeDeleted)
+	searchedTransaction, err := h.repository.FindByID(r.Context(), idToBeDeleted)
 	if err != nil {
 		setupInternalServerErrorResponse(w)
 		return
@@ -152,22 +226,234 @@ func (h *TransactionHandler) DeleteByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = h.repository.DeleteByID(idToBeDeleted)
+	err = h.repository.DeleteByID(r.Context(), idToBeDeleted)
+	if err != nil {
+		setupInternalServerErrorResponse(w)
+		return
+	}
+}
+
+type valueBucket struct {
+	MinValue float64 `json:"minValue"`
+	MaxValue float64 `json:"maxValue"`
+	Count    int     `json:"count"`
+}
+
+// CountByValueBucket reports how many transactions have their value in each
+// bucket, which works without decrypting the encrypted values.
+func (h *TransactionHandler) CountByValueBucket(w http.ResponseWriter, r *http.Request) {
+	countByBucket, err := h.repository.CountByValueBucket(r.Context(), h.valueBucketWidth)
 	if err != nil {
 		setupInternalServerErrorResponse(w)
 		return
 	}
+
+	buckets := make([]valueBucket, 0, len(countByBucket))
+	for bucket, count := range countByBucket {
+		buckets = append(buckets, valueBucket{
+			MinValue: float64(bucket) * h.valueBucketWidth,
+			MaxValue: float64(bucket+1) * h.valueBucketWidth,
+			Count:    count,
+		})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].MinValue < buckets[j].MinValue
+	})
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"bucketWidth": h.valueBucketWidth,
+		"buckets":     buckets,
+	})
+}
+
+// maskingPolicy returns the policy of the caller's role or, when asked for in
+// the reveal query parameter, a policy revealing no more than that one.
+func (h *TransactionHandler) maskingPolicy(w http.ResponseWriter, r *http.Request) (MaskingPolicy, bool) {
+	policy := h.masking.defaultPolicy
+	if rolePolicy, ok := h.masking.rolePolicies[r.Header.Get(h.masking.roleHeader)]; ok {
+		policy = rolePolicy
+	}
+
+	reveal := r.URL.Query().Get("reveal")
+	if reveal == "" {
+		return policy, true
+	}
+
+	requestedPolicy, err := ParseMaskingPolicy(reveal)
+	if err != nil {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "Unknown reveal policy, expected one of: full, masked, last-4, hidden.",
+		})
+		return "", false
+	}
+
+	if !policy.reveals(requestedPolicy) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "The caller's role can't reveal transactions as " + reveal + ".",
+		})
+		return "", false
+	}
+
+	return requestedPolicy, true
+}
+
+// decryptMatching decrypts the transactions for display: the erased or
+// corrupted ones are marked as such, the others masked. It returns the ones
+// within the filter's value bounds, which the database only narrowed down to
+// their buckets for the encrypted values.
+func (h *TransactionHandler) decryptMatching(transactions []*entities.Transaction, filter *repositories.TransactionFilter,
+	maskingPolicy MaskingPolicy) ([]*entities.Transaction, error) {
+	rowErrs, err := h.transactionCryptoProvider.DecryptMany(transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := make([]*entities.Transaction, 0, len(transactions))
+
+	for i, transaction := range transactions {
+		if filter.HasValueBounds() && !matchesValue(filter, transaction, rowErrs[i]) {
+			continue
+		}
+
+		matching = append(matching, transaction)
+
+		if errors.Is(rowErrs[i], providers.ErrErased) {
+			markErased(transaction)
+			continue
+		}
+
+		if rowErrs[i] != nil {
+			markCorrupted(transaction, providers.DecryptionErrorReason(rowErrs[i]))
+			continue
+		}
+
+		maskingPolicy.apply(transaction)
+	}
+
+	return matching, nil
+}
+
+// matchesValue leaves out the transactions whose value is encrypted and
+// couldn't be decrypted, since it's unknown.
+func matchesValue(filter *repositories.TransactionFilter, transaction *entities.Transaction, rowErr error) bool {
+	if rowErr != nil && transaction.EncryptedValue != "" {
+		return false
+	}
+
+	return filter.MatchesValue(transaction.Value)
+}
+
+// markErased leaves only the unencrypted fields of a transaction whose
+// customer was forgotten.
+func markErased(transaction *entities.Transaction) {
+	*transaction = entities.Transaction{
+		ID:        transaction.ID,
+		Value:     transaction.Value,
+		CreatedAt: transaction.CreatedAt,
+		Erased:    true,
+	}
 }
 
-func NewTransactionRouter(repository repositories.TransactionRepository, transactionCryptoProvider providers.TransactionCryptoProvider) *chi.Mux {
+// markCorrupted leaves only the unencrypted fields of a transaction that
+// can't be decrypted, along with the reason why.
+func markCorrupted(transaction *entities.Transaction, reason string) {
+	*transaction = entities.Transaction{
+		ID:              transaction.ID,
+		Value:           transaction.Value,
+		CreatedAt:       transaction.CreatedAt,
+		DecryptionError: reason,
+	}
+}
+
+func decryptionDisabled(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": "Transactions can't be read from this encrypt-only deployment.",
+	})
+}
+
+type transactionRouterConfig struct {
+	encryptOnly      bool
+	masking          maskingConfig
+	valueBucketWidth float64
+	encryptedValues  bool
+}
+
+type TransactionRouterOption func(*transactionRouterConfig)
+
+// WithEncryptOnly disables the routes that decrypt transactions, for the
+// deployments that don't hold any key that can decrypt them.
+func WithEncryptOnly() TransactionRouterOption {
+	return func(cfg *transactionRouterConfig) {
+		cfg.encryptOnly = true
+	}
+}
+
+// WithMaskingPolicies masks the CPF and card token returned to each caller
+// according to the policy of their role, read from the given header, or the
+// default policy for the callers without one, masked when not given. The
+// header is trusted as is: a gateway must authenticate the callers and strip
+// the header they send, replacing it with their role.
+func WithMaskingPolicies(roleHeader string, defaultPolicy MaskingPolicy, rolePolicies map[string]MaskingPolicy) TransactionRouterOption {
+	return func(cfg *transactionRouterConfig) {
+		cfg.masking = maskingConfig{roleHeader, defaultPolicy, rolePolicies}
+	}
+}
+
+// WithValueBuckets serves GET /transactions/value-buckets, counting the
+// transactions by buckets of the given width, the one their encrypted values
+// are bucketed by.
+func WithValueBuckets(bucketWidth float64) TransactionRouterOption {
+	return func(cfg *transactionRouterConfig) {
+		cfg.valueBucketWidth = bucketWidth
+	}
+}
+
+// WithEncryptedValues refuses to sort the transactions by value, which the
+// database can't do when the values are encrypted.
+func WithEncryptedValues() TransactionRouterOption {
+	return func(cfg *transactionRouterConfig) {
+		cfg.encryptedValues = true
+	}
+}
+
+func NewTransactionRouter(repository repositories.TransactionRepository, transactionCryptoProvider providers.TransactionCryptoProvider,
+	options ...TransactionRouterOption) *chi.Mux {
+	cfg := transactionRouterConfig{
+		masking: maskingConfig{roleHeader: DefaultRoleHeader, defaultPolicy: MaskingPolicyMasked},
+	}
+
+	for _, option := range options {
+		option(&cfg)
+	}
+
 	r := chi.NewRouter()
 
-	handler := &TransactionHandler{repository, transactionCryptoProvider}
+	handler := &TransactionHandler{repository, transactionCryptoProvider, cfg.masking, cfg.valueBucketWidth,
+		cfg.encryptedValues}
+
+	findAll, findByID, export := handler.FindAll, handler.FindByID, handler.Export
+	if cfg.encryptOnly {
+		findAll, findByID, export = decryptionDisabled, decryptionDisabled, decryptionDisabled
+	}
 
 	r.Route("/transactions", func(r chi.Router) {
 		r.Post("/", handler.Create)
-		r.Get("/", handler.FindAll)
-		r.Get("/{id}", handler.FindByID)
+		r.Get("/", findAll)
+		r.Get("/export", export)
+
+		if cfg.valueBucketWidth > 0 {
+			r.Get("/value-buckets", handler.CountByValueBucket)
+		}
+
+		r.Get("/{id}", findByID)
 		r.Put("/{id}", handler.UpdateByID)
 		r.Delete("/{id}", handler.DeleteByID)
 	})
@@ -1,10 +1,13 @@
 package handlers_test
 
 import (
+	"context"
+	dbrepositories "crypto-challenge/database/repositories"
 	"crypto-challenge/entities"
 	"crypto-challenge/handlers"
 	"crypto-challenge/mocks/crypto-challenge/database/repositories"
 	"crypto-challenge/mocks/crypto-challenge/providers"
+	cryptoproviders "crypto-challenge/providers"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,6 +16,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -47,7 +51,7 @@ func (ts *TransactionHandlerTestSuite) TestCreate() {
 	}
 
 	ts.cryptoProviderMock.EXPECT().Encrypt(mock.AnythingOfType("*entities.Transaction")).Return(nil).Once()
-	ts.repositoryMock.EXPECT().Create(mock.AnythingOfType("*entities.Transaction")).Return(nil).Once()
+	ts.repositoryMock.EXPECT().Create(mock.Anything, mock.AnythingOfType("*entities.Transaction")).Return(nil).Once()
 
 	// when
 	res := makeRequest(ts.router, http.MethodPost, "/transactions", strings.NewReader(validNewTransactionJSON))
@@ -57,6 +61,22 @@ func (ts *TransactionHandlerTestSuite) TestCreate() {
 	ts.Require().Empty(res.Body.Bytes())
 }
 
+func (ts *TransactionHandlerTestSuite) TestCreate_IgnoresCreatedAt() {
+	// given
+	body := `{"cpf":"50277613433","creditCardToken":"937","value":1299.80,"createdAt":"2020-01-01T00:00:00Z"}`
+
+	ts.cryptoProviderMock.EXPECT().Encrypt(mock.AnythingOfType("*entities.Transaction")).Return(nil).Once()
+	ts.repositoryMock.EXPECT().Create(mock.Anything, mock.MatchedBy(func(transaction *entities.Transaction) bool {
+		return transaction.CreatedAt.IsZero()
+	})).Return(nil).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodPost, "/transactions", strings.NewReader(body))
+
+	// then
+	ts.Require().Equal(http.StatusCreated, res.Code)
+}
+
 func (ts *TransactionHandlerTestSuite) TestCreate_WithInvalidRequestBody() {
 	// given
 	invalidNewTransactionJSON, err := generateRandomTransactionJSON(false, false)
@@ -93,6 +113,25 @@ func (ts *TransactionHandlerTestSuite) TestCreate_WithErrorOnEncryption() {
 		res.Body.String())
 }
 
+func (ts *TransactionHandlerTestSuite) TestCreate_WithInvalidUserDocument() {
+	// given
+	validNewTransactionJSON, err := generateRandomTransactionJSON(false, true)
+	if err != nil {
+		ts.T().Fatal(err)
+	}
+
+	ts.cryptoProviderMock.EXPECT().Encrypt(mock.AnythingOfType("*entities.Transaction")).
+		Return(cryptoproviders.ErrInvalidUserDocument)
+
+	// when
+	res := makeRequest(ts.router, http.MethodPost, "/transactions", strings.NewReader(validNewTransactionJSON))
+
+	// then
+	ts.Require().Equal(http.StatusUnprocessableEntity, res.Code)
+	ts.Assert().Empty(res.Body.Bytes())
+	ts.repositoryMock.AssertNotCalled(ts.T(), "Create", mock.Anything, mock.Anything)
+}
+
 func (ts *TransactionHandlerTestSuite) TestCreate_WithErrorOnCreate() {
 	// given
 	validNewTransactionJSON, err := generateRandomTransactionJSON(false, true)
@@ -101,7 +140,7 @@ func (ts *TransactionHandlerTestSuite) TestCreate_WithErrorOnCreate() {
 	}
 
 	ts.cryptoProviderMock.EXPECT().Encrypt(mock.AnythingOfType("*entities.Transaction")).Return(nil)
-	ts.repositoryMock.EXPECT().Create(mock.AnythingOfType("*entities.Transaction")).Return(errorOnMethod("create"))
+	ts.repositoryMock.EXPECT().Create(mock.Anything, mock.AnythingOfType("*entities.Transaction")).Return(errorOnMethod("create"))
 
 	// when
 	res := makeRequest(ts.router, http.MethodPost, "/transactions", strings.NewReader(validNewTransactionJSON))
@@ -119,7 +158,7 @@ func (ts *TransactionHandlerTestSuite) TestFindByID() {
 	// given
 	expectedTransaction := generateRandomTransaction(true)
 
-	ts.repositoryMock.EXPECT().FindByID(expectedTransaction.ID).Return(expectedTransaction, nil).Once()
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, expectedTransaction.ID).Return(expectedTransaction, nil).Once()
 	ts.cryptoProviderMock.EXPECT().Decrypt(mock.AnythingOfType("*entities.Transaction")).Return(nil).Once()
 
 	// when
@@ -143,7 +182,7 @@ func (ts *TransactionHandlerTestSuite) TestFindByID_WithErrorOnFindByID() {
 	// given
 	randomID := uuid.NewString()
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(nil, errorOnMethod("FindByID"))
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(nil, errorOnMethod("FindByID"))
 
 	// when
 	res := makeRequest(ts.router, http.MethodGet, fmt.Sprintf("/transactions/%s", randomID), nil)
@@ -160,7 +199,7 @@ func (ts *TransactionHandlerTestSuite) TestFindByID_WhenNotFound() {
 	// given
 	randomID := uuid.NewString()
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(nil, nil).Once()
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(nil, nil).Once()
 
 	// when
 	res := makeRequest(ts.router, http.MethodGet, fmt.Sprintf("/transactions/%s", randomID), nil)
@@ -176,7 +215,7 @@ func (ts *TransactionHandlerTestSuite) TestFindByID_WithErrorOnDecrypt() {
 	// given
 	randomID := uuid.NewString()
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(&entities.Transaction{}, nil)
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(&entities.Transaction{}, nil)
 	ts.cryptoProviderMock.EXPECT().Decrypt(mock.AnythingOfType("*entities.Transaction")).
 		Return(errorOnMethod("Decrypt"))
 
@@ -197,9 +236,8 @@ func (ts *TransactionHandlerTestSuite) TestFindAll() {
 		generateRandomTransaction(true),
 	}
 
-	ts.repositoryMock.EXPECT().FindAll().Return(expectedTransactions, nil)
-	ts.cryptoProviderMock.EXPECT().Decrypt(mock.AnythingOfType("*entities.Transaction")).
-		Return(nil).Times(2)
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expectedTransactions, nil)
+	ts.cryptoProviderMock.EXPECT().DecryptMany(expectedTransactions).Return(make([]error, 2), nil).Once()
 
 	// when
 	res := makeRequest(ts.router, http.MethodGet, "/transactions", nil)
@@ -208,19 +246,48 @@ func (ts *TransactionHandlerTestSuite) TestFindAll() {
 	ts.Require().Equal(http.StatusOK, res.Code)
 	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
 
-	var actualTransactions []*entities.Transaction
+	var actualPage transactionPage
+
+	err := json.Unmarshal(res.Body.Bytes(), &actualPage)
+	if err != nil {
+		ts.T().Fatal(err)
+	}
+
+	ts.Require().Equal(expectedTransactions, actualPage.Transactions)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithUserDocument() {
+	// given
+	expectedTransactions := []*entities.Transaction{
+		generateRandomTransaction(true),
+	}
+
+	ts.cryptoProviderMock.EXPECT().UserDocumentIndex("50277613433").Return("index", nil)
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.MatchedBy(
+		func(filter *dbrepositories.TransactionFilter) bool {
+			return filter.UserDocumentIndex == "index"
+		}), mock.Anything, mock.Anything).Return(expectedTransactions, nil)
+	ts.cryptoProviderMock.EXPECT().DecryptMany(expectedTransactions).Return(make([]error, 1), nil).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions?cpf=50277613433", nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+
+	var actualPage transactionPage
 
-	err := json.Unmarshal(res.Body.Bytes(), &actualTransactions)
+	err := json.Unmarshal(res.Body.Bytes(), &actualPage)
 	if err != nil {
 		ts.T().Fatal(err)
 	}
 
-	ts.Require().Equal(expectedTransactions, actualTransactions)
+	ts.Require().Equal(expectedTransactions, actualPage.Transactions)
 }
 
 func (ts *TransactionHandlerTestSuite) TestFindAll_WithErrorOnFindAll() {
 	// given
-	ts.repositoryMock.EXPECT().FindAll().Return(nil, errorOnMethod("FindAll"))
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errorOnMethod("FindAll"))
 
 	// when
 	res := makeRequest(ts.router, http.MethodGet, "/transactions", nil)
@@ -238,8 +305,8 @@ func (ts *TransactionHandlerTestSuite) TestFindAll_WithErrorOnDecrypt() {
 		generateRandomTransaction(true),
 	}
 
-	ts.repositoryMock.EXPECT().FindAll().Return(transactions, nil)
-	ts.cryptoProviderMock.EXPECT().Decrypt(transactions[0]).Return(errorOnMethod("Decrypt"))
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(transactions, nil)
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions).Return(nil, errorOnMethod("DecryptMany"))
 
 	// when
 	res := makeRequest(ts.router, http.MethodGet, "/transactions", nil)
@@ -251,6 +318,403 @@ func (ts *TransactionHandlerTestSuite) TestFindAll_WithErrorOnDecrypt() {
 	requireValidJSON(ts.T(), res.Body.Bytes(), InvalidJSONResponsePayload, res.Body.String())
 }
 
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithNextPage() {
+	// given
+	transactions := []*entities.Transaction{
+		generateRandomTransaction(true),
+		generateRandomTransaction(true),
+		generateRandomTransaction(true),
+	}
+	transactions[1].CreatedAt = time.Date(2024, 5, 1, 10, 30, 0, 123456000, time.UTC)
+
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, (*dbrepositories.TransactionCursor)(nil), 3).Return(transactions, nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions[:2]).Return(make([]error, 2), nil).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions?limit=2", nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+
+	var actualPage transactionPage
+	ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &actualPage))
+	ts.Require().Equal(transactions[:2], actualPage.Transactions)
+	ts.Require().NotEmpty(actualPage.NextCursor)
+
+	// given
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, &dbrepositories.TransactionCursor{
+		CreatedAt: transactions[1].CreatedAt,
+		Value:     transactions[1].Value,
+		ID:        transactions[1].ID,
+	}, 3).Return(transactions[2:], nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions[2:]).Return(make([]error, 1), nil).Once()
+
+	// when
+	res = makeRequest(ts.router, http.MethodGet, "/transactions?limit=2&cursor="+actualPage.NextCursor, nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+
+	var nextPage transactionPage
+	ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &nextPage))
+	ts.Require().Equal(transactions[2:], nextPage.Transactions)
+	ts.Require().Empty(nextPage.NextCursor)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithNextPageAfterNullValue() {
+	// given
+	transactions := []*entities.Transaction{generateRandomTransaction(true), generateRandomTransaction(true),
+		generateRandomTransaction(true)}
+	transactions[0].Value, transactions[0].NullValue = 0, true
+	transactions[1].Value, transactions[1].NullValue = 0, true
+
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, (*dbrepositories.TransactionCursor)(nil), 3).
+		Return(transactions, nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions[:2]).RunAndReturn(func(decrypted []*entities.Transaction) ([]error, error) {
+		for _, transaction := range decrypted {
+			transaction.Value = 1299.80
+		}
+
+		return make([]error, len(decrypted)), nil
+	}).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions?sort=value&limit=2", nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+
+	var actualPage transactionPage
+	ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &actualPage))
+	ts.Require().NotEmpty(actualPage.NextCursor)
+
+	// given
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, &dbrepositories.TransactionCursor{
+		CreatedAt: transactions[1].CreatedAt,
+		NullValue: true,
+		ID:        transactions[1].ID,
+	}, 3).Return(transactions[2:], nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions[2:]).Return(make([]error, 1), nil).Once()
+
+	// when
+	res = makeRequest(ts.router, http.MethodGet, "/transactions?sort=value&limit=2&cursor="+actualPage.NextCursor, nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithFilter() {
+	// given
+	router := handlers.NewTransactionRouter(ts.repositoryMock, ts.cryptoProviderMock, handlers.WithValueBuckets(50))
+
+	minValue, maxValue := 10.0, 100.5
+	createdFrom := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	createdTo := time.Date(2024, 5, 31, 23, 59, 59, 999999000, time.UTC)
+
+	expectedFilter := &dbrepositories.TransactionFilter{
+		UserDocumentIndex:         "index",
+		CreditCardTokenLast4Index: "last4 index",
+		MinValue:                  &minValue,
+		MaxValue:                  &maxValue,
+		ValueBucketWidth:          50,
+		CreatedFrom:               &createdFrom,
+		CreatedTo:                 &createdTo,
+		SortBy:                    dbrepositories.SortByValue,
+		Descending:                true,
+	}
+
+	ts.cryptoProviderMock.EXPECT().UserDocumentIndex("50277613433").Return("index", nil).Once()
+	ts.cryptoProviderMock.EXPECT().CreditCardTokenLast4Index("1111").Return("last4 index").Once()
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, expectedFilter, (*dbrepositories.TransactionCursor)(nil), 51).
+		Return([]*entities.Transaction{}, nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany([]*entities.Transaction{}).Return([]error{}, nil).Once()
+
+	// when
+	res := makeRequest(router, http.MethodGet, "/transactions?cpf=50277613433&cardLast4=1111&minValue=10&maxValue=100.5"+
+		"&createdFrom=2024-05-01&createdTo=2024-05-31&sort=value&direction=desc", nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithValueBoundsOnEncryptedValues() {
+	// given
+	inRange, outOfRange := generateRandomTransaction(true), generateRandomTransaction(true)
+	inRange.Value, outOfRange.Value = 50, 150
+
+	corrupted := generateRandomTransaction(true)
+	corrupted.Value, corrupted.EncryptedValue = 0, "v2:dek/encrypted_value:aes-256-gcm:00:00"
+
+	transactions := []*entities.Transaction{inRange, outOfRange, corrupted}
+
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(transactions, nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions).
+		Return([]error{nil, nil, cryptoproviders.ErrMalformedCiphertext}, nil).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions?minValue=0&maxValue=100", nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+
+	var actualPage transactionPage
+	ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &actualPage))
+	ts.Require().Equal([]*entities.Transaction{inRange}, actualPage.Transactions)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithInvalidFilter() {
+	for _, query := range []string{"cardLast4=111", "cardLast4=11a1", "minValue=ten", "maxValue=NaN",
+		"createdFrom=yesterday", "createdTo=2024-13-01", "sort=cpf", "direction=up"} {
+		// when
+		res := makeRequest(ts.router, http.MethodGet, "/transactions?"+query, nil)
+
+		// then
+		ts.Require().Equal(http.StatusBadRequest, res.Code, query)
+		ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+	}
+
+	ts.repositoryMock.AssertNotCalled(ts.T(), "FindByFilter", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithInvalidUserDocument() {
+	// given
+	ts.cryptoProviderMock.EXPECT().UserDocumentIndex("502776134").Return("", cryptoproviders.ErrInvalidUserDocument).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions?cpf=502776134", nil)
+
+	// then
+	ts.Require().Equal(http.StatusBadRequest, res.Code)
+	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+	ts.repositoryMock.AssertNotCalled(ts.T(), "FindByFilter", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_SortedByEncryptedValue() {
+	// given
+	router := handlers.NewTransactionRouter(ts.repositoryMock, ts.cryptoProviderMock, handlers.WithEncryptedValues())
+
+	// when
+	res := makeRequest(router, http.MethodGet, "/transactions?sort=value", nil)
+
+	// then
+	ts.Require().Equal(http.StatusBadRequest, res.Code)
+	ts.repositoryMock.AssertNotCalled(ts.T(), "FindByFilter", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (ts *TransactionHandlerTestSuite) TestExport_AsNDJSON() {
+	// given
+	transactions := []*entities.Transaction{generateRandomTransaction(true), generateRandomTransaction(true)}
+
+	ts.repositoryMock.EXPECT().IterateByFilter(mock.Anything, mock.Anything).Return(ts.iterate(transactions, nil), nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions).Return(make([]error, 2), nil).Once()
+
+	// when
+	res := makeExportRequest(ts.router, "/transactions/export", "")
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+	ts.Require().Equal("application/x-ndjson", res.Header().Get("Content-Type"))
+
+	decoder := json.NewDecoder(res.Body)
+	for _, expected := range transactions {
+		var actual *entities.Transaction
+		ts.Require().Nil(decoder.Decode(&actual))
+		ts.Require().Equal(expected, actual)
+	}
+
+	ts.Require().False(decoder.More())
+}
+
+func (ts *TransactionHandlerTestSuite) TestExport_AsCSV() {
+	// given
+	router := handlers.NewTransactionRouter(ts.repositoryMock, ts.cryptoProviderMock,
+		handlers.WithMaskingPolicies(handlers.DefaultRoleHeader, handlers.MaskingPolicyMasked, nil))
+
+	transaction := &entities.Transaction{
+		ID:              uuid.NewString(),
+		UserDocument:    "50277613433",
+		CreditCardToken: "8372950164821111",
+		Value:           1299.8,
+		CreatedAt:       time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC),
+	}
+	corrupted := &entities.Transaction{ID: uuid.NewString(), Value: 35.5, CreatedAt: transaction.CreatedAt}
+	transactions := []*entities.Transaction{transaction, corrupted}
+
+	ts.repositoryMock.EXPECT().IterateByFilter(mock.Anything, mock.Anything).Return(ts.iterate(transactions, nil), nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions).
+		Return([]error{nil, cryptoproviders.ErrMalformedCiphertext}, nil).Once()
+
+	// when
+	res := makeExportRequest(router, "/transactions/export", "text/csv")
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+	ts.Require().Equal("text/csv", res.Header().Get("Content-Type"))
+	ts.Require().Equal("id,cpf,creditCardToken,value,createdAt,erased,decryptionError\n"+
+		transaction.ID+",***.***.134-**,************1111,1299.8,2024-05-01T10:30:00Z,false,\n"+
+		corrupted.ID+",,,35.5,2024-05-01T10:30:00Z,false,malformed-ciphertext\n", res.Body.String())
+}
+
+func (ts *TransactionHandlerTestSuite) TestExport_AsCSVWithFormulas() {
+	// given
+	router := handlers.NewTransactionRouter(ts.repositoryMock, ts.cryptoProviderMock,
+		handlers.WithMaskingPolicies(handlers.DefaultRoleHeader, handlers.MaskingPolicyFull, nil))
+
+	transaction := generateRandomTransaction(true)
+	transaction.UserDocument, transaction.CreditCardToken = "=HYPERLINK(\"http://example.com\")", "+5511"
+
+	transactions := []*entities.Transaction{transaction}
+
+	ts.repositoryMock.EXPECT().IterateByFilter(mock.Anything, mock.Anything).Return(ts.iterate(transactions, nil), nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions).Return(make([]error, 1), nil).Once()
+
+	// when
+	res := makeExportRequest(router, "/transactions/export", "text/csv")
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+	ts.Require().Contains(res.Body.String(), transaction.ID+`,"'=HYPERLINK(""http://example.com"")",'+5511,`)
+}
+
+func (ts *TransactionHandlerTestSuite) TestExport_InBatches() {
+	// given
+	transactions := make([]*entities.Transaction, 150)
+	for i := range transactions {
+		transactions[i] = generateRandomTransaction(true)
+	}
+
+	ts.repositoryMock.EXPECT().IterateByFilter(mock.Anything, mock.MatchedBy(
+		func(filter *dbrepositories.TransactionFilter) bool {
+			return filter.CreditCardTokenLast4Index == "last4 index"
+		})).Return(ts.iterate(transactions, nil), nil).Once()
+	ts.cryptoProviderMock.EXPECT().CreditCardTokenLast4Index("1111").Return("last4 index").Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions[:100]).Return(make([]error, 100), nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions[100:]).Return(make([]error, 50), nil).Once()
+
+	// when
+	res := makeExportRequest(ts.router, "/transactions/export?cardLast4=1111", "application/x-ndjson")
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+	ts.Require().Equal(150, strings.Count(res.Body.String(), "\n"))
+}
+
+func (ts *TransactionHandlerTestSuite) TestExport_WithUnsupportedAccept() {
+	// when
+	res := makeExportRequest(ts.router, "/transactions/export", "application/xml")
+
+	// then
+	ts.Require().Equal(http.StatusNotAcceptable, res.Code)
+	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+	ts.repositoryMock.AssertNotCalled(ts.T(), "IterateByFilter", mock.Anything, mock.Anything)
+}
+
+func (ts *TransactionHandlerTestSuite) TestExport_WithQualityValues() {
+	for accept, expected := range map[string]string{
+		"text/csv;q=0, application/x-ndjson":        "application/x-ndjson",
+		"text/csv;q=0.5, application/x-ndjson":      "application/x-ndjson",
+		"application/x-ndjson;q=0.2, text/*;q=0.8":  "text/csv",
+		"application/x-ndjson;q=0, */*":             "text/csv",
+		"*/*;q=0.1, application/ndjson":             "application/ndjson",
+		"text/csv, application/x-ndjson":            "text/csv",
+		"text/csv;q=invalid, application/x-ndjson":  "application/x-ndjson",
+		"text/csv; Q=1.0 ,application/x-ndjson;q=1": "text/csv",
+	} {
+		// given
+		ts.repositoryMock.EXPECT().IterateByFilter(mock.Anything, mock.Anything).Return(ts.iterate(nil, nil), nil).Once()
+		ts.cryptoProviderMock.EXPECT().DecryptMany(mock.Anything).Return([]error{}, nil).Maybe()
+
+		// when
+		res := makeExportRequest(ts.router, "/transactions/export", accept)
+
+		// then
+		ts.Require().Equal(http.StatusOK, res.Code, accept)
+		ts.Require().Equal(expected, res.Header().Get("Content-Type"), accept)
+	}
+}
+
+func (ts *TransactionHandlerTestSuite) TestExport_WithRefusedTypes() {
+	for _, accept := range []string{"text/csv;q=0, application/x-ndjson;q=0", "*/*;q=0", "application/*;q=0, text/csv;q=0"} {
+		// when
+		res := makeExportRequest(ts.router, "/transactions/export", accept)
+
+		// then
+		ts.Require().Equal(http.StatusNotAcceptable, res.Code, accept)
+	}
+
+	ts.repositoryMock.AssertNotCalled(ts.T(), "IterateByFilter", mock.Anything, mock.Anything)
+}
+
+func (ts *TransactionHandlerTestSuite) TestExport_WithErrorOnIterate() {
+	// given
+	ts.repositoryMock.EXPECT().IterateByFilter(mock.Anything, mock.Anything).
+		Return(nil, errorOnMethod("IterateByFilter")).Once()
+
+	// when
+	res := makeExportRequest(ts.router, "/transactions/export", "text/csv")
+
+	// then
+	ts.Require().Equal(http.StatusInternalServerError, res.Code)
+	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+}
+
+func (ts *TransactionHandlerTestSuite) TestExport_WithErrorOnFirstBatch() {
+	// given
+	ts.repositoryMock.EXPECT().IterateByFilter(mock.Anything, mock.Anything).
+		Return(ts.iterate(nil, errorOnMethod("Next")), nil).Once()
+
+	// when
+	res := makeExportRequest(ts.router, "/transactions/export", "text/csv")
+
+	// then
+	ts.Require().Equal(http.StatusInternalServerError, res.Code)
+	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+}
+
+func (ts *TransactionHandlerTestSuite) TestExport_WithErrorAfterFirstBatch() {
+	// given
+	transactions := make([]*entities.Transaction, 100)
+	for i := range transactions {
+		transactions[i] = generateRandomTransaction(true)
+	}
+
+	ts.repositoryMock.EXPECT().IterateByFilter(mock.Anything, mock.Anything).Return(ts.iterate(transactions, nil), nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions).Return(make([]error, 100), nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany([]*entities.Transaction{}).Return(nil, errorOnMethod("DecryptMany")).Once()
+
+	// when / then
+	ts.Require().PanicsWithValue(http.ErrAbortHandler, func() {
+		makeExportRequest(ts.router, "/transactions/export", "text/csv")
+	})
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithLimitBeyondMaxPageSize() {
+	// given
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, mock.Anything, handlers.MaxPageSize+1).
+		Return([]*entities.Transaction{}, nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany([]*entities.Transaction{}).Return([]error{}, nil).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions?limit=100000", nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithInvalidPage() {
+	for _, query := range []string{"limit=0", "limit=ten", "cursor=invalid!", "cursor=bm9jb2xvbg"} {
+		// when
+		res := makeRequest(ts.router, http.MethodGet, "/transactions?"+query, nil)
+
+		// then
+		ts.Require().Equal(http.StatusBadRequest, res.Code, query)
+		ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+	}
+
+	ts.repositoryMock.AssertNotCalled(ts.T(), "FindByFilter", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func (ts *TransactionHandlerTestSuite) TestUpdateByID() {
 	// given
 	randomID := uuid.NewString()
@@ -260,10 +724,10 @@ func (ts *TransactionHandlerTestSuite) TestUpdateByID() {
 		ts.T().Fatal(err)
 	}
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(&entities.Transaction{}, nil)
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(&entities.Transaction{}, nil)
 	ts.cryptoProviderMock.EXPECT().Encrypt(mock.AnythingOfType("*entities.Transaction")).
 		Return(nil)
-	ts.repositoryMock.EXPECT().UpdateByID(mock.AnythingOfType("*entities.Transaction")).
+	ts.repositoryMock.EXPECT().UpdateByID(mock.Anything, mock.AnythingOfType("*entities.Transaction")).
 		Return(nil)
 
 	// when
@@ -277,6 +741,24 @@ func (ts *TransactionHandlerTestSuite) TestUpdateByID() {
 	ts.Require().Empty(res.Body.Bytes())
 }
 
+func (ts *TransactionHandlerTestSuite) TestUpdateByID_KeepsCreatedAt() {
+	// given
+	current := generateRandomTransaction(true)
+	body := `{"cpf":"50277613433","creditCardToken":"937","value":1299.80,"createdAt":"2020-01-01T00:00:00Z"}`
+
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, current.ID).Return(current, nil).Once()
+	ts.cryptoProviderMock.EXPECT().Encrypt(mock.AnythingOfType("*entities.Transaction")).Return(nil).Once()
+	ts.repositoryMock.EXPECT().UpdateByID(mock.Anything, mock.MatchedBy(func(transaction *entities.Transaction) bool {
+		return transaction.CreatedAt.Equal(current.CreatedAt)
+	})).Return(nil).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodPut, "/transactions/"+current.ID, strings.NewReader(body))
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+}
+
 func (ts *TransactionHandlerTestSuite) TestUpdateByID_WhenNotFound() {
 	// given
 	randomID := "abc"
@@ -286,7 +768,7 @@ func (ts *TransactionHandlerTestSuite) TestUpdateByID_WhenNotFound() {
 		ts.T().Fatal(err)
 	}
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(nil, nil)
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(nil, nil)
 
 	// when
 	res := makeRequest(ts.router, http.MethodPut, fmt.Sprintf("/transactions/%s", randomID),
@@ -308,7 +790,7 @@ func (ts *TransactionHandlerTestSuite) TestUpdateByID_WithErrorOnFindByID() {
 		ts.T().Fatal(err)
 	}
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(nil, errorOnMethod("FindByID"))
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(nil, errorOnMethod("FindByID"))
 
 	// when
 	res := makeRequest(ts.router, http.MethodPut, fmt.Sprintf("/transactions/%s", randomID),
@@ -330,7 +812,7 @@ func (ts *TransactionHandlerTestSuite) TestUpdateByID_WithInvalidBody() {
 		ts.T().Fatal(err)
 	}
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(&entities.Transaction{}, nil)
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(&entities.Transaction{}, nil)
 
 	// when
 	res := makeRequest(ts.router, http.MethodPut, fmt.Sprintf("/transactions/%s", randomID),
@@ -352,7 +834,7 @@ func (ts *TransactionHandlerTestSuite) TestUpdateByID_WithErrorOnEncrypt() {
 		ts.T().Fatal(err)
 	}
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(&entities.Transaction{}, nil)
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(&entities.Transaction{}, nil)
 	ts.cryptoProviderMock.EXPECT().Encrypt(mock.AnythingOfType("*entities.Transaction")).
 		Return(errorOnMethod("Encrypt"))
 
@@ -376,9 +858,9 @@ func (ts *TransactionHandlerTestSuite) TestUpdateByID_WithErrorOnUpdateByID() {
 		ts.T().Fatal(err)
 	}
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(&entities.Transaction{}, nil)
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(&entities.Transaction{}, nil)
 	ts.cryptoProviderMock.EXPECT().Encrypt(mock.AnythingOfType("*entities.Transaction")).Return(nil)
-	ts.repositoryMock.EXPECT().UpdateByID(mock.AnythingOfType("*entities.Transaction")).
+	ts.repositoryMock.EXPECT().UpdateByID(mock.Anything, mock.AnythingOfType("*entities.Transaction")).
 		Return(errorOnMethod("UpdateByID"))
 
 	// when
@@ -396,8 +878,8 @@ func (ts *TransactionHandlerTestSuite) TestDeleteByID() {
 	// given
 	randomID := uuid.NewString()
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(&entities.Transaction{}, nil)
-	ts.repositoryMock.EXPECT().DeleteByID(randomID).Return(nil)
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(&entities.Transaction{}, nil)
+	ts.repositoryMock.EXPECT().DeleteByID(mock.Anything, randomID).Return(nil)
 
 	// when
 	res := makeRequest(ts.router, http.MethodDelete, fmt.Sprintf("/transactions/%s", randomID), nil)
@@ -411,7 +893,7 @@ func (ts *TransactionHandlerTestSuite) TestDeleteByID_WithErrorOnFindByID() {
 	// given
 	randomID := uuid.NewString()
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(nil, errorOnMethod("DeleteByID"))
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(nil, errorOnMethod("DeleteByID"))
 
 	// when
 	res := makeRequest(ts.router, http.MethodDelete, fmt.Sprintf("/transactions/%s", randomID), nil)
@@ -425,7 +907,7 @@ func (ts *TransactionHandlerTestSuite) TestDeleteByID_WhenNotFound() {
 	// given
 	randomID := uuid.NewString()
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(nil, nil)
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(nil, nil)
 
 	// when
 	res := makeRequest(ts.router, http.MethodDelete, fmt.Sprintf("/transactions/%s", randomID), nil)
@@ -439,8 +921,8 @@ func (ts *TransactionHandlerTestSuite) TestDeleteByID_WithErrorOnDeleteByID() {
 	// given
 	randomID := uuid.NewString()
 
-	ts.repositoryMock.EXPECT().FindByID(randomID).Return(&entities.Transaction{}, nil)
-	ts.repositoryMock.EXPECT().DeleteByID(randomID).Return(errorOnMethod("DeleteByID"))
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(&entities.Transaction{}, nil)
+	ts.repositoryMock.EXPECT().DeleteByID(mock.Anything, randomID).Return(errorOnMethod("DeleteByID"))
 
 	// when
 	res := makeRequest(ts.router, http.MethodDelete, fmt.Sprintf("/transactions/%s", randomID), nil)
@@ -450,10 +932,276 @@ func (ts *TransactionHandlerTestSuite) TestDeleteByID_WithErrorOnDeleteByID() {
 	requireValidJSON(ts.T(), res.Body.Bytes(), InvalidJSONResponsePayload, res.Body.String())
 }
 
+func (ts *TransactionHandlerTestSuite) TestFindByID_WhenErased() {
+	// given
+	randomID := uuid.NewString()
+
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(&entities.Transaction{ID: randomID}, nil).Once()
+	ts.cryptoProviderMock.EXPECT().Decrypt(mock.AnythingOfType("*entities.Transaction")).
+		Return(cryptoproviders.ErrErased).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, fmt.Sprintf("/transactions/%s", randomID), nil)
+
+	// then
+	ts.Require().Equal(http.StatusGone, res.Code)
+	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+
+	var body map[string]any
+	ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &body))
+	ts.Require().Equal("erased", body["status"])
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithErasedTransaction() {
+	// given
+	readable := generateRandomTransaction(true)
+	erased := &entities.Transaction{ID: uuid.NewString(), Value: 1299.80, UserDocumentIndex: "index"}
+	transactions := []*entities.Transaction{readable, erased}
+
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(transactions, nil)
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions).Return([]error{nil, cryptoproviders.ErrErased}, nil).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions", nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+
+	var actualPage transactionPage
+
+	err := json.Unmarshal(res.Body.Bytes(), &actualPage)
+	if err != nil {
+		ts.T().Fatal(err)
+	}
+
+	ts.Require().Equal([]*entities.Transaction{
+		readable,
+		{ID: erased.ID, Value: erased.Value, Erased: true},
+	}, actualPage.Transactions)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindByID_WhenCorrupted() {
+	// given
+	randomID := uuid.NewString()
+
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, randomID).Return(&entities.Transaction{ID: randomID}, nil).Once()
+	ts.cryptoProviderMock.EXPECT().Decrypt(mock.AnythingOfType("*entities.Transaction")).
+		Return(fmt.Errorf("%w: tampered", cryptoproviders.ErrAuthenticationFailed)).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, fmt.Sprintf("/transactions/%s", randomID), nil)
+
+	// then
+	ts.Require().Equal(http.StatusInternalServerError, res.Code)
+	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+
+	var body map[string]any
+	ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &body))
+	ts.Require().Equal("corrupted", body["status"])
+	ts.Require().Equal("authentication-failed", body["reason"])
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithCorruptedTransactions() {
+	// given
+	readable := generateRandomTransaction(true)
+	malformed := generateRandomTransaction(true)
+	unknownKey := generateRandomTransaction(true)
+	transactions := []*entities.Transaction{readable, malformed, unknownKey}
+
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(transactions, nil)
+	ts.cryptoProviderMock.EXPECT().DecryptMany(transactions).Return([]error{
+		nil,
+		fmt.Errorf("%w: invalid envelope", cryptoproviders.ErrMalformedCiphertext),
+		fmt.Errorf("%w \"k9\"", cryptoproviders.ErrUnknownKeyID),
+	}, nil).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions", nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+
+	var actualPage transactionPage
+	ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &actualPage))
+
+	ts.Require().Equal([]*entities.Transaction{
+		readable,
+		{ID: malformed.ID, Value: malformed.Value, DecryptionError: "malformed-ciphertext"},
+		{ID: unknownKey.ID, Value: unknownKey.Value, DecryptionError: "unknown-key-id"},
+	}, actualPage.Transactions)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindByID_WhenEncryptOnly() {
+	// given
+	router := handlers.NewTransactionRouter(ts.repositoryMock, ts.cryptoProviderMock, handlers.WithEncryptOnly())
+
+	// when
+	findByIDRes := makeRequest(router, http.MethodGet, "/transactions/"+uuid.NewString(), nil)
+	findAllRes := makeRequest(router, http.MethodGet, "/transactions", nil)
+
+	// then
+	ts.Require().Equal(http.StatusForbidden, findByIDRes.Code)
+	ts.Require().Equal(http.StatusForbidden, findAllRes.Code)
+	ts.Require().Equal("application/json", findAllRes.Header().Get("Content-Type"))
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithRequestContext() {
+	// given
+	type contextKey struct{}
+
+	req := httptest.NewRequest(http.MethodGet, "/transactions", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextKey{}, "request"))
+
+	ts.repositoryMock.EXPECT().FindByFilter(mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Value(contextKey{}) == "request"
+	}), mock.Anything, mock.Anything, mock.Anything).Return([]*entities.Transaction{}, nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany([]*entities.Transaction{}).Return([]error{}, nil).Once()
+
+	res := httptest.NewRecorder()
+
+	// when
+	ts.router.ServeHTTP(res, req)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+}
+
+func (ts *TransactionHandlerTestSuite) TestCountByValueBucket() {
+	// given
+	router := handlers.NewTransactionRouter(ts.repositoryMock, ts.cryptoProviderMock, handlers.WithValueBuckets(100))
+
+	ts.repositoryMock.EXPECT().CountByValueBucket(mock.Anything, float64(100)).Return(map[int64]int{12: 3, 0: 5}, nil).Once()
+
+	// when
+	res := makeRequest(router, http.MethodGet, "/transactions/value-buckets", nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+	ts.Require().JSONEq(`{"bucketWidth":100,"buckets":[{"minValue":0,"maxValue":100,"count":5},`+
+		`{"minValue":1200,"maxValue":1300,"count":3}]}`, res.Body.String())
+}
+
+func (ts *TransactionHandlerTestSuite) TestCountByValueBucket_WithRepositoryError() {
+	// given
+	router := handlers.NewTransactionRouter(ts.repositoryMock, ts.cryptoProviderMock, handlers.WithValueBuckets(100))
+
+	ts.repositoryMock.EXPECT().CountByValueBucket(mock.Anything, float64(100)).Return(nil, errorOnMethod("CountByValueBucket")).Once()
+
+	// when
+	res := makeRequest(router, http.MethodGet, "/transactions/value-buckets", nil)
+
+	// then
+	ts.Require().Equal(http.StatusInternalServerError, res.Code)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindByID_WithMaskingPolicies() {
+	testCases := map[handlers.MaskingPolicy][2]string{
+		handlers.MaskingPolicyFull:   {"502.776.134-33", "8372950164821111"},
+		handlers.MaskingPolicyMasked: {"***.***.134-**", "************1111"},
+		handlers.MaskingPolicyLast4:  {"3433", "1111"},
+		handlers.MaskingPolicyHidden: {"", ""},
+	}
+
+	for policy, expected := range testCases {
+		// given
+		router := handlers.NewTransactionRouter(ts.repositoryMock, ts.cryptoProviderMock,
+			handlers.WithMaskingPolicies("X-Role", handlers.MaskingPolicyHidden,
+				map[string]handlers.MaskingPolicy{"caller": policy}))
+
+		transaction := &entities.Transaction{ID: uuid.NewString(), UserDocument: "502.776.134-33",
+			CreditCardToken: "8372950164821111"}
+
+		ts.repositoryMock.EXPECT().FindByID(mock.Anything, transaction.ID).Return(transaction, nil).Once()
+		ts.cryptoProviderMock.EXPECT().Decrypt(transaction).Return(nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/transactions/"+transaction.ID, nil)
+		req.Header.Set("X-Role", "caller")
+		res := httptest.NewRecorder()
+
+		// when
+		router.ServeHTTP(res, req)
+
+		// then
+		ts.Require().Equal(http.StatusOK, res.Code, policy)
+
+		var actualTransaction entities.Transaction
+		ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &actualTransaction))
+		ts.Require().Equal(expected[0], actualTransaction.UserDocument, policy)
+		ts.Require().Equal(expected[1], actualTransaction.CreditCardToken, policy)
+	}
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindByID_MaskedByDefault() {
+	// given
+	transaction := &entities.Transaction{ID: uuid.NewString(), UserDocument: "502.776.134-33",
+		CreditCardToken: "8372950164821111"}
+
+	ts.repositoryMock.EXPECT().FindByID(mock.Anything, transaction.ID).Return(transaction, nil).Once()
+	ts.cryptoProviderMock.EXPECT().Decrypt(transaction).Return(nil).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions/"+transaction.ID, nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+
+	var actualTransaction entities.Transaction
+	ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &actualTransaction))
+	ts.Require().Equal("***.***.134-**", actualTransaction.UserDocument)
+	ts.Require().Equal("************1111", actualTransaction.CreditCardToken)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithRevealParameter() {
+	// given
+	transaction := &entities.Transaction{ID: uuid.NewString(), UserDocument: "50277613433", CreditCardToken: "937"}
+
+	ts.repositoryMock.EXPECT().FindByFilter(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*entities.Transaction{transaction}, nil).Once()
+	ts.cryptoProviderMock.EXPECT().DecryptMany([]*entities.Transaction{transaction}).Return(make([]error, 1), nil).Once()
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions?reveal=masked", nil)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+
+	var actualPage transactionPage
+	ts.Require().Nil(json.Unmarshal(res.Body.Bytes(), &actualPage))
+	ts.Require().Equal("***.***.134-**", actualPage.Transactions[0].UserDocument)
+	ts.Require().Equal("***", actualPage.Transactions[0].CreditCardToken)
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindAll_WithRevealParameterBeyondRole() {
+	// given
+	router := handlers.NewTransactionRouter(ts.repositoryMock, ts.cryptoProviderMock,
+		handlers.WithMaskingPolicies(handlers.DefaultRoleHeader, handlers.MaskingPolicyMasked, nil))
+
+	// when
+	res := makeRequest(router, http.MethodGet, "/transactions?reveal=full", nil)
+
+	// then
+	ts.Require().Equal(http.StatusForbidden, res.Code)
+	requireValidJSON(ts.T(), res.Body.Bytes(), InvalidJSONResponsePayload, res.Body.String())
+}
+
+func (ts *TransactionHandlerTestSuite) TestFindByID_WithUnknownRevealParameter() {
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions/"+uuid.NewString()+"?reveal=everything", nil)
+
+	// then
+	ts.Require().Equal(http.StatusBadRequest, res.Code)
+	requireValidJSON(ts.T(), res.Body.Bytes(), InvalidJSONResponsePayload, res.Body.String())
+}
+
 func TestTransactionHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(TransactionHandlerTestSuite))
 }
 
+type transactionPage struct {
+	Transactions []*entities.Transaction `json:"transactions"`
+	NextCursor   string                  `json:"nextCursor"`
+}
+
 func generateRandomTransactionJSON(withID, validJSON bool) (string, error) {
 	t := generateRandomTransaction(withID)
 
@@ -497,6 +1245,31 @@ func requireValidJSON(t *testing.T, data []byte, msgAndArgs ...string) {
 	require.True(t, json.Valid(data), msgAndArgs)
 }
 
+// iterate returns an iterator over the transactions, failing with err once
+// they're all read.
+func (ts *TransactionHandlerTestSuite) iterate(transactions []*entities.Transaction, err error) *repositories.MockTransactionIterator {
+	iterator := repositories.NewMockTransactionIterator(ts.T())
+	next := -1
+
+	iterator.EXPECT().Next().RunAndReturn(func() bool {
+		next++
+		return next < len(transactions)
+	})
+	iterator.EXPECT().Transaction().RunAndReturn(func() *entities.Transaction {
+		return transactions[next]
+	}).Maybe()
+	iterator.EXPECT().Err().RunAndReturn(func() error {
+		if next < len(transactions) {
+			return nil
+		}
+
+		return err
+	})
+	iterator.EXPECT().Close().Return(nil).Once()
+
+	return iterator
+}
+
 func errorOnMethod(method string) error {
 	return fmt.Errorf("error on %s", method)
 }
@@ -509,3 +1282,13 @@ func makeRequest(router *chi.Mux, method, path string, body io.Reader) *httptest
 
 	return rr
 }
+
+func makeExportRequest(router *chi.Mux, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept", accept)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	return rr
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"crypto-challenge/providers"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SealedHandler answers 503 until it is unsealed with the handler serving the
+// requests from then on.
+type SealedHandler struct {
+	handler atomic.Pointer[http.Handler]
+}
+
+func NewSealedHandler() *SealedHandler {
+	return &SealedHandler{}
+}
+
+func (h *SealedHandler) Unseal(handler http.Handler) {
+	h.handler.Store(&handler)
+}
+
+func (h *SealedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler := h.handler.Load(); handler != nil {
+		(*handler).ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Header().Add("Retry-After", "30")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": "The server is sealed, it serves requests once enough key shares are submitted.",
+	})
+}
+
+type UnsealHandler struct {
+	unsealer providers.Unsealer
+}
+
+type unsealRequest struct {
+	Share string `json:"share"`
+}
+
+func (h *UnsealHandler) Unseal(w http.ResponseWriter, r *http.Request) {
+	var request unsealRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.Share == "" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	status, err := h.unsealer.SubmitKeyShare(request.Share)
+
+	var message string
+	switch {
+	case errors.Is(err, providers.ErrInvalidKeyShare):
+		message = "Invalid key share."
+	case errors.Is(err, providers.ErrKeySharesMismatch):
+		message = "The key shares don't rebuild the key, submit them again."
+	case errors.Is(err, providers.ErrKeyShareAlreadySubmitted):
+		message = "The key share was already submitted."
+	}
+
+	if message != "" {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":  message,
+			"status": h.unsealer.Status(),
+		})
+		return
+	}
+
+	if err != nil {
+		setupInternalServerErrorResponse(w)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+func (h *UnsealHandler) Status(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.unsealer.Status())
+}
+
+// NewUnsealRouter serves the unseal routes, to be mounted at /admin, behind
+// the given bearer token.
+func NewUnsealRouter(unsealer providers.Unsealer, token string) *chi.Mux {
+	r := chi.NewRouter()
+
+	handler := &UnsealHandler{unsealer}
+
+	r.Use(requireBearerToken(token))
+
+	r.Post("/unseal", handler.Unseal)
+	r.Get("/seal-status", handler.Status)
+
+	return r
+}
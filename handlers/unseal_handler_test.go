@@ -0,0 +1,146 @@
+package handlers_test
+
+import (
+	"crypto-challenge/handlers"
+	"crypto-challenge/mocks/crypto-challenge/providers"
+	cryptoproviders "crypto-challenge/providers"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/suite"
+)
+
+const unsealToken = "unseal-token"
+
+type UnsealHandlerTestSuite struct {
+	suite.Suite
+	router        *chi.Mux
+	sealedHandler *handlers.SealedHandler
+	unsealerMock  *providers.MockUnsealer
+}
+
+func (ts *UnsealHandlerTestSuite) SetupTest() {
+	ts.router = chi.NewRouter()
+
+	ts.sealedHandler = handlers.NewSealedHandler()
+	ts.unsealerMock = providers.NewMockUnsealer(ts.T())
+
+	ts.router.Mount("/admin", handlers.NewUnsealRouter(ts.unsealerMock, unsealToken))
+	ts.router.Mount("/", ts.sealedHandler)
+}
+
+func (ts *UnsealHandlerTestSuite) TestSealedHandler_WhileSealed() {
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions", nil)
+
+	// then
+	ts.Require().Equal(http.StatusServiceUnavailable, res.Code)
+	ts.Require().Equal("application/json", res.Header().Get("Content-Type"))
+	requireValidJSON(ts.T(), res.Body.Bytes(), InvalidJSONResponsePayload, res.Body.String())
+}
+
+func (ts *UnsealHandlerTestSuite) TestSealedHandler_OnceUnsealed() {
+	// given
+	ts.sealedHandler.Unseal(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	// when
+	res := makeRequest(ts.router, http.MethodGet, "/transactions", nil)
+
+	// then
+	ts.Require().Equal(http.StatusTeapot, res.Code)
+}
+
+func (ts *UnsealHandlerTestSuite) TestUnseal() {
+	// given
+	ts.unsealerMock.EXPECT().SubmitKeyShare("3-1-ab-0011223344556677").
+		Return(&cryptoproviders.SealStatus{Sealed: true, Progress: 1, Threshold: 3}, nil).Once()
+
+	// when
+	res := ts.makeUnsealRequest(`{"share":"3-1-ab-0011223344556677"}`, unsealToken)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+	ts.Require().JSONEq(`{"sealed":true,"progress":1,"threshold":3}`, res.Body.String())
+}
+
+func (ts *UnsealHandlerTestSuite) TestUnseal_WithInvalidShare() {
+	// given
+	ts.unsealerMock.EXPECT().SubmitKeyShare("invalid").Return(nil, cryptoproviders.ErrInvalidKeyShare).Once()
+	ts.unsealerMock.EXPECT().Status().Return(&cryptoproviders.SealStatus{Sealed: true}).Once()
+
+	// when
+	res := ts.makeUnsealRequest(`{"share":"invalid"}`, unsealToken)
+
+	// then
+	ts.Require().Equal(http.StatusUnprocessableEntity, res.Code)
+	ts.Require().JSONEq(`{"error":"Invalid key share.","status":{"sealed":true,"progress":0,"threshold":0}}`,
+		res.Body.String())
+}
+
+func (ts *UnsealHandlerTestSuite) TestUnseal_WithMismatchingShares() {
+	// given
+	ts.unsealerMock.EXPECT().SubmitKeyShare("2-2-ab-0011223344556677").Return(nil, cryptoproviders.ErrKeySharesMismatch).Once()
+	ts.unsealerMock.EXPECT().Status().Return(&cryptoproviders.SealStatus{Sealed: true}).Once()
+
+	// when
+	res := ts.makeUnsealRequest(`{"share":"2-2-ab-0011223344556677"}`, unsealToken)
+
+	// then
+	ts.Require().Equal(http.StatusUnprocessableEntity, res.Code)
+	requireValidJSON(ts.T(), res.Body.Bytes(), InvalidJSONResponsePayload, res.Body.String())
+}
+
+func (ts *UnsealHandlerTestSuite) TestUnseal_WhenUnsealingFails() {
+	// given
+	ts.unsealerMock.EXPECT().SubmitKeyShare("2-2-ab-0011223344556677").Return(nil, errors.New("unseal error")).Once()
+
+	// when
+	res := ts.makeUnsealRequest(`{"share":"2-2-ab-0011223344556677"}`, unsealToken)
+
+	// then
+	ts.Require().Equal(http.StatusInternalServerError, res.Code)
+}
+
+func (ts *UnsealHandlerTestSuite) TestUnseal_WithoutToken() {
+	// when
+	res := ts.makeUnsealRequest(`{"share":"3-1-ab-0011223344556677"}`, "wrong-token")
+
+	// then
+	ts.Require().Equal(http.StatusUnauthorized, res.Code)
+}
+
+func (ts *UnsealHandlerTestSuite) TestSealStatus() {
+	// given
+	ts.unsealerMock.EXPECT().Status().Return(&cryptoproviders.SealStatus{Sealed: false}).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/seal-status", nil)
+	req.Header.Set("Authorization", "Bearer "+unsealToken)
+
+	// when
+	res := httptest.NewRecorder()
+	ts.router.ServeHTTP(res, req)
+
+	// then
+	ts.Require().Equal(http.StatusOK, res.Code)
+	ts.Require().JSONEq(`{"sealed":false,"progress":0,"threshold":0}`, res.Body.String())
+}
+
+func (ts *UnsealHandlerTestSuite) makeUnsealRequest(body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/admin/unseal", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res := httptest.NewRecorder()
+	ts.router.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestUnsealHandler(t *testing.T) {
+	suite.Run(t, new(UnsealHandlerTestSuite))
+}
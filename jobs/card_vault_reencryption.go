@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"context"
+	"crypto-challenge/database/repositories"
+	"crypto-challenge/entities"
+	"crypto-challenge/providers"
+	"log"
+)
+
+// CardVaultReencryptionJob rewraps the data key of every card in the vault
+// under the primary master key. Like CustomerKeyReencryptionJob, it skips the
+// entries already under the primary key and keeps no checkpoint.
+type CardVaultReencryptionJob struct {
+	repository    repositories.CardVaultRepository
+	cardTokenizer *providers.VaultCardTokenizer
+	primaryKeyID  string
+	chunkSize     int
+}
+
+func NewCardVaultReencryptionJob(repository repositories.CardVaultRepository, cardTokenizer *providers.VaultCardTokenizer,
+	primaryKeyID string, chunkSize int) *CardVaultReencryptionJob {
+	return &CardVaultReencryptionJob{repository, cardTokenizer, primaryKeyID, chunkSize}
+}
+
+func (j *CardVaultReencryptionJob) Run(ctx context.Context) (*ReencryptionResult, error) {
+	result := &ReencryptionResult{}
+	afterToken := ""
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		chunk, err := j.repository.FindAfterToken(afterToken, j.chunkSize)
+		if err != nil {
+			return result, err
+		}
+
+		if len(chunk) == 0 {
+			return result, nil
+		}
+
+		for _, current := range chunk {
+			if err := j.rewrap(current, result); err != nil {
+				return result, err
+			}
+		}
+
+		afterToken = chunk[len(chunk)-1].Token
+
+		log.Printf("Card vault re-encryption progress: %+v\n", *result)
+	}
+}
+
+func (j *CardVaultReencryptionJob) rewrap(current *entities.CardVaultEntry, result *ReencryptionResult) error {
+	if isCurrentEnvelope(current.DataKey, j.primaryKeyID) {
+		result.Skipped++
+		return nil
+	}
+
+	updated := *current
+
+	if err := j.cardTokenizer.RewrapDataKey(&updated); err != nil {
+		return err
+	}
+
+	replaced, err := j.repository.ReplaceDataKey(current, &updated)
+	if err != nil {
+		return err
+	}
+
+	if !replaced {
+		result.Conflicts++
+		return nil
+	}
+
+	result.Rewrapped++
+
+	return nil
+}
+
+// CountByKeyID reports how many cards in the vault have their data key
+// wrapped by each master key.
+func (j *CardVaultReencryptionJob) CountByKeyID(ctx context.Context) (map[string]int, error) {
+	countByKeyID := make(map[string]int)
+	afterToken := ""
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		chunk, err := j.repository.FindAfterToken(afterToken, j.chunkSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(chunk) == 0 {
+			return countByKeyID, nil
+		}
+
+		for _, entry := range chunk {
+			countByKeyID[keyIDOf(entry.DataKey)]++
+		}
+
+		afterToken = chunk[len(chunk)-1].Token
+	}
+}
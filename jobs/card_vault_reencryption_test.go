@@ -0,0 +1,82 @@
+package jobs_test
+
+import (
+	"context"
+	"crypto-challenge/entities"
+	"crypto-challenge/jobs"
+	"crypto-challenge/mocks/crypto-challenge/database/repositories"
+	"crypto-challenge/providers"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type CardVaultReencryptionJobTestSuite struct {
+	suite.Suite
+	repositoryMock *repositories.MockCardVaultRepository
+	blindIndex     *providers.HmacSha256BlindIndex
+	underTest      *jobs.CardVaultReencryptionJob
+}
+
+func (ts *CardVaultReencryptionJobTestSuite) SetupTest() {
+	ts.repositoryMock = repositories.NewMockCardVaultRepository(ts.T())
+	ts.blindIndex = providers.NewHmacSha256BlindIndex(mustDecodeHex(blindIndexKey))
+
+	rotatedKeyring := providers.NewKeyring("k2", mustDecodeHex(newSecretKey))
+	ts.Require().Nil(rotatedKeyring.AddDecryptOnlyKey("k1", mustDecodeHex(oldSecretKey)))
+	kms := providers.NewLocalKeyManagementService(rotatedKeyring, providers.AlgorithmAesGcm256)
+
+	cardTokenizer := providers.NewVaultCardTokenizer(ts.repositoryMock, kms, ts.blindIndex, providers.AlgorithmAesGcm256)
+
+	ts.underTest = jobs.NewCardVaultReencryptionJob(ts.repositoryMock, cardTokenizer, "k2", 2)
+}
+
+func (ts *CardVaultReencryptionJobTestSuite) TestRun() {
+	// given
+	onOldKey := ts.cardVaultEntry("8372950164821111", "k1", oldSecretKey)
+	onNewKey := ts.cardVaultEntry("9372950164821111", "k2", newSecretKey)
+
+	ts.repositoryMock.EXPECT().FindAfterToken("", 2).Return([]*entities.CardVaultEntry{onOldKey, onNewKey}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterToken(onNewKey.Token, 2).Return([]*entities.CardVaultEntry{}, nil).Once()
+	ts.repositoryMock.EXPECT().ReplaceDataKey(onOldKey, mock.MatchedBy(func(updated *entities.CardVaultEntry) bool {
+		return strings.HasPrefix(updated.DataKey, "v2:k2:") && updated.Pan == onOldKey.Pan
+	})).Return(true, nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Rewrapped: 1, Skipped: 1}, *result)
+}
+
+func (ts *CardVaultReencryptionJobTestSuite) TestCountByKeyID() {
+	// given
+	onOldKey := ts.cardVaultEntry("8372950164821111", "k1", oldSecretKey)
+
+	ts.repositoryMock.EXPECT().FindAfterToken("", 2).Return([]*entities.CardVaultEntry{onOldKey}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterToken(onOldKey.Token, 2).Return([]*entities.CardVaultEntry{}, nil).Once()
+
+	// when
+	countByKeyID, err := ts.underTest.CountByKeyID(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(map[string]int{"k1": 1}, countByKeyID)
+}
+
+func TestCardVaultReencryptionJobTestSuite(t *testing.T) {
+	suite.Run(t, new(CardVaultReencryptionJobTestSuite))
+}
+
+func (ts *CardVaultReencryptionJobTestSuite) cardVaultEntry(token, keyID, secretKey string) *entities.CardVaultEntry {
+	kms := providers.NewLocalKeyManagementService(providers.NewKeyring(keyID, mustDecodeHex(secretKey)),
+		providers.AlgorithmAesGcm256)
+
+	entry := &entities.CardVaultEntry{Token: token, Pan: "4111111111111111"}
+	ts.Require().Nil(providers.NewFieldEncryptor(kms, ts.blindIndex, providers.AlgorithmAesGcm256).Encrypt("card_vault", entry))
+
+	return entry
+}
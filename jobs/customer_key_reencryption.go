@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"context"
+	"crypto-challenge/database/repositories"
+	"crypto-challenge/entities"
+	"crypto-challenge/providers"
+	"log"
+)
+
+// CustomerKeyReencryptionJob rewraps the private key of every customer under
+// the primary master key. It is cheap to run again, since the keys already
+// under the primary key are skipped, so it keeps no checkpoint.
+type CustomerKeyReencryptionJob struct {
+	repository   repositories.CustomerKeyRepository
+	customerKeys *providers.CustomerKeys
+	primaryKeyID string
+	chunkSize    int
+}
+
+func NewCustomerKeyReencryptionJob(repository repositories.CustomerKeyRepository, customerKeys *providers.CustomerKeys,
+	primaryKeyID string, chunkSize int) *CustomerKeyReencryptionJob {
+	return &CustomerKeyReencryptionJob{repository, customerKeys, primaryKeyID, chunkSize}
+}
+
+func (j *CustomerKeyReencryptionJob) Run(ctx context.Context) (*ReencryptionResult, error) {
+	result := &ReencryptionResult{}
+	afterUserDocumentIndex := ""
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		chunk, err := j.repository.FindAfterUserDocumentIndex(afterUserDocumentIndex, j.chunkSize)
+		if err != nil {
+			return result, err
+		}
+
+		if len(chunk) == 0 {
+			return result, nil
+		}
+
+		for _, current := range chunk {
+			if err := j.rewrap(current, result); err != nil {
+				return result, err
+			}
+		}
+
+		afterUserDocumentIndex = chunk[len(chunk)-1].UserDocumentIndex
+
+		log.Printf("Customer keys re-encryption progress: %+v\n", *result)
+	}
+}
+
+func (j *CustomerKeyReencryptionJob) rewrap(current *entities.CustomerKey, result *ReencryptionResult) error {
+	if isCurrentEnvelope(current.PrivateKey, j.primaryKeyID) {
+		result.Skipped++
+		return nil
+	}
+
+	updated := *current
+
+	if err := j.customerKeys.Rewrap(&updated); err != nil {
+		return err
+	}
+
+	replaced, err := j.repository.ReplacePrivateKey(current, &updated)
+	if err != nil {
+		return err
+	}
+
+	// The customer was forgotten after their key was read.
+	if !replaced {
+		result.Conflicts++
+		return nil
+	}
+
+	result.Rewrapped++
+
+	return nil
+}
+
+// CountByKeyID reports how many customer keys are wrapped by each master key.
+func (j *CustomerKeyReencryptionJob) CountByKeyID(ctx context.Context) (map[string]int, error) {
+	countByKeyID := make(map[string]int)
+	afterUserDocumentIndex := ""
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		chunk, err := j.repository.FindAfterUserDocumentIndex(afterUserDocumentIndex, j.chunkSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(chunk) == 0 {
+			return countByKeyID, nil
+		}
+
+		for _, customerKey := range chunk {
+			countByKeyID[keyIDOf(customerKey.PrivateKey)]++
+		}
+
+		afterUserDocumentIndex = chunk[len(chunk)-1].UserDocumentIndex
+	}
+}
@@ -0,0 +1,109 @@
+package jobs_test
+
+import (
+	"context"
+	"crypto-challenge/entities"
+	"crypto-challenge/jobs"
+	"crypto-challenge/mocks/crypto-challenge/database/repositories"
+	"crypto-challenge/providers"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type CustomerKeyReencryptionJobTestSuite struct {
+	suite.Suite
+	repositoryMock *repositories.MockCustomerKeyRepository
+	underTest      *jobs.CustomerKeyReencryptionJob
+}
+
+func (ts *CustomerKeyReencryptionJobTestSuite) SetupTest() {
+	ts.repositoryMock = repositories.NewMockCustomerKeyRepository(ts.T())
+
+	rotatedKeyring := providers.NewKeyring("k2", mustDecodeHex(newSecretKey))
+	ts.Require().Nil(rotatedKeyring.AddDecryptOnlyKey("k1", mustDecodeHex(oldSecretKey)))
+	kms := providers.NewLocalKeyManagementService(rotatedKeyring, providers.AlgorithmAesGcm256)
+
+	ts.underTest = jobs.NewCustomerKeyReencryptionJob(ts.repositoryMock, providers.NewCustomerKeys(ts.repositoryMock, kms), "k2", 2)
+}
+
+func (ts *CustomerKeyReencryptionJobTestSuite) TestRun() {
+	// given
+	onOldKey := ts.customerKey("1", "k1", oldSecretKey)
+	onNewKey := ts.customerKey("2", "k2", newSecretKey)
+
+	ts.repositoryMock.EXPECT().FindAfterUserDocumentIndex("", 2).
+		Return([]*entities.CustomerKey{onOldKey, onNewKey}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterUserDocumentIndex(onNewKey.UserDocumentIndex, 2).
+		Return([]*entities.CustomerKey{}, nil).Once()
+	ts.repositoryMock.EXPECT().ReplacePrivateKey(onOldKey, mock.MatchedBy(func(updated *entities.CustomerKey) bool {
+		return strings.HasPrefix(updated.PrivateKey, "v2:k2:")
+	})).Return(true, nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Rewrapped: 1, Skipped: 1}, *result)
+}
+
+func (ts *CustomerKeyReencryptionJobTestSuite) TestRun_WhenErasedConcurrently() {
+	// given
+	onOldKey := ts.customerKey("1", "k1", oldSecretKey)
+
+	ts.repositoryMock.EXPECT().FindAfterUserDocumentIndex("", 2).Return([]*entities.CustomerKey{onOldKey}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterUserDocumentIndex(onOldKey.UserDocumentIndex, 2).
+		Return([]*entities.CustomerKey{}, nil).Once()
+	ts.repositoryMock.EXPECT().ReplacePrivateKey(onOldKey, mock.AnythingOfType("*entities.CustomerKey")).
+		Return(false, nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Conflicts: 1}, *result)
+}
+
+func (ts *CustomerKeyReencryptionJobTestSuite) TestCountByKeyID() {
+	// given
+	onOldKey := ts.customerKey("1", "k1", oldSecretKey)
+	onNewKey := ts.customerKey("2", "k2", newSecretKey)
+
+	ts.repositoryMock.EXPECT().FindAfterUserDocumentIndex("", 2).
+		Return([]*entities.CustomerKey{onOldKey, onNewKey}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterUserDocumentIndex(onNewKey.UserDocumentIndex, 2).
+		Return([]*entities.CustomerKey{}, nil).Once()
+
+	// when
+	countByKeyID, err := ts.underTest.CountByKeyID(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(map[string]int{"k1": 1, "k2": 1}, countByKeyID)
+}
+
+func TestCustomerKeyReencryptionJobTestSuite(t *testing.T) {
+	suite.Run(t, new(CustomerKeyReencryptionJobTestSuite))
+}
+
+func (ts *CustomerKeyReencryptionJobTestSuite) customerKey(userDocumentIndex, keyID, secretKey string) *entities.CustomerKey {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	ts.Require().Nil(err)
+
+	wrappedPrivateKey, err := providers.NewAesGcm256CryptoProvider(providers.NewKeyring(keyID, mustDecodeHex(secretKey))).
+		Encrypt(privateKey.Bytes(), []byte("customer_keys/"+userDocumentIndex+"/private_key"))
+	ts.Require().Nil(err)
+
+	return &entities.CustomerKey{
+		UserDocumentIndex: userDocumentIndex,
+		PublicKey:         hex.EncodeToString(privateKey.PublicKey().Bytes()),
+		PrivateKey:        wrappedPrivateKey,
+	}
+}
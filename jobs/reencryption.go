@@ -0,0 +1,292 @@
+package jobs
+
+import (
+	"context"
+	"crypto-challenge/database/repositories"
+	"crypto-challenge/entities"
+	"crypto-challenge/providers"
+	"errors"
+	"log"
+)
+
+const (
+	ReencryptionCheckpointName = "transactions-reencryption"
+
+	// LegacyKeyID labels the values written before the ciphertext envelope
+	// existed, which don't record the ID of their key.
+	LegacyKeyID     = "(legacy)"
+	UnreadableKeyID = "(unreadable)"
+	ErasedKeyID     = "(erased)"
+)
+
+type ReencryptionResult struct {
+	Reencrypted int
+	Rewrapped   int
+	Skipped     int
+	Erased      int
+	Corrupted   int
+	Conflicts   int
+}
+
+// ReencryptionJob brings every transaction under the primary key: the ones
+// with a data key only get it rewrapped, the ones from before data keys or
+// envelope version 2, or with a value left in clear, are fully re-encrypted. Progress is checkpointed after
+// each chunk, so an interrupted run resumes where it stopped, and rows are only
+// replaced if they were not changed by the API after being read. When the data
+// keys are wrapped by customer keys, the primary key ID is
+// providers.CustomerKeyID and the transactions of forgotten customers are
+// left as they are. So are the corrupted ones, which are logged.
+type ReencryptionJob struct {
+	repository                repositories.TransactionRepository
+	checkpoints               repositories.CheckpointRepository
+	transactionCryptoProvider providers.TransactionCryptoProvider
+	primaryKeyID              string
+	deterministicKeyID        string
+	encryptValues             bool
+	chunkSize                 int
+}
+
+func NewReencryptionJob(repository repositories.TransactionRepository, checkpoints repositories.CheckpointRepository,
+	transactionCryptoProvider providers.TransactionCryptoProvider, primaryKeyID string, chunkSize int) *ReencryptionJob {
+	return &ReencryptionJob{
+		repository:                repository,
+		checkpoints:               checkpoints,
+		transactionCryptoProvider: transactionCryptoProvider,
+		primaryKeyID:              primaryKeyID,
+		chunkSize:                 chunkSize,
+	}
+}
+
+// UseDeterministicKeyID brings the deterministic fields under the subkeys of
+// the given deterministic key, the primary one, as well.
+func (j *ReencryptionJob) UseDeterministicKeyID(keyID string) {
+	j.deterministicKeyID = keyID
+}
+
+// EncryptValues fully re-encrypts the transactions whose value is still in
+// clear, so it gets encrypted as well, for when the crypto provider encrypts
+// the values.
+func (j *ReencryptionJob) EncryptValues() {
+	j.encryptValues = true
+}
+
+func (j *ReencryptionJob) Run(ctx context.Context) (*ReencryptionResult, error) {
+	result := &ReencryptionResult{}
+
+	afterID, err := j.checkpoints.FindByName(ReencryptionCheckpointName)
+	if err != nil {
+		return result, err
+	}
+
+	if afterID != "" {
+		log.Printf("Resuming re-encryption after transaction %s.\n", afterID)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		chunk, err := j.repository.FindAfterID(ctx, afterID, j.chunkSize)
+		if err != nil {
+			return result, err
+		}
+
+		if len(chunk) == 0 {
+			break
+		}
+
+		// A chunk being replaced is finished and checkpointed even when the
+		// run is stopped, each statement is still bound by its own timeout.
+		chunkCtx := context.WithoutCancel(ctx)
+
+		for _, current := range chunk {
+			if err := j.reencrypt(chunkCtx, current, result); err != nil {
+				return result, err
+			}
+		}
+
+		afterID = chunk[len(chunk)-1].ID
+
+		if err := j.checkpoints.Save(ReencryptionCheckpointName, afterID); err != nil {
+			return result, err
+		}
+
+		log.Printf("Re-encryption checkpoint at transaction %s: %+v\n", afterID, *result)
+	}
+
+	// A finished run must not be resumed by the next rotation.
+	if err := j.checkpoints.DeleteByName(ReencryptionCheckpointName); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+func (j *ReencryptionJob) reencrypt(ctx context.Context, current *entities.Transaction, result *ReencryptionResult) error {
+	if providers.IsErased(current) {
+		result.Erased++
+		return nil
+	}
+
+	if j.isUpToDate(current) {
+		result.Skipped++
+		return nil
+	}
+
+	updated := *current
+	rewrap := j.hasUpToDateFields(current)
+
+	var err error
+
+	if rewrap {
+		err = j.transactionCryptoProvider.RewrapDataKey(&updated)
+	} else if err = j.transactionCryptoProvider.Decrypt(&updated); err == nil {
+		err = j.transactionCryptoProvider.Encrypt(&updated)
+	}
+
+	// The customer's key was destroyed, the transaction can't be read anymore.
+	if errors.Is(err, providers.ErrErased) {
+		result.Erased++
+		return nil
+	}
+
+	if errors.Is(err, providers.ErrInvalidUserDocument) {
+		log.Printf("Transaction %s left as it is, its CPF doesn't have 11 digits.\n", current.ID)
+		result.Corrupted++
+		return nil
+	}
+
+	if reason := providers.DecryptionErrorReason(err); reason != "" {
+		log.Printf("Transaction %s left as it is, it can't be decrypted: %s\n", current.ID, reason)
+		result.Corrupted++
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	replaced, err := j.repository.ReplaceEncryptedFieldsByID(ctx, current, &updated)
+	if err != nil {
+		return err
+	}
+
+	// The transaction was updated or deleted through the API after being read,
+	// either way there's nothing left to re-encrypt.
+	if !replaced {
+		result.Conflicts++
+		return nil
+	}
+
+	if rewrap {
+		result.Rewrapped++
+	} else {
+		result.Reencrypted++
+	}
+
+	return nil
+}
+
+func (j *ReencryptionJob) isUpToDate(transaction *entities.Transaction) bool {
+	return j.hasUpToDateFields(transaction) && isCurrentEnvelope(transaction.DataKey, j.primaryKeyID)
+}
+
+// hasUpToDateFields tells whether the transaction's fields are encrypted
+// under subkeys of a data key, or deterministically, its CPF and card token
+// are indexed and its value is encrypted when values are, in which case
+// rotating the master key doesn't touch them.
+func (j *ReencryptionJob) hasUpToDateFields(transaction *entities.Transaction) bool {
+	return transaction.DataKey != "" && transaction.UserDocumentIndex != "" &&
+		transaction.CreditCardTokenLast4Index != "" && (!j.encryptValues || transaction.EncryptedValue != "") &&
+		j.isUpToDateField(transaction.UserDocument, providers.UserDocumentField) &&
+		j.isUpToDateField(transaction.CreditCardToken, providers.CreditCardTokenField)
+}
+
+func (j *ReencryptionJob) isUpToDateField(ciphertext, field string) bool {
+	envelope, err := providers.ParseEnvelope(ciphertext)
+	if err != nil || envelope.Version != providers.EnvelopeVersion2 {
+		return false
+	}
+
+	// Deterministic ciphertexts are under a subkey of the deterministic key.
+	if envelope.Algorithm == providers.AlgorithmAesSiv {
+		return envelope.KeyID == providers.DerivedKeyID(j.deterministicKeyID, field)
+	}
+
+	return envelope.KeyID == providers.DerivedKeyID(providers.DataKeyID, field)
+}
+
+func isCurrentEnvelope(ciphertext, keyID string) bool {
+	envelope, err := providers.ParseEnvelope(ciphertext)
+
+	return err == nil && envelope.Version == providers.EnvelopeVersion2 && envelope.KeyID == keyID
+}
+
+// CountByKeyID reports how many transactions reference each key ID, a
+// transaction whose fields are under different keys is counted for each one.
+func (j *ReencryptionJob) CountByKeyID(ctx context.Context) (map[string]int, error) {
+	countByKeyID := make(map[string]int)
+	afterID := ""
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		chunk, err := j.repository.FindAfterID(ctx, afterID, j.chunkSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(chunk) == 0 {
+			return countByKeyID, nil
+		}
+
+		for _, transaction := range chunk {
+			for keyID := range toSet(encryptedFieldKeyIDs(transaction)) {
+				countByKeyID[keyID]++
+			}
+		}
+
+		afterID = chunk[len(chunk)-1].ID
+	}
+}
+
+// encryptedFieldKeyIDs returns the IDs of the master keys the transaction
+// depends on: the one wrapping its data key or, without one, the ones its
+// fields are encrypted under.
+func encryptedFieldKeyIDs(transaction *entities.Transaction) []string {
+	if providers.IsErased(transaction) {
+		return []string{ErasedKeyID}
+	}
+
+	if transaction.DataKey != "" {
+		return []string{keyIDOf(transaction.DataKey)}
+	}
+
+	return []string{keyIDOf(transaction.UserDocument), keyIDOf(transaction.CreditCardToken)}
+}
+
+func keyIDOf(ciphertext string) string {
+	envelope, err := providers.ParseEnvelope(ciphertext)
+	if err != nil {
+		return UnreadableKeyID
+	}
+
+	if envelope.Version == providers.EnvelopeVersionLegacy {
+		return LegacyKeyID
+	}
+
+	return envelope.KeyID
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+
+	return set
+}
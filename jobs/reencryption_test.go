@@ -0,0 +1,401 @@
+package jobs_test
+
+import (
+	"context"
+	"crypto-challenge/entities"
+	"crypto-challenge/jobs"
+	"crypto-challenge/mocks/crypto-challenge/database/repositories"
+	"crypto-challenge/providers"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	oldSecretKey  = "c7b81104b9fc8b05ff85995f6d34d5b18cfbb0cff21ff2ceab154a3bcfae3aba"
+	newSecretKey  = "5be3c9e1b0e4bb3f0d4f0b1b8e1f0cc1a4c3d2f1e0a9b8c7d6e5f4a3b2c1d0e9"
+	blindIndexKey = "0d7f3c2b8a1e4f6d9c5b2a7e3f1d8c4b6a2e9f5d1c7b3a8e4f2d6c9b5a1e7f3d"
+)
+
+type ReencryptionJobTestSuite struct {
+	suite.Suite
+	repositoryMock  *repositories.MockTransactionRepository
+	checkpointsMock *repositories.MockCheckpointRepository
+	oldProvider     *providers.StandardTransactionCryptoProvider
+	newProvider     *providers.StandardTransactionCryptoProvider
+	underTest       *jobs.ReencryptionJob
+}
+
+func (ts *ReencryptionJobTestSuite) SetupTest() {
+	ts.repositoryMock = repositories.NewMockTransactionRepository(ts.T())
+	ts.checkpointsMock = repositories.NewMockCheckpointRepository(ts.T())
+
+	blindIndex := providers.NewHmacSha256BlindIndex(mustDecodeHex(blindIndexKey))
+
+	oldKeyring := providers.NewKeyring("k1", mustDecodeHex(oldSecretKey))
+	ts.oldProvider = newStandardTransactionCryptoProvider(oldKeyring, blindIndex)
+
+	rotatedKeyring := providers.NewKeyring("k2", mustDecodeHex(newSecretKey))
+	ts.Require().Nil(rotatedKeyring.AddDecryptOnlyKey("k1", mustDecodeHex(oldSecretKey)))
+	ts.newProvider = newStandardTransactionCryptoProvider(rotatedKeyring, blindIndex)
+
+	ts.underTest = jobs.NewReencryptionJob(ts.repositoryMock, ts.checkpointsMock, ts.newProvider, "k2", 2)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun() {
+	// given
+	first, second, third := ts.encryptedTransaction(), ts.encryptedTransaction(), ts.encryptedTransaction()
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return("", nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, "", 2).Return([]*entities.Transaction{first, second}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, second.ID, 2).Return([]*entities.Transaction{third}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, third.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+	ts.repositoryMock.EXPECT().ReplaceEncryptedFieldsByID(mock.Anything, mock.AnythingOfType("*entities.Transaction"),
+		mock.MatchedBy(isOnKey("k2"))).Return(true, nil).Times(3)
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, second.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, third.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().DeleteByName(jobs.ReencryptionCheckpointName).Return(nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Rewrapped: 3}, *result)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun_ReencryptsTransactionsWithoutDataKey() {
+	// given
+	transaction := ts.encryptedTransaction()
+	transaction.DataKey = ""
+	transaction.UserDocument = ts.encryptUnderOldKey(transaction.ID, "user_document", "50277613433")
+	transaction.CreditCardToken = ts.encryptUnderOldKey(transaction.ID, "credit_card_token", "937")
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return("", nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, "", 2).Return([]*entities.Transaction{transaction}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, transaction.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+	ts.repositoryMock.EXPECT().ReplaceEncryptedFieldsByID(mock.Anything, transaction, mock.MatchedBy(isOnKey("k2"))).
+		Return(true, nil).Once()
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, transaction.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().DeleteByName(jobs.ReencryptionCheckpointName).Return(nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Reencrypted: 1}, *result)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun_LeavesTransactionsWithInvalidUserDocument() {
+	// given
+	transaction := ts.encryptedTransaction()
+	transaction.DataKey = ""
+	transaction.UserDocument = ts.encryptUnderOldKey(transaction.ID, "user_document", "502776134")
+	transaction.CreditCardToken = ts.encryptUnderOldKey(transaction.ID, "credit_card_token", "937")
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return("", nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, "", 2).Return([]*entities.Transaction{transaction}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, transaction.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, transaction.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().DeleteByName(jobs.ReencryptionCheckpointName).Return(nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Corrupted: 1}, *result)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun_EncryptsValuesInClear() {
+	// given
+	transaction := &entities.Transaction{ID: uuid.NewString(), UserDocument: "50277613433", CreditCardToken: "937",
+		Value: 1299.80}
+	ts.Require().Nil(ts.newProvider.Encrypt(transaction))
+
+	ts.newProvider.EncryptValue(100)
+	ts.underTest.EncryptValues()
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return("", nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, "", 2).Return([]*entities.Transaction{transaction}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, transaction.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+	ts.repositoryMock.EXPECT().ReplaceEncryptedFieldsByID(mock.Anything, transaction, mock.MatchedBy(func(updated *entities.Transaction) bool {
+		return isOnKey("k2")(updated) && updated.Value == 0 && updated.EncryptedValue != "" &&
+			updated.ValueBucket != nil && *updated.ValueBucket == 12
+	})).Return(true, nil).Once()
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, transaction.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().DeleteByName(jobs.ReencryptionCheckpointName).Return(nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Reencrypted: 1}, *result)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun_ResumesFromCheckpoint() {
+	// given
+	checkpointID := uuid.NewString()
+	transaction := ts.encryptedTransaction()
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return(checkpointID, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, checkpointID, 2).Return([]*entities.Transaction{transaction}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, transaction.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+	ts.repositoryMock.EXPECT().ReplaceEncryptedFieldsByID(mock.Anything, transaction, mock.MatchedBy(isOnKey("k2"))).
+		Return(true, nil).Once()
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, transaction.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().DeleteByName(jobs.ReencryptionCheckpointName).Return(nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(1, result.Rewrapped)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun_WhenChangedConcurrently() {
+	// given
+	transaction := ts.encryptedTransaction()
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return("", nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, "", 2).Return([]*entities.Transaction{transaction}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, transaction.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+	ts.repositoryMock.EXPECT().ReplaceEncryptedFieldsByID(mock.Anything, transaction, mock.AnythingOfType("*entities.Transaction")).
+		Return(false, nil).Once()
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, transaction.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().DeleteByName(jobs.ReencryptionCheckpointName).Return(nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Conflicts: 1}, *result)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun_SkipsUpToDateTransactions() {
+	// given
+	upToDate := ts.encryptedTransaction()
+	ts.Require().Nil(ts.newProvider.Decrypt(upToDate))
+	ts.Require().Nil(ts.newProvider.Encrypt(upToDate))
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return("", nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, "", 2).Return([]*entities.Transaction{upToDate}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, upToDate.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, upToDate.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().DeleteByName(jobs.ReencryptionCheckpointName).Return(nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Skipped: 1}, *result)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun_IndexesCreditCardTokenLast4() {
+	// given
+	unindexed := ts.encryptedTransaction()
+	ts.Require().Nil(ts.newProvider.Decrypt(unindexed))
+	ts.Require().Nil(ts.newProvider.Encrypt(unindexed))
+	unindexed.CreditCardTokenLast4Index = ""
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return("", nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, "", 2).Return([]*entities.Transaction{unindexed}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, unindexed.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+	ts.repositoryMock.EXPECT().ReplaceEncryptedFieldsByID(mock.Anything, unindexed, mock.MatchedBy(func(updated *entities.Transaction) bool {
+		return updated.CreditCardTokenLast4Index != ""
+	})).Return(true, nil).Once()
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, unindexed.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().DeleteByName(jobs.ReencryptionCheckpointName).Return(nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Reencrypted: 1}, *result)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun_ReencryptsDeterministicFieldsUnderOldKey() {
+	// given
+	oldKeyring := providers.NewKeyring("k1", mustDecodeHex(oldSecretKey))
+	rotatedKeyring := providers.NewKeyring("k2", mustDecodeHex(newSecretKey))
+	ts.Require().Nil(rotatedKeyring.AddDecryptOnlyKey("k1", mustDecodeHex(oldSecretKey)))
+
+	blindIndex := providers.NewHmacSha256BlindIndex(mustDecodeHex(blindIndexKey))
+	oldProvider := newDeterministicTransactionCryptoProvider(ts.T(), oldKeyring, blindIndex)
+	newProvider := newDeterministicTransactionCryptoProvider(ts.T(), rotatedKeyring, blindIndex)
+
+	// Only the deterministic field is under the old key.
+	transaction := &entities.Transaction{ID: uuid.NewString(), UserDocument: "50277613433", CreditCardToken: "937"}
+	underOldKey := *transaction
+	ts.Require().Nil(oldProvider.Encrypt(&underOldKey))
+	ts.Require().Nil(newProvider.Encrypt(transaction))
+	transaction.CreditCardToken = underOldKey.CreditCardToken
+
+	underTest := jobs.NewReencryptionJob(ts.repositoryMock, ts.checkpointsMock, newProvider, "k2", 2)
+	underTest.UseDeterministicKeyID("k2")
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return("", nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, "", 2).Return([]*entities.Transaction{transaction}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, transaction.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+	ts.repositoryMock.EXPECT().ReplaceEncryptedFieldsByID(mock.Anything, transaction, mock.MatchedBy(func(updated *entities.Transaction) bool {
+		return strings.HasPrefix(updated.CreditCardToken,
+			"v2:"+providers.DerivedKeyID("k2", providers.CreditCardTokenField)+":"+providers.AlgorithmAesSiv+":")
+	})).Return(true, nil).Once()
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, transaction.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().DeleteByName(jobs.ReencryptionCheckpointName).Return(nil).Once()
+
+	// when
+	result, err := underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Reencrypted: 1}, *result)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun_LeavesErasedTransactions() {
+	// given
+	erased := &entities.Transaction{ID: uuid.NewString(), Value: 1299.80}
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return("", nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, "", 2).Return([]*entities.Transaction{erased}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, erased.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, erased.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().DeleteByName(jobs.ReencryptionCheckpointName).Return(nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Erased: 1}, *result)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun_LeavesCorruptedTransactions() {
+	// given
+	corrupted, other := ts.encryptedTransaction(), ts.encryptedTransaction()
+	corrupted.DataKey = other.DataKey
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return("", nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, "", 2).Return([]*entities.Transaction{corrupted}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, corrupted.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+	ts.checkpointsMock.EXPECT().Save(jobs.ReencryptionCheckpointName, corrupted.ID).Return(nil).Once()
+	ts.checkpointsMock.EXPECT().DeleteByName(jobs.ReencryptionCheckpointName).Return(nil).Once()
+
+	// when
+	result, err := ts.underTest.Run(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(jobs.ReencryptionResult{Corrupted: 1}, *result)
+}
+
+func (ts *ReencryptionJobTestSuite) TestRun_WithCancelledContext() {
+	// given
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ts.checkpointsMock.EXPECT().FindByName(jobs.ReencryptionCheckpointName).Return("", nil).Once()
+
+	// when
+	_, err := ts.underTest.Run(ctx)
+
+	// then
+	ts.Require().ErrorIs(err, context.Canceled)
+}
+
+func (ts *ReencryptionJobTestSuite) TestCountByKeyID() {
+	// given
+	onOldKey := ts.encryptedTransaction()
+	legacy := &entities.Transaction{ID: uuid.NewString(), UserDocument: "00-00", CreditCardToken: "00-00"}
+	erased := &entities.Transaction{ID: uuid.NewString()}
+
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, "", 2).Return([]*entities.Transaction{onOldKey, legacy}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, legacy.ID, 2).Return([]*entities.Transaction{erased}, nil).Once()
+	ts.repositoryMock.EXPECT().FindAfterID(mock.Anything, erased.ID, 2).Return([]*entities.Transaction{}, nil).Once()
+
+	// when
+	countByKeyID, err := ts.underTest.CountByKeyID(context.Background())
+
+	// then
+	ts.Require().Nil(err)
+	ts.Require().Equal(map[string]int{"k1": 1, jobs.LegacyKeyID: 1, jobs.ErasedKeyID: 1}, countByKeyID)
+}
+
+func TestReencryptionJobTestSuite(t *testing.T) {
+	suite.Run(t, new(ReencryptionJobTestSuite))
+}
+
+func (ts *ReencryptionJobTestSuite) encryptedTransaction() *entities.Transaction {
+	transaction := &entities.Transaction{
+		ID:              uuid.NewString(),
+		UserDocument:    "50277613433",
+		CreditCardToken: "937",
+		Value:           1299.80,
+	}
+
+	ts.Require().Nil(ts.oldProvider.Encrypt(transaction))
+
+	return transaction
+}
+
+func (ts *ReencryptionJobTestSuite) encryptUnderOldKey(id, field, plaintext string) string {
+	oldKeyring := providers.NewKeyring("k1", mustDecodeHex(oldSecretKey))
+
+	ciphertext, err := providers.NewAesGcm256CryptoProvider(oldKeyring).
+		Encrypt([]byte(plaintext), []byte("transactions/"+id+"/"+field))
+	ts.Require().Nil(err)
+
+	return ciphertext
+}
+
+func isOnKey(keyID string) func(*entities.Transaction) bool {
+	return func(transaction *entities.Transaction) bool {
+		return strings.HasPrefix(transaction.DataKey, "v2:"+keyID+":") &&
+			strings.HasPrefix(transaction.UserDocument,
+				"v2:"+providers.DerivedKeyID(providers.DataKeyID, providers.UserDocumentField)+":") &&
+			strings.HasPrefix(transaction.CreditCardToken,
+				"v2:"+providers.DerivedKeyID(providers.DataKeyID, providers.CreditCardTokenField)+":")
+	}
+}
+
+func mustDecodeHex(toDecode string) []byte {
+	decoded, err := hex.DecodeString(toDecode)
+	if err != nil {
+		panic(err)
+	}
+
+	return decoded
+}
+
+func newStandardTransactionCryptoProvider(keyring *providers.Keyring,
+	blindIndex *providers.HmacSha256BlindIndex) *providers.StandardTransactionCryptoProvider {
+	return providers.NewStandardTransactionCryptoProvider(
+		providers.NewLocalKeyManagementService(keyring, providers.AlgorithmAesGcm256), blindIndex, providers.AlgorithmAesGcm256)
+}
+
+func newDeterministicTransactionCryptoProvider(t *testing.T, keyring *providers.Keyring,
+	blindIndex *providers.HmacSha256BlindIndex) *providers.StandardTransactionCryptoProvider {
+	deterministicKeyring, err := keyring.DeriveKeyring(providers.KeyPurposeDeterministicEncryption,
+		providers.AesSivKeySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactionCryptoProvider := newStandardTransactionCryptoProvider(keyring, blindIndex)
+	if err := transactionCryptoProvider.EncryptDeterministically(deterministicKeyring,
+		providers.CreditCardTokenField); err != nil {
+		t.Fatal(err)
+	}
+
+	return transactionCryptoProvider
+}
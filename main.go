@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"crypto-challenge/config"
 	"crypto-challenge/database/repositories"
 	"crypto-challenge/handlers"
 	"crypto-challenge/providers"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -16,34 +22,306 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 func main() {
-	cfg := config.GetAppConfig(".env")
+	var command string
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", cfg.Database.User, cfg.Database.Password, cfg.Database.Host, cfg.Database.Port, cfg.Database.DbName)
+	cfg := config.GetAppConfig(".env", command)
 
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		panic(err.Error())
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	defer db.Close()
+	// Zeroes the keys on the way out, once nothing uses them anymore.
+	defer providers.WipeLockedKeys()
 
-	if err := db.Ping(); err != nil {
-		panic(err.Error())
+	if command != "" {
+		err := runCommand(ctx, os.Args[1:], cfg)
+
+		// log.Fatal skips the deferred calls.
+		providers.WipeLockedKeys()
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return
 	}
 
+	db := openDatabase(cfg)
+	defer db.Close()
+
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 
-	transactionRepository := repositories.NewTransactionMySqlRepository(db)
-	cryptoProvider := providers.NewAesGcm256CryptoProvider(cfg.Cryptography.SecretKey)
-	transactionCryptoProvider := providers.NewStandardTransactionCryptoProvider(cryptoProvider)
+	if cfg.Unseal.Enabled {
+		r.Mount("/", newSealedRouter(cfg, db))
+	} else {
+		keyManagementService, keyring, err := newKeyManagementService(cfg)
+		if err != nil {
+			panic(err)
+		}
 
-	r.Mount("/", handlers.NewTransactionRouter(transactionRepository, transactionCryptoProvider))
+		router, err := newRouter(cfg, db, keyManagementService, keyring)
+		if err != nil {
+			panic(err)
+		}
+
+		cfg.ClearKeys()
+
+		r.Mount("/", router)
+	}
 
 	log.Println("🚀 Server running at: 127.0.0.1:3000")
-	err = http.ListenAndServe(":3000", r)
+	err := listenAndServe(ctx, ":3000", r)
 	if err != nil {
 		panic(err)
 	}
 }
+
+// newRouter serves the transactions, the customers and the card vault, with
+// their keys wrapped by the given KMS. The keyring of the local KMS, nil for
+// the others, is the one the index keys are derived from.
+func newRouter(cfg *config.AppConfig, db *sql.DB, kms providers.KeyManagementService,
+	keyring *providers.Keyring) (*chi.Mux, error) {
+	r := chi.NewRouter()
+
+	keys, err := newIndexKeys(cfg, keyring)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionRepository := repositories.NewTransactionMySqlRepository(db)
+	transactionRepository.UseTimeouts(cfg.Database.ReadTimeout, cfg.Database.WriteTimeout)
+	customerKeyRepository := repositories.NewCustomerKeyMySqlRepository(db)
+	customerKeys := providers.NewCustomerKeys(customerKeyRepository, kms)
+
+	transactionCryptoProvider, err := newTransactionCryptoProvider(cfg, kms, customerKeys, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	routerOptions := []handlers.TransactionRouterOption{newMaskingPolicies(cfg)}
+	if cfg.Cryptography.EncryptOnly {
+		routerOptions = append(routerOptions, handlers.WithEncryptOnly())
+	}
+
+	if cfg.Cryptography.EncryptValue {
+		routerOptions = append(routerOptions, handlers.WithEncryptedValues())
+	}
+
+	if cfg.Cryptography.ValueBucketWidth > 0 {
+		routerOptions = append(routerOptions, handlers.WithValueBuckets(cfg.Cryptography.ValueBucketWidth))
+	}
+
+	r.Mount("/", handlers.NewTransactionRouter(transactionRepository, transactionCryptoProvider, routerOptions...))
+	r.Mount("/customers", handlers.NewCustomerRouter(customerKeyRepository, transactionCryptoProvider,
+		cfg.Customers.ForgetToken))
+
+	cardTokenizer := newCardTokenizer(cfg, kms, repositories.NewCardVaultMySqlRepository(db), keys)
+
+	r.Mount("/cards", handlers.NewCardVaultRouter(cardTokenizer, cfg.Vault.TokenizeToken, cfg.Vault.DetokenizeToken))
+
+	return r, nil
+}
+
+// newSealedRouter answers 503 until the primary key is rebuilt from the shares
+// submitted to /admin/unseal, then serves the routes of newRouter.
+func newSealedRouter(cfg *config.AppConfig, db *sql.DB) *chi.Mux {
+	sealedHandler := handlers.NewSealedHandler()
+
+	unsealer := providers.NewShamirUnsealer(func(primaryKey []byte) error {
+		keyring, err := providers.NewUnsealedKeyringFromConfig(cfg, primaryKey)
+		if err != nil {
+			return err
+		}
+
+		router, err := newRouter(cfg, db, providers.NewLocalKeyManagementServiceFromConfig(cfg, keyring), keyring)
+		if err != nil {
+			return err
+		}
+
+		cfg.ClearKeys()
+
+		sealedHandler.Unseal(router)
+		log.Println("🔓 Server unsealed")
+
+		return nil
+	})
+
+	r := chi.NewRouter()
+
+	r.Mount("/admin", handlers.NewUnsealRouter(unsealer, cfg.Unseal.Token))
+	r.Mount("/", sealedHandler)
+
+	log.Println("🔒 Server sealed, submit the key shares to /admin/unseal")
+
+	return r
+}
+
+// listenAndServe serves until the context is done, then waits for the
+// requests in flight to finish, up to shutdownTimeout.
+func listenAndServe(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{Addr: addr, Handler: handler}
+
+	shutdownErr := make(chan error, 1)
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		shutdownErr <- server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return <-shutdownErr
+}
+
+// newKeyManagementService returns the KMS of the config and, for the local
+// one, its keyring, rebuilt from the key shares read from the standard input
+// when Unseal.Enabled is set.
+func newKeyManagementService(cfg *config.AppConfig) (providers.KeyManagementService, *providers.Keyring, error) {
+	if cfg.Kms.Provider != providers.KmsProviderLocal {
+		kms, err := providers.NewKeyManagementServiceFromConfig(cfg)
+
+		return kms, nil, err
+	}
+
+	keyring, err := newLocalKeyring(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return providers.NewLocalKeyManagementServiceFromConfig(cfg, keyring), keyring, nil
+}
+
+// indexKeys index and deterministically encrypt the transactions, the same
+// way in every transaction. They are derived from the master keys of the local
+// KMS with HKDF, unless given in the config, as they must be for the other
+// KMSs, which never hand their master keys out.
+type indexKeys struct {
+	blindIndexKey        []byte
+	deterministicKeyring *providers.Keyring
+}
+
+func newIndexKeys(cfg *config.AppConfig, keyring *providers.Keyring) (*indexKeys, error) {
+	keys := &indexKeys{}
+
+	if cfg.Cryptography.BlindIndexKey != "" || keyring == nil {
+		blindIndexKey, err := providers.DecodeLockedKey(cfg.Cryptography.BlindIndexKey)
+		if err != nil {
+			return nil, err
+		}
+
+		keys.blindIndexKey = blindIndexKey
+	} else {
+		// The blind indexes can't be rotated, they stay under the same master
+		// key as long as it's in the keyring.
+		blindIndexKeyring, err := keyring.DeriveKeyring(providers.KeyPurposeBlindIndex, providers.BlindIndexKeySize)
+		if err != nil {
+			return nil, err
+		}
+
+		if keys.blindIndexKey, err = blindIndexKeyring.Key(cfg.Cryptography.BlindIndexKeyID); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cfg.Cryptography.DeterministicFields) == 0 {
+		return keys, nil
+	}
+
+	if cfg.Cryptography.DeterministicKey != "" || keyring == nil {
+		deterministicKey, err := providers.DecodeLockedKey(cfg.Cryptography.DeterministicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		keys.deterministicKeyring = providers.NewKeyring(cfg.Cryptography.DeterministicKeyID, deterministicKey)
+
+		return keys, nil
+	}
+
+	// The deterministic fields are rotated along with the master keys, by
+	// the reencrypt command.
+	deterministicKeyring, err := keyring.DeriveKeyring(providers.KeyPurposeDeterministicEncryption,
+		providers.AesSivKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	keys.deterministicKeyring = deterministicKeyring
+
+	return keys, nil
+}
+
+func newTransactionCryptoProvider(cfg *config.AppConfig, kms providers.KeyManagementService,
+	customerKeys *providers.CustomerKeys, keys *indexKeys) (*providers.StandardTransactionCryptoProvider, error) {
+	transactionCryptoProvider := providers.NewStandardTransactionCryptoProvider(kms,
+		providers.NewHmacSha256BlindIndex(keys.blindIndexKey), cfg.Cryptography.Algorithm)
+	transactionCryptoProvider.UseCustomerKeys(customerKeys)
+	transactionCryptoProvider.UseWorkers(cfg.Cryptography.Workers)
+
+	if cfg.Cryptography.RejectUnbound {
+		transactionCryptoProvider.RejectUnboundCiphertexts()
+	}
+
+	if cfg.Cryptography.EncryptValue {
+		transactionCryptoProvider.EncryptValue(cfg.Cryptography.ValueBucketWidth)
+	}
+
+	if keys.deterministicKeyring != nil {
+		err := transactionCryptoProvider.EncryptDeterministically(keys.deterministicKeyring,
+			cfg.Cryptography.DeterministicFields...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return transactionCryptoProvider, nil
+}
+
+// newMaskingPolicies turns the masking settings, validated with the rest of
+// the config, into a router option.
+func newMaskingPolicies(cfg *config.AppConfig) handlers.TransactionRouterOption {
+	defaultPolicy, _ := handlers.ParseMaskingPolicy(cfg.Masking.DefaultPolicy)
+
+	rolePolicies := make(map[string]handlers.MaskingPolicy, len(cfg.Masking.RolePolicies))
+	for role, policy := range cfg.Masking.RolePolicies {
+		rolePolicies[role], _ = handlers.ParseMaskingPolicy(policy)
+	}
+
+	return handlers.WithMaskingPolicies(cfg.Masking.RoleHeader, defaultPolicy, rolePolicies)
+}
+
+// newCardTokenizer indexes the card numbers under their own subkey of the
+// blind index key, like every blind index, so they can't be matched with the
+// CPFs.
+func newCardTokenizer(cfg *config.AppConfig, kms providers.KeyManagementService,
+	repository repositories.CardVaultRepository, keys *indexKeys) *providers.VaultCardTokenizer {
+	return providers.NewVaultCardTokenizer(repository, kms, providers.NewHmacSha256BlindIndex(keys.blindIndexKey),
+		cfg.Cryptography.Algorithm)
+}
+
+func openDatabase(cfg *config.AppConfig) *sql.DB {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true", cfg.Database.User, cfg.Database.Password, cfg.Database.Host, cfg.Database.Port, cfg.Database.DbName)
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	if err := db.Ping(); err != nil {
+		panic(err.Error())
+	}
+
+	return db
+}
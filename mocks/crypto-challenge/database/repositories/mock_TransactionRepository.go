@@ -3,6 +3,8 @@
 package repositories
 
 import (
+	context "context"
+	repositories "crypto-challenge/database/repositories"
 	entities "crypto-challenge/entities"
 
 	mock "github.com/stretchr/testify/mock"
@@ -21,17 +23,76 @@ func (_m *MockTransactionRepository) EXPECT() *MockTransactionRepository_Expecte
 	return &MockTransactionRepository_Expecter{mock: &_m.Mock}
 }
 
-// Create provides a mock function with given fields: newTransaction
-func (_m *MockTransactionRepository) Create(newTransaction *entities.Transaction) error {
-	ret := _m.Called(newTransaction)
+// CountByValueBucket provides a mock function with given fields: ctx, bucketWidth
+func (_m *MockTransactionRepository) CountByValueBucket(ctx context.Context, bucketWidth float64) (map[int64]int, error) {
+	ret := _m.Called(ctx, bucketWidth)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountByValueBucket")
+	}
+
+	var r0 map[int64]int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, float64) (map[int64]int, error)); ok {
+		return rf(ctx, bucketWidth)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, float64) map[int64]int); ok {
+		r0 = rf(ctx, bucketWidth)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int64]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, float64) error); ok {
+		r1 = rf(ctx, bucketWidth)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTransactionRepository_CountByValueBucket_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountByValueBucket'
+type MockTransactionRepository_CountByValueBucket_Call struct {
+	*mock.Call
+}
+
+// CountByValueBucket is a helper method to define mock.On call
+//   - ctx context.Context
+//   - bucketWidth float64
+func (_e *MockTransactionRepository_Expecter) CountByValueBucket(ctx interface{}, bucketWidth interface{}) *MockTransactionRepository_CountByValueBucket_Call {
+	return &MockTransactionRepository_CountByValueBucket_Call{Call: _e.mock.On("CountByValueBucket", ctx, bucketWidth)}
+}
+
+func (_c *MockTransactionRepository_CountByValueBucket_Call) Run(run func(ctx context.Context, bucketWidth float64)) *MockTransactionRepository_CountByValueBucket_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(float64))
+	})
+	return _c
+}
+
+func (_c *MockTransactionRepository_CountByValueBucket_Call) Return(_a0 map[int64]int, _a1 error) *MockTransactionRepository_CountByValueBucket_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTransactionRepository_CountByValueBucket_Call) RunAndReturn(run func(context.Context, float64) (map[int64]int, error)) *MockTransactionRepository_CountByValueBucket_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Create provides a mock function with given fields: ctx, newTransaction
+func (_m *MockTransactionRepository) Create(ctx context.Context, newTransaction *entities.Transaction) error {
+	ret := _m.Called(ctx, newTransaction)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Create")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*entities.Transaction) error); ok {
-		r0 = rf(newTransaction)
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.Transaction) error); ok {
+		r0 = rf(ctx, newTransaction)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -45,14 +106,15 @@ type MockTransactionRepository_Create_Call struct {
 }
 
 // Create is a helper method to define mock.On call
+//   - ctx context.Context
 //   - newTransaction *entities.Transaction
-func (_e *MockTransactionRepository_Expecter) Create(newTransaction interface{}) *MockTransactionRepository_Create_Call {
-	return &MockTransactionRepository_Create_Call{Call: _e.mock.On("Create", newTransaction)}
+func (_e *MockTransactionRepository_Expecter) Create(ctx interface{}, newTransaction interface{}) *MockTransactionRepository_Create_Call {
+	return &MockTransactionRepository_Create_Call{Call: _e.mock.On("Create", ctx, newTransaction)}
 }
 
-func (_c *MockTransactionRepository_Create_Call) Run(run func(newTransaction *entities.Transaction)) *MockTransactionRepository_Create_Call {
+func (_c *MockTransactionRepository_Create_Call) Run(run func(ctx context.Context, newTransaction *entities.Transaction)) *MockTransactionRepository_Create_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*entities.Transaction))
+		run(args[0].(context.Context), args[1].(*entities.Transaction))
 	})
 	return _c
 }
@@ -62,22 +124,22 @@ func (_c *MockTransactionRepository_Create_Call) Return(_a0 error) *MockTransact
 	return _c
 }
 
-func (_c *MockTransactionRepository_Create_Call) RunAndReturn(run func(*entities.Transaction) error) *MockTransactionRepository_Create_Call {
+func (_c *MockTransactionRepository_Create_Call) RunAndReturn(run func(context.Context, *entities.Transaction) error) *MockTransactionRepository_Create_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// DeleteByID provides a mock function with given fields: idToDelete
-func (_m *MockTransactionRepository) DeleteByID(idToDelete string) error {
-	ret := _m.Called(idToDelete)
+// DeleteByID provides a mock function with given fields: ctx, idToDelete
+func (_m *MockTransactionRepository) DeleteByID(ctx context.Context, idToDelete string) error {
+	ret := _m.Called(ctx, idToDelete)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteByID")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(idToDelete)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, idToDelete)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -91,14 +153,15 @@ type MockTransactionRepository_DeleteByID_Call struct {
 }
 
 // DeleteByID is a helper method to define mock.On call
+//   - ctx context.Context
 //   - idToDelete string
-func (_e *MockTransactionRepository_Expecter) DeleteByID(idToDelete interface{}) *MockTransactionRepository_DeleteByID_Call {
-	return &MockTransactionRepository_DeleteByID_Call{Call: _e.mock.On("DeleteByID", idToDelete)}
+func (_e *MockTransactionRepository_Expecter) DeleteByID(ctx interface{}, idToDelete interface{}) *MockTransactionRepository_DeleteByID_Call {
+	return &MockTransactionRepository_DeleteByID_Call{Call: _e.mock.On("DeleteByID", ctx, idToDelete)}
 }
 
-func (_c *MockTransactionRepository_DeleteByID_Call) Run(run func(idToDelete string)) *MockTransactionRepository_DeleteByID_Call {
+func (_c *MockTransactionRepository_DeleteByID_Call) Run(run func(ctx context.Context, idToDelete string)) *MockTransactionRepository_DeleteByID_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
@@ -108,14 +171,74 @@ func (_c *MockTransactionRepository_DeleteByID_Call) Return(_a0 error) *MockTran
 	return _c
 }
 
-func (_c *MockTransactionRepository_DeleteByID_Call) RunAndReturn(run func(string) error) *MockTransactionRepository_DeleteByID_Call {
+func (_c *MockTransactionRepository_DeleteByID_Call) RunAndReturn(run func(context.Context, string) error) *MockTransactionRepository_DeleteByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindAfterID provides a mock function with given fields: ctx, afterID, limit
+func (_m *MockTransactionRepository) FindAfterID(ctx context.Context, afterID string, limit int) ([]*entities.Transaction, error) {
+	ret := _m.Called(ctx, afterID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAfterID")
+	}
+
+	var r0 []*entities.Transaction
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]*entities.Transaction, error)); ok {
+		return rf(ctx, afterID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []*entities.Transaction); ok {
+		r0 = rf(ctx, afterID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.Transaction)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, afterID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTransactionRepository_FindAfterID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindAfterID'
+type MockTransactionRepository_FindAfterID_Call struct {
+	*mock.Call
+}
+
+// FindAfterID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - afterID string
+//   - limit int
+func (_e *MockTransactionRepository_Expecter) FindAfterID(ctx interface{}, afterID interface{}, limit interface{}) *MockTransactionRepository_FindAfterID_Call {
+	return &MockTransactionRepository_FindAfterID_Call{Call: _e.mock.On("FindAfterID", ctx, afterID, limit)}
+}
+
+func (_c *MockTransactionRepository_FindAfterID_Call) Run(run func(ctx context.Context, afterID string, limit int)) *MockTransactionRepository_FindAfterID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *MockTransactionRepository_FindAfterID_Call) Return(_a0 []*entities.Transaction, _a1 error) *MockTransactionRepository_FindAfterID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTransactionRepository_FindAfterID_Call) RunAndReturn(run func(context.Context, string, int) ([]*entities.Transaction, error)) *MockTransactionRepository_FindAfterID_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// FindAll provides a mock function with given fields:
-func (_m *MockTransactionRepository) FindAll() ([]*entities.Transaction, error) {
-	ret := _m.Called()
+// FindAll provides a mock function with given fields: ctx, after, limit
+func (_m *MockTransactionRepository) FindAll(ctx context.Context, after *repositories.TransactionCursor, limit int) ([]*entities.Transaction, error) {
+	ret := _m.Called(ctx, after, limit)
 
 	if len(ret) == 0 {
 		panic("no return value specified for FindAll")
@@ -123,19 +246,19 @@ func (_m *MockTransactionRepository) FindAll() ([]*entities.Transaction, error)
 
 	var r0 []*entities.Transaction
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]*entities.Transaction, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context, *repositories.TransactionCursor, int) ([]*entities.Transaction, error)); ok {
+		return rf(ctx, after, limit)
 	}
-	if rf, ok := ret.Get(0).(func() []*entities.Transaction); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, *repositories.TransactionCursor, int) []*entities.Transaction); ok {
+		r0 = rf(ctx, after, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*entities.Transaction)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, *repositories.TransactionCursor, int) error); ok {
+		r1 = rf(ctx, after, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -149,13 +272,16 @@ type MockTransactionRepository_FindAll_Call struct {
 }
 
 // FindAll is a helper method to define mock.On call
-func (_e *MockTransactionRepository_Expecter) FindAll() *MockTransactionRepository_FindAll_Call {
-	return &MockTransactionRepository_FindAll_Call{Call: _e.mock.On("FindAll")}
+//   - ctx context.Context
+//   - after *repositories.TransactionCursor
+//   - limit int
+func (_e *MockTransactionRepository_Expecter) FindAll(ctx interface{}, after interface{}, limit interface{}) *MockTransactionRepository_FindAll_Call {
+	return &MockTransactionRepository_FindAll_Call{Call: _e.mock.On("FindAll", ctx, after, limit)}
 }
 
-func (_c *MockTransactionRepository_FindAll_Call) Run(run func()) *MockTransactionRepository_FindAll_Call {
+func (_c *MockTransactionRepository_FindAll_Call) Run(run func(ctx context.Context, after *repositories.TransactionCursor, limit int)) *MockTransactionRepository_FindAll_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		run(args[0].(context.Context), args[1].(*repositories.TransactionCursor), args[2].(int))
 	})
 	return _c
 }
@@ -165,14 +291,75 @@ func (_c *MockTransactionRepository_FindAll_Call) Return(_a0 []*entities.Transac
 	return _c
 }
 
-func (_c *MockTransactionRepository_FindAll_Call) RunAndReturn(run func() ([]*entities.Transaction, error)) *MockTransactionRepository_FindAll_Call {
+func (_c *MockTransactionRepository_FindAll_Call) RunAndReturn(run func(context.Context, *repositories.TransactionCursor, int) ([]*entities.Transaction, error)) *MockTransactionRepository_FindAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindByFilter provides a mock function with given fields: ctx, filter, after, limit
+func (_m *MockTransactionRepository) FindByFilter(ctx context.Context, filter *repositories.TransactionFilter, after *repositories.TransactionCursor, limit int) ([]*entities.Transaction, error) {
+	ret := _m.Called(ctx, filter, after, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByFilter")
+	}
+
+	var r0 []*entities.Transaction
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *repositories.TransactionFilter, *repositories.TransactionCursor, int) ([]*entities.Transaction, error)); ok {
+		return rf(ctx, filter, after, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *repositories.TransactionFilter, *repositories.TransactionCursor, int) []*entities.Transaction); ok {
+		r0 = rf(ctx, filter, after, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.Transaction)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *repositories.TransactionFilter, *repositories.TransactionCursor, int) error); ok {
+		r1 = rf(ctx, filter, after, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTransactionRepository_FindByFilter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByFilter'
+type MockTransactionRepository_FindByFilter_Call struct {
+	*mock.Call
+}
+
+// FindByFilter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter *repositories.TransactionFilter
+//   - after *repositories.TransactionCursor
+//   - limit int
+func (_e *MockTransactionRepository_Expecter) FindByFilter(ctx interface{}, filter interface{}, after interface{}, limit interface{}) *MockTransactionRepository_FindByFilter_Call {
+	return &MockTransactionRepository_FindByFilter_Call{Call: _e.mock.On("FindByFilter", ctx, filter, after, limit)}
+}
+
+func (_c *MockTransactionRepository_FindByFilter_Call) Run(run func(ctx context.Context, filter *repositories.TransactionFilter, after *repositories.TransactionCursor, limit int)) *MockTransactionRepository_FindByFilter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*repositories.TransactionFilter), args[2].(*repositories.TransactionCursor), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *MockTransactionRepository_FindByFilter_Call) Return(_a0 []*entities.Transaction, _a1 error) *MockTransactionRepository_FindByFilter_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTransactionRepository_FindByFilter_Call) RunAndReturn(run func(context.Context, *repositories.TransactionFilter, *repositories.TransactionCursor, int) ([]*entities.Transaction, error)) *MockTransactionRepository_FindByFilter_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// FindByID provides a mock function with given fields: idToSearch
-func (_m *MockTransactionRepository) FindByID(idToSearch string) (*entities.Transaction, error) {
-	ret := _m.Called(idToSearch)
+// FindByID provides a mock function with given fields: ctx, idToSearch
+func (_m *MockTransactionRepository) FindByID(ctx context.Context, idToSearch string) (*entities.Transaction, error) {
+	ret := _m.Called(ctx, idToSearch)
 
 	if len(ret) == 0 {
 		panic("no return value specified for FindByID")
@@ -180,19 +367,19 @@ func (_m *MockTransactionRepository) FindByID(idToSearch string) (*entities.Tran
 
 	var r0 *entities.Transaction
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*entities.Transaction, error)); ok {
-		return rf(idToSearch)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entities.Transaction, error)); ok {
+		return rf(ctx, idToSearch)
 	}
-	if rf, ok := ret.Get(0).(func(string) *entities.Transaction); ok {
-		r0 = rf(idToSearch)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entities.Transaction); ok {
+		r0 = rf(ctx, idToSearch)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entities.Transaction)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(idToSearch)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, idToSearch)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -206,14 +393,15 @@ type MockTransactionRepository_FindByID_Call struct {
 }
 
 // FindByID is a helper method to define mock.On call
+//   - ctx context.Context
 //   - idToSearch string
-func (_e *MockTransactionRepository_Expecter) FindByID(idToSearch interface{}) *MockTransactionRepository_FindByID_Call {
-	return &MockTransactionRepository_FindByID_Call{Call: _e.mock.On("FindByID", idToSearch)}
+func (_e *MockTransactionRepository_Expecter) FindByID(ctx interface{}, idToSearch interface{}) *MockTransactionRepository_FindByID_Call {
+	return &MockTransactionRepository_FindByID_Call{Call: _e.mock.On("FindByID", ctx, idToSearch)}
 }
 
-func (_c *MockTransactionRepository_FindByID_Call) Run(run func(idToSearch string)) *MockTransactionRepository_FindByID_Call {
+func (_c *MockTransactionRepository_FindByID_Call) Run(run func(ctx context.Context, idToSearch string)) *MockTransactionRepository_FindByID_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
@@ -223,22 +411,200 @@ func (_c *MockTransactionRepository_FindByID_Call) Return(_a0 *entities.Transact
 	return _c
 }
 
-func (_c *MockTransactionRepository_FindByID_Call) RunAndReturn(run func(string) (*entities.Transaction, error)) *MockTransactionRepository_FindByID_Call {
+func (_c *MockTransactionRepository_FindByID_Call) RunAndReturn(run func(context.Context, string) (*entities.Transaction, error)) *MockTransactionRepository_FindByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindByUserDocument provides a mock function with given fields: ctx, userDocumentIndex, after, limit
+func (_m *MockTransactionRepository) FindByUserDocument(ctx context.Context, userDocumentIndex string, after *repositories.TransactionCursor, limit int) ([]*entities.Transaction, error) {
+	ret := _m.Called(ctx, userDocumentIndex, after, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByUserDocument")
+	}
+
+	var r0 []*entities.Transaction
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *repositories.TransactionCursor, int) ([]*entities.Transaction, error)); ok {
+		return rf(ctx, userDocumentIndex, after, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *repositories.TransactionCursor, int) []*entities.Transaction); ok {
+		r0 = rf(ctx, userDocumentIndex, after, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.Transaction)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *repositories.TransactionCursor, int) error); ok {
+		r1 = rf(ctx, userDocumentIndex, after, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTransactionRepository_FindByUserDocument_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByUserDocument'
+type MockTransactionRepository_FindByUserDocument_Call struct {
+	*mock.Call
+}
+
+// FindByUserDocument is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userDocumentIndex string
+//   - after *repositories.TransactionCursor
+//   - limit int
+func (_e *MockTransactionRepository_Expecter) FindByUserDocument(ctx interface{}, userDocumentIndex interface{}, after interface{}, limit interface{}) *MockTransactionRepository_FindByUserDocument_Call {
+	return &MockTransactionRepository_FindByUserDocument_Call{Call: _e.mock.On("FindByUserDocument", ctx, userDocumentIndex, after, limit)}
+}
+
+func (_c *MockTransactionRepository_FindByUserDocument_Call) Run(run func(ctx context.Context, userDocumentIndex string, after *repositories.TransactionCursor, limit int)) *MockTransactionRepository_FindByUserDocument_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(*repositories.TransactionCursor), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *MockTransactionRepository_FindByUserDocument_Call) Return(_a0 []*entities.Transaction, _a1 error) *MockTransactionRepository_FindByUserDocument_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTransactionRepository_FindByUserDocument_Call) RunAndReturn(run func(context.Context, string, *repositories.TransactionCursor, int) ([]*entities.Transaction, error)) *MockTransactionRepository_FindByUserDocument_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IterateByFilter provides a mock function with given fields: ctx, filter
+func (_m *MockTransactionRepository) IterateByFilter(ctx context.Context, filter *repositories.TransactionFilter) (repositories.TransactionIterator, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IterateByFilter")
+	}
+
+	var r0 repositories.TransactionIterator
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *repositories.TransactionFilter) (repositories.TransactionIterator, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *repositories.TransactionFilter) repositories.TransactionIterator); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(repositories.TransactionIterator)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *repositories.TransactionFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTransactionRepository_IterateByFilter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IterateByFilter'
+type MockTransactionRepository_IterateByFilter_Call struct {
+	*mock.Call
+}
+
+// IterateByFilter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter *repositories.TransactionFilter
+func (_e *MockTransactionRepository_Expecter) IterateByFilter(ctx interface{}, filter interface{}) *MockTransactionRepository_IterateByFilter_Call {
+	return &MockTransactionRepository_IterateByFilter_Call{Call: _e.mock.On("IterateByFilter", ctx, filter)}
+}
+
+func (_c *MockTransactionRepository_IterateByFilter_Call) Run(run func(ctx context.Context, filter *repositories.TransactionFilter)) *MockTransactionRepository_IterateByFilter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*repositories.TransactionFilter))
+	})
+	return _c
+}
+
+func (_c *MockTransactionRepository_IterateByFilter_Call) Return(_a0 repositories.TransactionIterator, _a1 error) *MockTransactionRepository_IterateByFilter_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTransactionRepository_IterateByFilter_Call) RunAndReturn(run func(context.Context, *repositories.TransactionFilter) (repositories.TransactionIterator, error)) *MockTransactionRepository_IterateByFilter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReplaceEncryptedFieldsByID provides a mock function with given fields: ctx, current, updated
+func (_m *MockTransactionRepository) ReplaceEncryptedFieldsByID(ctx context.Context, current *entities.Transaction, updated *entities.Transaction) (bool, error) {
+	ret := _m.Called(ctx, current, updated)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceEncryptedFieldsByID")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.Transaction, *entities.Transaction) (bool, error)); ok {
+		return rf(ctx, current, updated)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.Transaction, *entities.Transaction) bool); ok {
+		r0 = rf(ctx, current, updated)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *entities.Transaction, *entities.Transaction) error); ok {
+		r1 = rf(ctx, current, updated)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTransactionRepository_ReplaceEncryptedFieldsByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReplaceEncryptedFieldsByID'
+type MockTransactionRepository_ReplaceEncryptedFieldsByID_Call struct {
+	*mock.Call
+}
+
+// ReplaceEncryptedFieldsByID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - current *entities.Transaction
+//   - updated *entities.Transaction
+func (_e *MockTransactionRepository_Expecter) ReplaceEncryptedFieldsByID(ctx interface{}, current interface{}, updated interface{}) *MockTransactionRepository_ReplaceEncryptedFieldsByID_Call {
+	return &MockTransactionRepository_ReplaceEncryptedFieldsByID_Call{Call: _e.mock.On("ReplaceEncryptedFieldsByID", ctx, current, updated)}
+}
+
+func (_c *MockTransactionRepository_ReplaceEncryptedFieldsByID_Call) Run(run func(ctx context.Context, current *entities.Transaction, updated *entities.Transaction)) *MockTransactionRepository_ReplaceEncryptedFieldsByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*entities.Transaction), args[2].(*entities.Transaction))
+	})
+	return _c
+}
+
+func (_c *MockTransactionRepository_ReplaceEncryptedFieldsByID_Call) Return(_a0 bool, _a1 error) *MockTransactionRepository_ReplaceEncryptedFieldsByID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTransactionRepository_ReplaceEncryptedFieldsByID_Call) RunAndReturn(run func(context.Context, *entities.Transaction, *entities.Transaction) (bool, error)) *MockTransactionRepository_ReplaceEncryptedFieldsByID_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// UpdateByID provides a mock function with given fields: updatedTransaction
-func (_m *MockTransactionRepository) UpdateByID(updatedTransaction *entities.Transaction) error {
-	ret := _m.Called(updatedTransaction)
+// UpdateByID provides a mock function with given fields: ctx, updatedTransaction
+func (_m *MockTransactionRepository) UpdateByID(ctx context.Context, updatedTransaction *entities.Transaction) error {
+	ret := _m.Called(ctx, updatedTransaction)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateByID")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*entities.Transaction) error); ok {
-		r0 = rf(updatedTransaction)
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.Transaction) error); ok {
+		r0 = rf(ctx, updatedTransaction)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -252,14 +618,15 @@ type MockTransactionRepository_UpdateByID_Call struct {
 }
 
 // UpdateByID is a helper method to define mock.On call
+//   - ctx context.Context
 //   - updatedTransaction *entities.Transaction
-func (_e *MockTransactionRepository_Expecter) UpdateByID(updatedTransaction interface{}) *MockTransactionRepository_UpdateByID_Call {
-	return &MockTransactionRepository_UpdateByID_Call{Call: _e.mock.On("UpdateByID", updatedTransaction)}
+func (_e *MockTransactionRepository_Expecter) UpdateByID(ctx interface{}, updatedTransaction interface{}) *MockTransactionRepository_UpdateByID_Call {
+	return &MockTransactionRepository_UpdateByID_Call{Call: _e.mock.On("UpdateByID", ctx, updatedTransaction)}
 }
 
-func (_c *MockTransactionRepository_UpdateByID_Call) Run(run func(updatedTransaction *entities.Transaction)) *MockTransactionRepository_UpdateByID_Call {
+func (_c *MockTransactionRepository_UpdateByID_Call) Run(run func(ctx context.Context, updatedTransaction *entities.Transaction)) *MockTransactionRepository_UpdateByID_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*entities.Transaction))
+		run(args[0].(context.Context), args[1].(*entities.Transaction))
 	})
 	return _c
 }
@@ -269,7 +636,7 @@ func (_c *MockTransactionRepository_UpdateByID_Call) Return(_a0 error) *MockTran
 	return _c
 }
 
-func (_c *MockTransactionRepository_UpdateByID_Call) RunAndReturn(run func(*entities.Transaction) error) *MockTransactionRepository_UpdateByID_Call {
+func (_c *MockTransactionRepository_UpdateByID_Call) RunAndReturn(run func(context.Context, *entities.Transaction) error) *MockTransactionRepository_UpdateByID_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -21,6 +21,52 @@ func (_m *MockTransactionCryptoProvider) EXPECT() *MockTransactionCryptoProvider
 	return &MockTransactionCryptoProvider_Expecter{mock: &_m.Mock}
 }
 
+// CreditCardTokenLast4Index provides a mock function with given fields: last4
+func (_m *MockTransactionCryptoProvider) CreditCardTokenLast4Index(last4 string) string {
+	ret := _m.Called(last4)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreditCardTokenLast4Index")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(last4)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// MockTransactionCryptoProvider_CreditCardTokenLast4Index_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreditCardTokenLast4Index'
+type MockTransactionCryptoProvider_CreditCardTokenLast4Index_Call struct {
+	*mock.Call
+}
+
+// CreditCardTokenLast4Index is a helper method to define mock.On call
+//   - last4 string
+func (_e *MockTransactionCryptoProvider_Expecter) CreditCardTokenLast4Index(last4 interface{}) *MockTransactionCryptoProvider_CreditCardTokenLast4Index_Call {
+	return &MockTransactionCryptoProvider_CreditCardTokenLast4Index_Call{Call: _e.mock.On("CreditCardTokenLast4Index", last4)}
+}
+
+func (_c *MockTransactionCryptoProvider_CreditCardTokenLast4Index_Call) Run(run func(last4 string)) *MockTransactionCryptoProvider_CreditCardTokenLast4Index_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockTransactionCryptoProvider_CreditCardTokenLast4Index_Call) Return(_a0 string) *MockTransactionCryptoProvider_CreditCardTokenLast4Index_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockTransactionCryptoProvider_CreditCardTokenLast4Index_Call) RunAndReturn(run func(string) string) *MockTransactionCryptoProvider_CreditCardTokenLast4Index_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Decrypt provides a mock function with given fields: _a0
 func (_m *MockTransactionCryptoProvider) Decrypt(_a0 *entities.Transaction) error {
 	ret := _m.Called(_a0)
@@ -67,6 +113,64 @@ func (_c *MockTransactionCryptoProvider_Decrypt_Call) RunAndReturn(run func(*ent
 	return _c
 }
 
+// DecryptMany provides a mock function with given fields: _a0
+func (_m *MockTransactionCryptoProvider) DecryptMany(_a0 []*entities.Transaction) ([]error, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DecryptMany")
+	}
+
+	var r0 []error
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]*entities.Transaction) ([]error, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func([]*entities.Transaction) []error); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]*entities.Transaction) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTransactionCryptoProvider_DecryptMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DecryptMany'
+type MockTransactionCryptoProvider_DecryptMany_Call struct {
+	*mock.Call
+}
+
+// DecryptMany is a helper method to define mock.On call
+//   - _a0 []*entities.Transaction
+func (_e *MockTransactionCryptoProvider_Expecter) DecryptMany(_a0 interface{}) *MockTransactionCryptoProvider_DecryptMany_Call {
+	return &MockTransactionCryptoProvider_DecryptMany_Call{Call: _e.mock.On("DecryptMany", _a0)}
+}
+
+func (_c *MockTransactionCryptoProvider_DecryptMany_Call) Run(run func(_a0 []*entities.Transaction)) *MockTransactionCryptoProvider_DecryptMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]*entities.Transaction))
+	})
+	return _c
+}
+
+func (_c *MockTransactionCryptoProvider_DecryptMany_Call) Return(_a0 []error, _a1 error) *MockTransactionCryptoProvider_DecryptMany_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTransactionCryptoProvider_DecryptMany_Call) RunAndReturn(run func([]*entities.Transaction) ([]error, error)) *MockTransactionCryptoProvider_DecryptMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Encrypt provides a mock function with given fields: _a0
 func (_m *MockTransactionCryptoProvider) Encrypt(_a0 *entities.Transaction) error {
 	ret := _m.Called(_a0)
@@ -113,6 +217,154 @@ func (_c *MockTransactionCryptoProvider_Encrypt_Call) RunAndReturn(run func(*ent
 	return _c
 }
 
+// EncryptMany provides a mock function with given fields: _a0
+func (_m *MockTransactionCryptoProvider) EncryptMany(_a0 []*entities.Transaction) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EncryptMany")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]*entities.Transaction) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockTransactionCryptoProvider_EncryptMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EncryptMany'
+type MockTransactionCryptoProvider_EncryptMany_Call struct {
+	*mock.Call
+}
+
+// EncryptMany is a helper method to define mock.On call
+//   - _a0 []*entities.Transaction
+func (_e *MockTransactionCryptoProvider_Expecter) EncryptMany(_a0 interface{}) *MockTransactionCryptoProvider_EncryptMany_Call {
+	return &MockTransactionCryptoProvider_EncryptMany_Call{Call: _e.mock.On("EncryptMany", _a0)}
+}
+
+func (_c *MockTransactionCryptoProvider_EncryptMany_Call) Run(run func(_a0 []*entities.Transaction)) *MockTransactionCryptoProvider_EncryptMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]*entities.Transaction))
+	})
+	return _c
+}
+
+func (_c *MockTransactionCryptoProvider_EncryptMany_Call) Return(_a0 error) *MockTransactionCryptoProvider_EncryptMany_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockTransactionCryptoProvider_EncryptMany_Call) RunAndReturn(run func([]*entities.Transaction) error) *MockTransactionCryptoProvider_EncryptMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RewrapDataKey provides a mock function with given fields: _a0
+func (_m *MockTransactionCryptoProvider) RewrapDataKey(_a0 *entities.Transaction) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RewrapDataKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*entities.Transaction) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockTransactionCryptoProvider_RewrapDataKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RewrapDataKey'
+type MockTransactionCryptoProvider_RewrapDataKey_Call struct {
+	*mock.Call
+}
+
+// RewrapDataKey is a helper method to define mock.On call
+//   - _a0 *entities.Transaction
+func (_e *MockTransactionCryptoProvider_Expecter) RewrapDataKey(_a0 interface{}) *MockTransactionCryptoProvider_RewrapDataKey_Call {
+	return &MockTransactionCryptoProvider_RewrapDataKey_Call{Call: _e.mock.On("RewrapDataKey", _a0)}
+}
+
+func (_c *MockTransactionCryptoProvider_RewrapDataKey_Call) Run(run func(_a0 *entities.Transaction)) *MockTransactionCryptoProvider_RewrapDataKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*entities.Transaction))
+	})
+	return _c
+}
+
+func (_c *MockTransactionCryptoProvider_RewrapDataKey_Call) Return(_a0 error) *MockTransactionCryptoProvider_RewrapDataKey_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockTransactionCryptoProvider_RewrapDataKey_Call) RunAndReturn(run func(*entities.Transaction) error) *MockTransactionCryptoProvider_RewrapDataKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UserDocumentIndex provides a mock function with given fields: userDocument
+func (_m *MockTransactionCryptoProvider) UserDocumentIndex(userDocument string) (string, error) {
+	ret := _m.Called(userDocument)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UserDocumentIndex")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(userDocument)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(userDocument)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userDocument)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTransactionCryptoProvider_UserDocumentIndex_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UserDocumentIndex'
+type MockTransactionCryptoProvider_UserDocumentIndex_Call struct {
+	*mock.Call
+}
+
+// UserDocumentIndex is a helper method to define mock.On call
+//   - userDocument string
+func (_e *MockTransactionCryptoProvider_Expecter) UserDocumentIndex(userDocument interface{}) *MockTransactionCryptoProvider_UserDocumentIndex_Call {
+	return &MockTransactionCryptoProvider_UserDocumentIndex_Call{Call: _e.mock.On("UserDocumentIndex", userDocument)}
+}
+
+func (_c *MockTransactionCryptoProvider_UserDocumentIndex_Call) Run(run func(userDocument string)) *MockTransactionCryptoProvider_UserDocumentIndex_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockTransactionCryptoProvider_UserDocumentIndex_Call) Return(_a0 string, _a1 error) *MockTransactionCryptoProvider_UserDocumentIndex_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTransactionCryptoProvider_UserDocumentIndex_Call) RunAndReturn(run func(string) (string, error)) *MockTransactionCryptoProvider_UserDocumentIndex_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockTransactionCryptoProvider creates a new instance of MockTransactionCryptoProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockTransactionCryptoProvider(t interface {
@@ -0,0 +1,38 @@
+package providers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// BlindIndexKeySize is the size of the keys derived for HmacSha256BlindIndex.
+const BlindIndexKeySize = sha256.Size
+
+// HmacSha256BlindIndex computes a keyed hash of a value, so equal values can
+// be searched for without storing or revealing them.
+type HmacSha256BlindIndex struct {
+	key []byte
+}
+
+func NewHmacSha256BlindIndex(key []byte) *HmacSha256BlindIndex {
+	return &HmacSha256BlindIndex{key}
+}
+
+func (bi *HmacSha256BlindIndex) Compute(value string) string {
+	mac := hmac.New(sha256.New, bi.key)
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Derive returns the blind index of the subkey only used for the given table
+// and field, so its indexes can't be matched with any other field's.
+func (bi *HmacSha256BlindIndex) Derive(table, field string) (*HmacSha256BlindIndex, error) {
+	key, err := DeriveKey(bi.key, KeyPurposeBlindIndex, table+"/"+field)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHmacSha256BlindIndex(key), nil
+}
@@ -0,0 +1,170 @@
+package providers
+
+import (
+	"crypto-challenge/database/repositories"
+	"crypto-challenge/entities"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	cardVaultTable = "card_vault"
+	panField       = "pan"
+
+	cardTokenAttempts = 10
+)
+
+var (
+	ErrInvalidCardNumber = errors.New("invalid card number")
+	ErrCardTokenNotFound = errors.New("card token not found")
+)
+
+type CardTokenizer interface {
+	Tokenize(pan string) (string, error)
+	Detokenize(token string) (string, error)
+}
+
+// VaultCardTokenizer swaps card numbers (PANs) for random tokens of the same
+// length that keep their last 4 digits, storing each PAN encrypted in the
+// vault under its own data key. The tokens never pass the Luhn check, so they
+// can't be mistaken for real card numbers, and a card always gets the same
+// token, found through the blind index of its PAN.
+type VaultCardTokenizer struct {
+	repository     repositories.CardVaultRepository
+	fieldEncryptor *FieldEncryptor
+}
+
+func NewVaultCardTokenizer(repository repositories.CardVaultRepository, kms KeyManagementService,
+	blindIndex *HmacSha256BlindIndex, algorithm string) *VaultCardTokenizer {
+	return &VaultCardTokenizer{repository, NewFieldEncryptor(kms, blindIndex, algorithm)}
+}
+
+func (t *VaultCardTokenizer) Tokenize(pan string) (string, error) {
+	pan = strings.NewReplacer(" ", "", "-", "").Replace(pan)
+	if !isCardNumber(pan) || !luhnValid(pan) {
+		return "", ErrInvalidCardNumber
+	}
+
+	panIndex := t.fieldEncryptor.BlindIndex(cardVaultTable, panField, pan)
+
+	for attempt := 0; attempt < cardTokenAttempts; attempt++ {
+		existingEntry, err := t.repository.FindByPanIndex(panIndex)
+		if err != nil {
+			return "", err
+		}
+
+		if existingEntry != nil {
+			return existingEntry.Token, nil
+		}
+
+		token, err := newCardToken(pan)
+		if err != nil {
+			return "", err
+		}
+
+		newEntry := &entities.CardVaultEntry{Token: token, Pan: pan}
+
+		if err := t.fieldEncryptor.Encrypt(cardVaultTable, newEntry); err != nil {
+			return "", err
+		}
+
+		// Not created when the token was taken or the card was tokenized in the
+		// meantime, which the next attempt finds.
+		created, err := t.repository.Create(newEntry)
+		if err != nil {
+			return "", err
+		}
+
+		if created {
+			return token, nil
+		}
+	}
+
+	return "", errors.New("could not find an unused card token")
+}
+
+func (t *VaultCardTokenizer) Detokenize(token string) (string, error) {
+	if !isCardNumber(token) {
+		return "", ErrCardTokenNotFound
+	}
+
+	entry, err := t.repository.FindByToken(token)
+	if err != nil {
+		return "", err
+	}
+
+	if entry == nil {
+		return "", ErrCardTokenNotFound
+	}
+
+	if err := t.fieldEncryptor.Decrypt(cardVaultTable, entry); err != nil {
+		return "", err
+	}
+
+	return entry.Pan, nil
+}
+
+// RewrapDataKey wraps the entry's data key again under the current master
+// key.
+func (t *VaultCardTokenizer) RewrapDataKey(entry *entities.CardVaultEntry) error {
+	return t.fieldEncryptor.RewrapDataKey(cardVaultTable, entry)
+}
+
+// newCardToken draws random digits until they fail the Luhn check, keeping
+// the last 4 digits of the PAN.
+func newCardToken(pan string) (string, error) {
+	randomDigits := len(pan) - 4
+
+	for {
+		var b strings.Builder
+
+		for i := 0; i < randomDigits; i++ {
+			digit, err := rand.Int(rand.Reader, big.NewInt(10))
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteByte(byte('0' + digit.Int64()))
+		}
+
+		token := b.String() + pan[randomDigits:]
+		if !luhnValid(token) {
+			return token, nil
+		}
+	}
+}
+
+func isCardNumber(value string) bool {
+	if len(value) < 12 || len(value) > 19 {
+		return false
+	}
+
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func luhnValid(digits string) bool {
+	sum := 0
+
+	for i := 0; i < len(digits); i++ {
+		digit := int(digits[len(digits)-1-i] - '0')
+
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+	}
+
+	return sum%10 == 0
+}
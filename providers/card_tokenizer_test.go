@@ -0,0 +1,126 @@
+package providers
+
+import (
+	"crypto-challenge/entities"
+	"crypto-challenge/mocks/crypto-challenge/database/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const visaTestCardNumber = "4111111111111111"
+
+func TestTokenize(t *testing.T) {
+	// given
+	underTest, vault := newVaultCardTokenizer(t)
+
+	// when
+	token, err := underTest.Tokenize("4111 1111 1111 1111")
+
+	// then
+	require.Nil(t, err)
+	assert.Len(t, token, len(visaTestCardNumber))
+	assert.True(t, isCardNumber(token))
+	assert.Equal(t, "1111", token[len(token)-4:])
+	assert.NotEqual(t, visaTestCardNumber, token)
+	assert.False(t, luhnValid(token))
+
+	require.Contains(t, vault, token)
+	assert.NotContains(t, vault[token].Pan, visaTestCardNumber)
+}
+
+func TestTokenize_SameCardGetsSameToken(t *testing.T) {
+	// given
+	underTest, _ := newVaultCardTokenizer(t)
+
+	first, err := underTest.Tokenize(visaTestCardNumber)
+	require.Nil(t, err)
+
+	// when
+	second, err := underTest.Tokenize("4111-1111-1111-1111")
+
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestTokenize_WithInvalidCardNumber(t *testing.T) {
+	// given
+	underTest, _ := newVaultCardTokenizer(t)
+
+	for _, invalid := range []string{"4111111111111112", "41111111", "4111a11111111111", ""} {
+		// when
+		_, err := underTest.Tokenize(invalid)
+
+		// then
+		assert.ErrorIs(t, err, ErrInvalidCardNumber, invalid)
+	}
+}
+
+func TestDetokenize(t *testing.T) {
+	// given
+	underTest, _ := newVaultCardTokenizer(t)
+
+	token, err := underTest.Tokenize(visaTestCardNumber)
+	require.Nil(t, err)
+
+	// when
+	pan, err := underTest.Detokenize(token)
+
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, visaTestCardNumber, pan)
+}
+
+func TestDetokenize_WithUnknownToken(t *testing.T) {
+	// given
+	underTest, _ := newVaultCardTokenizer(t)
+
+	// when
+	_, err := underTest.Detokenize("4111111111111112")
+
+	// then
+	assert.ErrorIs(t, err, ErrCardTokenNotFound)
+}
+
+// newVaultCardTokenizer returns a tokenizer over a vault kept in a map, by
+// token.
+func newVaultCardTokenizer(t *testing.T) (*VaultCardTokenizer, map[string]*entities.CardVaultEntry) {
+	vault := make(map[string]*entities.CardVaultEntry)
+	repositoryMock := repositories.NewMockCardVaultRepository(t)
+
+	repositoryMock.EXPECT().FindByToken(mock.Anything).RunAndReturn(func(token string) (*entities.CardVaultEntry, error) {
+		if entry, ok := vault[token]; ok {
+			found := *entry
+			return &found, nil
+		}
+
+		return nil, nil
+	}).Maybe()
+
+	repositoryMock.EXPECT().FindByPanIndex(mock.Anything).RunAndReturn(func(panIndex string) (*entities.CardVaultEntry, error) {
+		for _, entry := range vault {
+			if entry.PanIndex == panIndex {
+				found := *entry
+				return &found, nil
+			}
+		}
+
+		return nil, nil
+	}).Maybe()
+
+	repositoryMock.EXPECT().Create(mock.Anything).RunAndReturn(func(newEntry *entities.CardVaultEntry) (bool, error) {
+		if _, ok := vault[newEntry.Token]; ok {
+			return false, nil
+		}
+
+		vault[newEntry.Token] = newEntry
+		return true, nil
+	}).Maybe()
+
+	kms := NewLocalKeyManagementService(newKeyring(t, "k1", secretKey), AlgorithmAesGcm256)
+
+	return NewVaultCardTokenizer(repositoryMock, kms, newBlindIndex(t), AlgorithmAesGcm256), vault
+}
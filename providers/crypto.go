@@ -4,77 +4,182 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
-	"strings"
+	"sync"
+
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 type CryptoProvider interface {
-	Encrypt([]byte) (string, error)
-	Decrypt(string) ([]byte, error)
+	Encrypt(toEncrypt, additionalData []byte) (string, error)
+	Decrypt(toDecrypt string, additionalData []byte) ([]byte, error)
 }
 
-type AesGcm256CryptoProvider struct {
-	key []byte
+// AeadCryptoProvider encrypts with the AEAD algorithm it was built for and
+// decrypts with the one recorded in each ciphertext's envelope, so switching
+// algorithms doesn't break reading what was written before. The AEAD of each
+// key and algorithm is built once and shared by every call: the AEADs hold no
+// state between calls, so they are safe for concurrent use. They hold their
+// own copy of the key, expanded into the AES key schedule or kept by
+// ChaCha20-Poly1305, which WipeLockedKeys can't reach: it lives unlocked and
+// unzeroed for as long as the provider.
+type AeadCryptoProvider struct {
+	keyring       *Keyring
+	algorithm     string
+	rejectUnbound bool
+
+	aeads sync.Map
 }
 
-func NewAesGcm256CryptoProvider(secretKey string) *AesGcm256CryptoProvider {
-	key, _ := hex.DecodeString(secretKey)
+type aeadCacheKey struct {
+	algorithm string
+	keyID     string
+}
 
-	return &AesGcm256CryptoProvider{key}
+func NewAeadCryptoProvider(keyring *Keyring, algorithm string) *AeadCryptoProvider {
+	return &AeadCryptoProvider{keyring: keyring, algorithm: algorithm}
 }
 
-func (cp *AesGcm256CryptoProvider) Encrypt(toEncrypt []byte) (string, error) {
-	block, err := aes.NewCipher(cp.key)
-	if err != nil {
-		log.Println(err)
-		return "", err
-	}
+func NewAesGcm256CryptoProvider(keyring *Keyring) *AeadCryptoProvider {
+	return NewAeadCryptoProvider(keyring, AlgorithmAesGcm256)
+}
+
+func NewChaCha20Poly1305CryptoProvider(keyring *Keyring) *AeadCryptoProvider {
+	return NewAeadCryptoProvider(keyring, AlgorithmChaCha20Poly1305)
+}
+
+func NewXChaCha20Poly1305CryptoProvider(keyring *Keyring) *AeadCryptoProvider {
+	return NewAeadCryptoProvider(keyring, AlgorithmXChaCha20Poly1305)
+}
 
-	aesgcm, err := cipher.NewGCM(block)
+// RejectUnboundCiphertexts stops decrypting the ciphertexts from before
+// version 2, which aren't bound to any additional data, so they can't be
+// copied to another row or column anymore. Meant for once all of them were
+// re-encrypted.
+func (cp *AeadCryptoProvider) RejectUnboundCiphertexts() {
+	cp.rejectUnbound = true
+}
+
+func (cp *AeadCryptoProvider) Encrypt(toEncrypt, additionalData []byte) (string, error) {
+	keyID, key := cp.keyring.PrimaryKey()
+
+	aead, err := cp.aead(cp.algorithm, keyID, key)
 	if err != nil {
 		log.Println(err)
 		return "", err
 	}
 
-	nonce := make([]byte, 12)
+	nonce := make([]byte, aead.NonceSize())
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		log.Println(err)
 		return "", err
 	}
 
-	ciphertext := aesgcm.Seal(nil, nonce, toEncrypt, nil)
+	ciphertext := aead.Seal(nil, nonce, toEncrypt, additionalData)
 
-	nonceAndCiphertext := fmt.Sprintf("%x-%x", nonce, ciphertext)
+	envelope := &Envelope{
+		Version:    EnvelopeVersion2,
+		KeyID:      keyID,
+		Algorithm:  cp.algorithm,
+		Nonce:      nonce,
+		Ciphertext: ciphertext,
+	}
 
-	return nonceAndCiphertext, nil
+	return envelope.String(), nil
 }
 
-func (cp *AesGcm256CryptoProvider) Decrypt(toDecrypt string) ([]byte, error) {
-	nonceWithCiphertextSplitted := strings.Split(toDecrypt, "-")
+func (cp *AeadCryptoProvider) Decrypt(toDecrypt string, additionalData []byte) ([]byte, error) {
+	envelope, err := ParseEnvelope(toDecrypt)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	if cp.rejectUnbound && envelope.Version != EnvelopeVersion2 {
+		err := fmt.Errorf("%w: the ciphertext predates version 2, it isn't bound to any additional data",
+			ErrAuthenticationFailed)
+		log.Println(err)
+		return nil, err
+	}
+
+	// Legacy ciphertexts carry no key ID nor algorithm, they were all produced
+	// with AES-256-GCM under the keyring's legacy key.
+	keyID, key := cp.keyring.LegacyKey()
+	algorithm := AlgorithmAesGcm256
 
-	nonce, _ := hex.DecodeString(nonceWithCiphertextSplitted[0])
-	ciphertext, _ := hex.DecodeString(nonceWithCiphertextSplitted[1])
+	if envelope.Version != EnvelopeVersionLegacy {
+		keyID, algorithm = envelope.KeyID, envelope.Algorithm
 
-	block, err := aes.NewCipher(cp.key)
+		key, err = cp.keyring.Key(keyID)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+	}
+
+	aead, err := cp.aead(algorithm, keyID, key)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	aesgcm, err := cipher.NewGCM(block)
-	if err != nil {
+	if len(envelope.Nonce) != aead.NonceSize() {
+		err := fmt.Errorf("%w: invalid nonce size %d", ErrMalformedCiphertext, len(envelope.Nonce))
 		log.Println(err)
 		return nil, err
 	}
 
-	decrypted, err := aesgcm.Open(nil, nonce, ciphertext, nil)
+	// Ciphertexts from before version 2 weren't bound to any additional data,
+	// they keep decrypting until re-encrypted, unless rejected.
+	if envelope.Version != EnvelopeVersion2 {
+		additionalData = nil
+	}
+
+	decrypted, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, additionalData)
 	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrAuthenticationFailed, err)
 		log.Println(err)
 		return nil, err
 	}
 
 	return decrypted, nil
 }
+
+// aead returns the AEAD of the key, building it on first use. Concurrent
+// first uses may build it more than once, only one of them is kept.
+func (cp *AeadCryptoProvider) aead(algorithm, keyID string, key []byte) (cipher.AEAD, error) {
+	cacheKey := aeadCacheKey{algorithm, keyID}
+
+	if aead, ok := cp.aeads.Load(cacheKey); ok {
+		return aead.(cipher.AEAD), nil
+	}
+
+	aead, err := newAead(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, _ := cp.aeads.LoadOrStore(cacheKey, aead)
+
+	return cached.(cipher.AEAD), nil
+}
+
+func newAead(algorithm string, key []byte) (cipher.AEAD, error) {
+	switch algorithm {
+	case AlgorithmAesGcm256:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+
+		return cipher.NewGCM(block)
+	case AlgorithmChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	case AlgorithmXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	default:
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrMalformedCiphertext, algorithm)
+	}
+}
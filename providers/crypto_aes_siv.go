@@ -0,0 +1,252 @@
+package providers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"sync"
+)
+
+const (
+	aesSivBlockSize = aes.BlockSize
+	// AesSivKeySize is the size of the keys derived for AesSivCryptoProvider.
+	AesSivKeySize = 64
+)
+
+var errAesSivAuthenticationFailed = fmt.Errorf("aes-siv: %w", ErrAuthenticationFailed)
+
+// AesSivCryptoProvider encrypts deterministically with AES-SIV (RFC 5297):
+// equal plaintexts under the same key and additional data give equal
+// ciphertexts, so encrypted values can still be matched for equality. Its keys
+// are 64 bytes long, half for the S2V MAC and half for AES-CTR. Like
+// AeadCryptoProvider, it builds the ciphers of each key once.
+type AesSivCryptoProvider struct {
+	keyring *Keyring
+
+	sivs sync.Map
+}
+
+func NewAesSivCryptoProvider(keyring *Keyring) *AesSivCryptoProvider {
+	return &AesSivCryptoProvider{keyring: keyring}
+}
+
+func (cp *AesSivCryptoProvider) Encrypt(toEncrypt, additionalData []byte) (string, error) {
+	keyID, key := cp.keyring.PrimaryKey()
+
+	aesSiv, err := cp.aesSiv(keyID, key)
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	siv, ciphertext := aesSiv.seal(toEncrypt, additionalData)
+
+	// The synthetic IV takes the place of the nonce in the envelope.
+	envelope := &Envelope{
+		Version:    EnvelopeVersion2,
+		KeyID:      keyID,
+		Algorithm:  AlgorithmAesSiv,
+		Nonce:      siv,
+		Ciphertext: ciphertext,
+	}
+
+	return envelope.String(), nil
+}
+
+func (cp *AesSivCryptoProvider) Decrypt(toDecrypt string, additionalData []byte) ([]byte, error) {
+	envelope, err := ParseEnvelope(toDecrypt)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	if envelope.Version != EnvelopeVersion2 || envelope.Algorithm != AlgorithmAesSiv {
+		err := fmt.Errorf("%w: unsupported algorithm %q", ErrMalformedCiphertext, envelope.Algorithm)
+		log.Println(err)
+		return nil, err
+	}
+
+	key, err := cp.keyring.Key(envelope.KeyID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	aesSiv, err := cp.aesSiv(envelope.KeyID, key)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	decrypted, err := aesSiv.open(envelope.Nonce, envelope.Ciphertext, additionalData)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return decrypted, nil
+}
+
+func (cp *AesSivCryptoProvider) aesSiv(keyID string, key []byte) (*aesSiv, error) {
+	if cached, ok := cp.sivs.Load(keyID); ok {
+		return cached.(*aesSiv), nil
+	}
+
+	built, err := newAesSiv(key)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, _ := cp.sivs.LoadOrStore(keyID, built)
+
+	return cached.(*aesSiv), nil
+}
+
+// aesSiv holds the AES ciphers of an AES-SIV key, which are safe for
+// concurrent use.
+type aesSiv struct {
+	macBlock cipher.Block
+	ctrBlock cipher.Block
+}
+
+func newAesSiv(key []byte) (*aesSiv, error) {
+	if len(key) != 32 && len(key) != 48 && len(key) != 64 {
+		return nil, fmt.Errorf("aes-siv: invalid key size %d", len(key))
+	}
+
+	macBlock, err := aes.NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+
+	ctrBlock, err := aes.NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &aesSiv{macBlock, ctrBlock}, nil
+}
+
+func (as *aesSiv) seal(plaintext, additionalData []byte) ([]byte, []byte) {
+	siv := s2v(as.macBlock, additionalData, plaintext)
+
+	ciphertext := make([]byte, len(plaintext))
+	aesSivCtr(as.ctrBlock, siv).XORKeyStream(ciphertext, plaintext)
+
+	return siv, ciphertext
+}
+
+func (as *aesSiv) open(siv, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(siv) != aesSivBlockSize {
+		return nil, fmt.Errorf("%w: invalid nonce size %d", ErrMalformedCiphertext, len(siv))
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	aesSivCtr(as.ctrBlock, siv).XORKeyStream(plaintext, ciphertext)
+
+	if subtle.ConstantTimeCompare(siv, s2v(as.macBlock, additionalData, plaintext)) != 1 {
+		return nil, errAesSivAuthenticationFailed
+	}
+
+	return plaintext, nil
+}
+
+// aesSivCtr clears the 31st and 63rd bits of the counter, counted from the
+// right, as RFC 5297 mandates for implementations with 32-bit counters.
+func aesSivCtr(block cipher.Block, siv []byte) cipher.Stream {
+	counter := make([]byte, aesSivBlockSize)
+	copy(counter, siv)
+
+	counter[8] &= 0x7f
+	counter[12] &= 0x7f
+
+	return cipher.NewCTR(block, counter)
+}
+
+// s2v turns the additional data and the plaintext into the synthetic IV.
+func s2v(block cipher.Block, additionalData, plaintext []byte) []byte {
+	d := cmac(block, make([]byte, aesSivBlockSize))
+
+	if additionalData != nil {
+		xorBlock(d, dbl(d), cmac(block, additionalData))
+	}
+
+	var t []byte
+
+	if len(plaintext) >= aesSivBlockSize {
+		t = append([]byte(nil), plaintext...)
+		xorBlock(t[len(t)-aesSivBlockSize:], t[len(t)-aesSivBlockSize:], d)
+	} else {
+		t = make([]byte, aesSivBlockSize)
+		xorBlock(t, dbl(d), cmacPad(plaintext))
+	}
+
+	return cmac(block, t)
+}
+
+// cmac computes the AES-CMAC (RFC 4493) of the message.
+func cmac(block cipher.Block, message []byte) []byte {
+	k1 := make([]byte, aesSivBlockSize)
+	block.Encrypt(k1, k1)
+	k1 = dbl(k1)
+	k2 := dbl(k1)
+
+	blockCount := (len(message) + aesSivBlockSize - 1) / aesSivBlockSize
+	if blockCount == 0 {
+		blockCount = 1
+	}
+
+	last := make([]byte, aesSivBlockSize)
+	lastStart := (blockCount - 1) * aesSivBlockSize
+
+	if len(message) > 0 && len(message)%aesSivBlockSize == 0 {
+		xorBlock(last, message[lastStart:], k1)
+	} else {
+		xorBlock(last, cmacPad(message[lastStart:]), k2)
+	}
+
+	x := make([]byte, aesSivBlockSize)
+
+	for i := 0; i < lastStart; i += aesSivBlockSize {
+		xorBlock(x, x, message[i:i+aesSivBlockSize])
+		block.Encrypt(x, x)
+	}
+
+	xorBlock(x, x, last)
+	block.Encrypt(x, x)
+
+	return x
+}
+
+func cmacPad(partial []byte) []byte {
+	padded := make([]byte, aesSivBlockSize)
+	copy(padded, partial)
+	padded[len(partial)] = 0x80
+
+	return padded
+}
+
+// dbl multiplies the block by x in GF(2^128).
+func dbl(in []byte) []byte {
+	out := make([]byte, aesSivBlockSize)
+
+	for i := 0; i < aesSivBlockSize-1; i++ {
+		out[i] = in[i]<<1 | in[i+1]>>7
+	}
+
+	out[aesSivBlockSize-1] = in[aesSivBlockSize-1] << 1
+
+	if in[0]&0x80 != 0 {
+		out[aesSivBlockSize-1] ^= 0x87
+	}
+
+	return out
+}
+
+func xorBlock(dst, a, b []byte) {
+	for i := 0; i < aesSivBlockSize; i++ {
+		dst[i] = a[i] ^ b[i]
+	}
+}
@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const aesSivKey = "7f7e7d7c7b7a79787776757473727170404142434445464748494a4b4c4d4e4f" +
+	"c7b81104b9fc8b05ff85995f6d34d5b18cfbb0cff21ff2ceab154a3bcfae3aba"
+
+func TestAesSivSeal_WithRfc5297TestVector(t *testing.T) {
+	// given
+	key := mustDecodeHex(t, "fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	additionalData := mustDecodeHex(t, "101112131415161718191a1b1c1d1e1f2021222324252627")
+	plaintext := mustDecodeHex(t, "112233445566778899aabbccddee")
+
+	underTest, err := newAesSiv(key)
+	require.Nil(t, err)
+
+	// when
+	siv, ciphertext := underTest.seal(plaintext, additionalData)
+
+	// then
+	assert.Equal(t, "85632d07c6e8f37f950acd320a2ecc93", hex.EncodeToString(siv))
+	assert.Equal(t, "40c02b9690c4dc04daef7f6afe5c", hex.EncodeToString(ciphertext))
+}
+
+func TestAesSivEncrypt_IsDeterministic(t *testing.T) {
+	// given
+	underTest := NewAesSivCryptoProvider(newKeyring(t, "d1", aesSivKey))
+	expected := "lorem ipsum"
+
+	// when
+	ciphertext, err := underTest.Encrypt([]byte(expected), []byte("additional data"))
+	require.Nil(t, err)
+
+	sameCiphertext, err := underTest.Encrypt([]byte(expected), []byte("additional data"))
+	require.Nil(t, err)
+
+	otherContextCiphertext, err := underTest.Encrypt([]byte(expected), []byte("other additional data"))
+	require.Nil(t, err)
+
+	actual, err := underTest.Decrypt(ciphertext, []byte("additional data"))
+	require.Nil(t, err)
+
+	// then
+	assert.Equal(t, ciphertext, sameCiphertext)
+	assert.NotEqual(t, ciphertext, otherContextCiphertext)
+	assert.Equal(t, expected, string(actual))
+}
+
+func TestAesSivDecrypt_WithWrongAdditionalData(t *testing.T) {
+	// given
+	underTest := NewAesSivCryptoProvider(newKeyring(t, "d1", aesSivKey))
+
+	ciphertext, err := underTest.Encrypt([]byte("lorem ipsum"), []byte("additional data"))
+	require.Nil(t, err)
+
+	// when
+	actual, err := underTest.Decrypt(ciphertext, []byte("other additional data"))
+
+	// then
+	assert.NotNil(t, err)
+	assert.Nil(t, actual)
+}
@@ -0,0 +1,234 @@
+package providers
+
+import (
+	"crypto-challenge/entities"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// listingSize is the number of transactions of the large listing benchmarks.
+const listingSize = 1000
+
+func BenchmarkAeadCryptoProviderDecrypt(b *testing.B) {
+	underTest := NewAesGcm256CryptoProvider(newKeyring(b, "k1", secretKey))
+
+	ciphertext, err := underTest.Encrypt([]byte("50277613433"), nil)
+	require.Nil(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := underTest.Decrypt(ciphertext, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkAeadCryptoProviderDecrypt_RebuildingAead decrypts as the provider
+// did before caching its AEADs, building them on every call.
+func BenchmarkAeadCryptoProviderDecrypt_RebuildingAead(b *testing.B) {
+	keyring := newKeyring(b, "k1", secretKey)
+
+	ciphertext, err := NewAesGcm256CryptoProvider(keyring).Encrypt([]byte("50277613433"), nil)
+	require.Nil(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := NewAesGcm256CryptoProvider(keyring).Decrypt(ciphertext, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAeadCryptoProviderDecrypt_Parallel(b *testing.B) {
+	underTest := NewAesGcm256CryptoProvider(newKeyring(b, "k1", secretKey))
+
+	ciphertext, err := underTest.Encrypt([]byte("50277613433"), nil)
+	require.Nil(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := underTest.Decrypt(ciphertext, nil); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkAesSivCryptoProviderDecrypt(b *testing.B) {
+	underTest := NewAesSivCryptoProvider(newKeyring(b, "d1", aesSivKey))
+
+	ciphertext, err := underTest.Encrypt([]byte("50277613433"), []byte("additional data"))
+	require.Nil(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := underTest.Decrypt(ciphertext, []byte("additional data")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecryptTransactions decrypts a listing the way FindAll does, each
+// transaction unwrapping its data key with the master key first.
+func BenchmarkDecryptTransactions(b *testing.B) {
+	for _, deterministic := range []bool{false, true} {
+		b.Run(fmt.Sprintf("deterministic=%t", deterministic), func(b *testing.B) {
+			underTest := newStandardTransactionCryptoProviderFor(b, newKeyring(b, "k1", secretKey))
+
+			if deterministic {
+				require.Nil(b, underTest.EncryptDeterministically(newKeyring(b, "d1", aesSivKey), UserDocumentField))
+			}
+
+			encrypted := encryptedListing(b, underTest)
+			listing := make([]entities.Transaction, len(encrypted))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				copy(listing, encrypted)
+
+				for j := range listing {
+					if err := underTest.Decrypt(&listing[j]); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+
+			b.ReportMetric(float64(b.N*len(listing))/b.Elapsed().Seconds(), "transactions/s")
+		})
+	}
+}
+
+// BenchmarkDecryptListingFields decrypts the fields of a listing under their
+// data keys the way FieldEncryptor does, deriving the subkeys of each
+// transaction from a single HKDF extraction, and the way it did before,
+// deriving the whole data keyring again for every field. Either way, each
+// transaction's AEADs are its own, since every data key is.
+func BenchmarkDecryptListingFields(b *testing.B) {
+	fieldEncryptor := NewFieldEncryptor(nil, nil, AlgorithmAesGcm256)
+	fields := []string{UserDocumentField, CreditCardTokenField}
+
+	dataKeys := make([][]byte, listingSize)
+	ciphertexts := make([][]string, listingSize)
+
+	for i := range dataKeys {
+		dataKeys[i] = make([]byte, 32)
+		_, err := rand.Read(dataKeys[i])
+		require.Nil(b, err)
+
+		for _, field := range fields {
+			cp, err := fieldEncryptor.fieldCryptoProvider(newEntityDataKey(dataKeys[i]), field,
+				DerivedKeyID(DataKeyID, field), AlgorithmAesGcm256)
+			require.Nil(b, err)
+
+			ciphertext, err := cp.Encrypt([]byte("50277613433"), []byte(field))
+			require.Nil(b, err)
+
+			ciphertexts[i] = append(ciphertexts[i], ciphertext)
+		}
+	}
+
+	b.Run("per-transaction", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			for i, dataKey := range dataKeys {
+				entityKey := newEntityDataKey(dataKey)
+
+				for j, field := range fields {
+					cp, err := fieldEncryptor.fieldCryptoProvider(entityKey, field, DerivedKeyID(DataKeyID, field),
+						AlgorithmAesGcm256)
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					if _, err := cp.Decrypt(ciphertexts[i][j], []byte(field)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		}
+	})
+
+	b.Run("per-field", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			for i, dataKey := range dataKeys {
+				dataKeyring := NewKeyring(DataKeyID, dataKey)
+
+				for j, field := range fields {
+					fieldKeyring, err := dataKeyring.Derive(KeyPurposeEncryption, field)
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					cp := NewAeadCryptoProvider(fieldKeyring, AlgorithmAesGcm256)
+
+					if _, err := cp.Decrypt(ciphertexts[i][j], []byte(field)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkDecryptManyTransactions decrypts the same listing with DecryptMany,
+// spread across GOMAXPROCS workers.
+func BenchmarkDecryptManyTransactions(b *testing.B) {
+	underTest := newStandardTransactionCryptoProviderFor(b, newKeyring(b, "k1", secretKey))
+
+	encrypted := encryptedListing(b, underTest)
+	listing := make([]entities.Transaction, len(encrypted))
+
+	toDecrypt := make([]*entities.Transaction, len(listing))
+	for i := range listing {
+		toDecrypt[i] = &listing[i]
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		copy(listing, encrypted)
+
+		if _, err := underTest.DecryptMany(toDecrypt); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(b.N*len(listing))/b.Elapsed().Seconds(), "transactions/s")
+}
+
+func encryptedListing(b *testing.B, tcp *StandardTransactionCryptoProvider) []entities.Transaction {
+	listing := make([]entities.Transaction, listingSize)
+
+	for i := range listing {
+		listing[i] = entities.Transaction{
+			ID:              uuid.NewString(),
+			UserDocument:    fmt.Sprintf("%011d", i),
+			CreditCardToken: fmt.Sprintf("%04d", i),
+			Value:           float64(i),
+		}
+
+		require.Nil(b, tcp.Encrypt(&listing[i]))
+	}
+
+	return listing
+}
@@ -1,26 +1,307 @@
 package providers
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-var secretKey = "c7b81104b9fc8b05ff85995f6d34d5b18cfbb0cff21ff2ceab154a3bcfae3aba"
+var (
+	secretKey        = "c7b81104b9fc8b05ff85995f6d34d5b18cfbb0cff21ff2ceab154a3bcfae3aba"
+	anotherSecretKey = "5be3c9e1b0e4bb3f0d4f0b1b8e1f0cc1a4c3d2f1e0a9b8c7d6e5f4a3b2c1d0e9"
+)
 
 func TestEncryptAndDecryptString(t *testing.T) {
 	// given
-	underTest := NewAesGcm256CryptoProvider(secretKey)
+	underTest := NewAesGcm256CryptoProvider(newKeyring(t, "k1", secretKey))
+	expected := "lorem ipsum"
+
+	// when
+	ciphertext, err := underTest.Encrypt([]byte(expected), nil)
+	require.Nil(t, err)
+
+	actual, err := underTest.Decrypt(ciphertext, nil)
+	require.Nil(t, err)
+
+	// then
+	assert.Equal(t, expected, string(actual))
+}
+
+func TestEncrypt_WritesVersionedEnvelope(t *testing.T) {
+	// given
+	underTest := NewAesGcm256CryptoProvider(newKeyring(t, "k1", secretKey))
+
+	// when
+	ciphertext, err := underTest.Encrypt([]byte("lorem ipsum"), nil)
+	require.Nil(t, err)
+
+	// then
+	envelope, err := ParseEnvelope(ciphertext)
+	require.Nil(t, err)
+
+	assert.True(t, strings.HasPrefix(ciphertext, "v2:k1:aes-256-gcm:"))
+	assert.Equal(t, EnvelopeVersion2, envelope.Version)
+	assert.Equal(t, "k1", envelope.KeyID)
+	assert.Equal(t, AlgorithmAesGcm256, envelope.Algorithm)
+	assert.Len(t, envelope.Nonce, 12)
+}
+
+func TestEncryptAndDecrypt_WithChaCha20Poly1305(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		nonceSize int
+	}{
+		{AlgorithmChaCha20Poly1305, 12},
+		{AlgorithmXChaCha20Poly1305, 24},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			// given
+			underTest := NewAeadCryptoProvider(newKeyring(t, "k1", secretKey), tt.algorithm)
+			expected := "lorem ipsum"
+
+			// when
+			ciphertext, err := underTest.Encrypt([]byte(expected), []byte("additional data"))
+			require.Nil(t, err)
+
+			actual, err := underTest.Decrypt(ciphertext, []byte("additional data"))
+			require.Nil(t, err)
+
+			// then
+			envelope, err := ParseEnvelope(ciphertext)
+			require.Nil(t, err)
+
+			assert.Equal(t, expected, string(actual))
+			assert.Equal(t, tt.algorithm, envelope.Algorithm)
+			assert.Len(t, envelope.Nonce, tt.nonceSize)
+		})
+	}
+}
+
+func TestDecrypt_WithAlgorithmFromEnvelope(t *testing.T) {
+	// given
+	keyring := newKeyring(t, "k1", secretKey)
+	expected := "lorem ipsum"
+
+	ciphertext, err := NewXChaCha20Poly1305CryptoProvider(keyring).Encrypt([]byte(expected), nil)
+	require.Nil(t, err)
+
+	underTest := NewAesGcm256CryptoProvider(keyring)
+
+	// when
+	actual, err := underTest.Decrypt(ciphertext, nil)
+	require.Nil(t, err)
+
+	// then
+	assert.Equal(t, expected, string(actual))
+}
+
+func TestDecrypt_LegacyFormat(t *testing.T) {
+	// given
+	underTest := NewAesGcm256CryptoProvider(newKeyring(t, "k1", secretKey))
+	expected := "lorem ipsum"
+
+	legacyCiphertext := encryptLegacy(t, secretKey, []byte(expected))
+
+	// when
+	actual, err := underTest.Decrypt(legacyCiphertext, nil)
+	require.Nil(t, err)
+
+	// then
+	assert.Equal(t, expected, string(actual))
+}
+
+func TestDecrypt_RejectingUnboundCiphertexts(t *testing.T) {
+	// given
+	underTest := NewAesGcm256CryptoProvider(newKeyring(t, "k1", secretKey))
+	underTest.RejectUnboundCiphertexts()
+
+	bound, err := underTest.Encrypt([]byte("lorem ipsum"), []byte("additional data"))
+	require.Nil(t, err)
+
+	for _, unbound := range []string{
+		encryptLegacy(t, secretKey, []byte("lorem ipsum")),
+		encryptV1(t, "k1", mustDecodeHex(t, secretKey), []byte("lorem ipsum")),
+	} {
+		// when
+		_, err := underTest.Decrypt(unbound, []byte("additional data"))
+
+		// then
+		assert.ErrorIs(t, err, ErrAuthenticationFailed, unbound)
+	}
+
+	actual, err := underTest.Decrypt(bound, []byte("additional data"))
+	require.Nil(t, err)
+	assert.Equal(t, "lorem ipsum", string(actual))
+}
+
+func TestDecrypt_WithUnknownKeyID(t *testing.T) {
+	// given
+	ciphertext, err := NewAesGcm256CryptoProvider(newKeyring(t, "k1", secretKey)).Encrypt([]byte("lorem ipsum"), nil)
+	require.Nil(t, err)
+
+	underTest := NewAesGcm256CryptoProvider(newKeyring(t, "k2", secretKey))
+
+	// when
+	actual, err := underTest.Decrypt(ciphertext, nil)
+
+	// then
+	assert.ErrorIs(t, err, ErrUnknownKeyID)
+	assert.Equal(t, "unknown-key-id", DecryptionErrorReason(err))
+	assert.Nil(t, actual)
+}
+
+func TestDecrypt_WithDecryptOnlyKeyAfterRotation(t *testing.T) {
+	// given
 	expected := "lorem ipsum"
 
+	ciphertext, err := NewAesGcm256CryptoProvider(newKeyring(t, "k1", secretKey)).Encrypt([]byte(expected), nil)
+	require.Nil(t, err)
+
+	rotatedKeyring := newKeyring(t, "k2", anotherSecretKey)
+	require.Nil(t, rotatedKeyring.AddDecryptOnlyKey("k1", mustDecodeHex(t, secretKey)))
+
+	underTest := NewAesGcm256CryptoProvider(rotatedKeyring)
+
 	// when
-	ciphertext, err := underTest.Encrypt([]byte(expected))
+	actual, err := underTest.Decrypt(ciphertext, nil)
 	require.Nil(t, err)
 
-	actual, err := underTest.Decrypt(ciphertext)
+	reencrypted, err := underTest.Encrypt(actual, nil)
 	require.Nil(t, err)
 
 	// then
 	assert.Equal(t, expected, string(actual))
+	assert.True(t, strings.HasPrefix(reencrypted, "v2:k2:"))
+}
+
+func TestDecrypt_LegacyFormatAfterRotation(t *testing.T) {
+	// given
+	expected := "lorem ipsum"
+	legacyCiphertext := encryptLegacy(t, secretKey, []byte(expected))
+
+	rotatedKeyring := newKeyring(t, "k2", anotherSecretKey)
+	require.Nil(t, rotatedKeyring.AddDecryptOnlyKey("k1", mustDecodeHex(t, secretKey)))
+	require.Nil(t, rotatedKeyring.SetLegacyKeyID("k1"))
+
+	underTest := NewAesGcm256CryptoProvider(rotatedKeyring)
+
+	// when
+	actual, err := underTest.Decrypt(legacyCiphertext, nil)
+	require.Nil(t, err)
+
+	// then
+	assert.Equal(t, expected, string(actual))
+}
+
+func TestDecrypt_WithMalformedCiphertext(t *testing.T) {
+	underTest := NewAesGcm256CryptoProvider(newKeyring(t, "k1", secretKey))
+
+	for _, malformed := range []string{"", "abc", "-", "00-", "zz-zz", "00-00-00", "v1:k1:aes-256-gcm:00",
+		"v1:k1:aes-256-gcm:zz:00", "v3:k1:aes-256-gcm:00:00", "v2:k1:rot13:00:00", "v2:k1:aes-256-gcm:00:00"} {
+		actual, err := underTest.Decrypt(malformed, nil)
+
+		assert.ErrorIs(t, err, ErrMalformedCiphertext, malformed)
+		assert.Equal(t, "malformed-ciphertext", DecryptionErrorReason(err), malformed)
+		assert.Nil(t, actual, malformed)
+	}
+}
+
+func TestDecrypt_WithTamperedCiphertext(t *testing.T) {
+	// given
+	underTest := NewAesGcm256CryptoProvider(newKeyring(t, "k1", secretKey))
+
+	ciphertext, err := underTest.Encrypt([]byte("lorem ipsum"), []byte("transactions/1/user_document"))
+	require.Nil(t, err)
+
+	// when
+	actual, err := underTest.Decrypt(ciphertext, []byte("transactions/2/user_document"))
+
+	// then
+	assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	assert.Equal(t, "authentication-failed", DecryptionErrorReason(err))
+	assert.Nil(t, actual)
+}
+
+func TestEncryptAndDecrypt_Concurrently(t *testing.T) {
+	// given
+	underTest := NewAesGcm256CryptoProvider(newKeyring(t, "k1", secretKey))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+
+	// when
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+
+		go func(expected string) {
+			defer wg.Done()
+
+			ciphertext, err := underTest.Encrypt([]byte(expected), nil)
+			if err == nil {
+				var actual []byte
+				if actual, err = underTest.Decrypt(ciphertext, nil); err == nil && string(actual) != expected {
+					err = fmt.Errorf("expected %q, got %q", expected, actual)
+				}
+			}
+
+			errs <- err
+		}(fmt.Sprintf("lorem ipsum %d", i))
+	}
+
+	wg.Wait()
+	close(errs)
+
+	// then
+	for err := range errs {
+		assert.Nil(t, err)
+	}
+}
+
+func newKeyring(t testing.TB, primaryID, primaryKey string) *Keyring {
+	return NewKeyring(primaryID, mustDecodeHex(t, primaryKey))
+}
+
+func mustDecodeHex(t testing.TB, toDecode string) []byte {
+	decoded, err := hex.DecodeString(toDecode)
+	require.Nil(t, err)
+
+	return decoded
+}
+
+func encryptLegacy(t *testing.T, secretKey string, toEncrypt []byte) string {
+	block, err := aes.NewCipher(mustDecodeHex(t, secretKey))
+	require.Nil(t, err)
+
+	aesgcm, err := cipher.NewGCM(block)
+	require.Nil(t, err)
+
+	nonce := make([]byte, aesgcm.NonceSize())
+
+	return fmt.Sprintf("%x-%x", nonce, aesgcm.Seal(nil, nonce, toEncrypt, nil))
+}
+
+func encryptV1(t testing.TB, keyID string, key, toEncrypt []byte) string {
+	aead, err := newAead(AlgorithmAesGcm256, key)
+	require.Nil(t, err)
+
+	nonce := make([]byte, aead.NonceSize())
+
+	envelope := &Envelope{
+		Version:    EnvelopeVersion1,
+		KeyID:      keyID,
+		Algorithm:  AlgorithmAesGcm256,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, toEncrypt, nil),
+	}
+
+	return envelope.String()
 }
@@ -0,0 +1,163 @@
+package providers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// X25519AesGcmCryptoProvider encrypts each value with AES-256-GCM under a key
+// agreed between a fresh ephemeral X25519 key and the recipient's public key,
+// so encrypting only takes the public key while decrypting takes the private
+// one. The envelope's nonce holds the ephemeral public key followed by the
+// AES-GCM nonce.
+type X25519AesGcmCryptoProvider struct {
+	publicKeyID string
+	publicKey   *ecdh.PublicKey
+	privateKeys map[string]*ecdh.PrivateKey
+}
+
+func NewX25519AesGcmCryptoProvider(publicKeyID string, publicKey []byte) (*X25519AesGcmCryptoProvider, error) {
+	parsedPublicKey, err := ecdh.X25519().NewPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &X25519AesGcmCryptoProvider{publicKeyID, parsedPublicKey, make(map[string]*ecdh.PrivateKey)}, nil
+}
+
+// AddPrivateKey lets the provider decrypt what was encrypted under the
+// public key matching the private one, identified by the given ID.
+func (cp *X25519AesGcmCryptoProvider) AddPrivateKey(id string, privateKey []byte) error {
+	parsedPrivateKey, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	if id == cp.publicKeyID && !parsedPrivateKey.PublicKey().Equal(cp.publicKey) {
+		return fmt.Errorf("private key %q doesn't match the public key", id)
+	}
+
+	cp.privateKeys[id] = parsedPrivateKey
+
+	return nil
+}
+
+func (cp *X25519AesGcmCryptoProvider) Encrypt(toEncrypt, additionalData []byte) (string, error) {
+	ephemeralKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	sharedSecret, err := ephemeralKey.ECDH(cp.publicKey)
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	aesgcm, err := newX25519AesGcm(sharedSecret, ephemeralKey.PublicKey(), cp.publicKey)
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	envelope := &Envelope{
+		Version:    EnvelopeVersion2,
+		KeyID:      cp.publicKeyID,
+		Algorithm:  AlgorithmX25519AesGcm256,
+		Nonce:      append(ephemeralKey.PublicKey().Bytes(), nonce...),
+		Ciphertext: aesgcm.Seal(nil, nonce, toEncrypt, additionalData),
+	}
+
+	return envelope.String(), nil
+}
+
+func (cp *X25519AesGcmCryptoProvider) Decrypt(toDecrypt string, additionalData []byte) ([]byte, error) {
+	envelope, err := ParseEnvelope(toDecrypt)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	if envelope.Version != EnvelopeVersion2 || envelope.Algorithm != AlgorithmX25519AesGcm256 {
+		err := fmt.Errorf("%w: unsupported algorithm %q", ErrMalformedCiphertext, envelope.Algorithm)
+		log.Println(err)
+		return nil, err
+	}
+
+	privateKey, ok := cp.privateKeys[envelope.KeyID]
+	if !ok {
+		err := fmt.Errorf("%w %q, encryption only", ErrUnknownKeyID, envelope.KeyID)
+		log.Println(err)
+		return nil, err
+	}
+
+	ephemeralKeySize := len(privateKey.PublicKey().Bytes())
+
+	if len(envelope.Nonce) != ephemeralKeySize+12 {
+		err := fmt.Errorf("%w: invalid nonce size %d", ErrMalformedCiphertext, len(envelope.Nonce))
+		log.Println(err)
+		return nil, err
+	}
+
+	ephemeralPublicKey, err := ecdh.X25519().NewPublicKey(envelope.Nonce[:ephemeralKeySize])
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrMalformedCiphertext, err)
+		log.Println(err)
+		return nil, err
+	}
+
+	sharedSecret, err := privateKey.ECDH(ephemeralPublicKey)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrMalformedCiphertext, err)
+		log.Println(err)
+		return nil, err
+	}
+
+	aesgcm, err := newX25519AesGcm(sharedSecret, ephemeralPublicKey, privateKey.PublicKey())
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	decrypted, err := aesgcm.Open(nil, envelope.Nonce[ephemeralKeySize:], envelope.Ciphertext, additionalData)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrAuthenticationFailed, err)
+		log.Println(err)
+		return nil, err
+	}
+
+	return decrypted, nil
+}
+
+// newX25519AesGcm derives the AES-GCM key from the X25519 shared secret with
+// HKDF-SHA256, salted with both public keys involved.
+func newX25519AesGcm(sharedSecret []byte, ephemeralPublicKey, recipientPublicKey *ecdh.PublicKey) (cipher.AEAD, error) {
+	salt := append(ephemeralPublicKey.Bytes(), recipientPublicKey.Bytes()...)
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, salt, []byte(AlgorithmX25519AesGcm256)), key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,87 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	x25519PrivateKey = "a895d83339c3536df9fcffa344cc5ac74ef5c23bbfe3944344fd355c53b9c977"
+	x25519PublicKey  = "358cb34610e8131b1e5874b38d689a06eb195b7dd16bae3a16516f7ddf0aee2b"
+)
+
+func TestX25519AesGcmEncryptAndDecrypt(t *testing.T) {
+	// given
+	encryptOnly := newX25519AesGcmCryptoProvider(t)
+
+	underTest := newX25519AesGcmCryptoProvider(t)
+	require.Nil(t, underTest.AddPrivateKey("pk1", mustDecodeHex(t, x25519PrivateKey)))
+
+	expected := "lorem ipsum"
+
+	// when
+	ciphertext, err := encryptOnly.Encrypt([]byte(expected), []byte("additional data"))
+	require.Nil(t, err)
+
+	actual, err := underTest.Decrypt(ciphertext, []byte("additional data"))
+	require.Nil(t, err)
+
+	// then
+	envelope, err := ParseEnvelope(ciphertext)
+	require.Nil(t, err)
+
+	assert.Equal(t, expected, string(actual))
+	assert.Equal(t, AlgorithmX25519AesGcm256, envelope.Algorithm)
+	assert.Equal(t, "pk1", envelope.KeyID)
+}
+
+func TestX25519AesGcmDecrypt_WithoutPrivateKey(t *testing.T) {
+	// given
+	underTest := newX25519AesGcmCryptoProvider(t)
+
+	ciphertext, err := underTest.Encrypt([]byte("lorem ipsum"), nil)
+	require.Nil(t, err)
+
+	// when
+	actual, err := underTest.Decrypt(ciphertext, nil)
+
+	// then
+	assert.NotNil(t, err)
+	assert.Nil(t, actual)
+}
+
+func TestX25519AesGcmAddPrivateKey_NotMatchingPublicKey(t *testing.T) {
+	// given
+	underTest := newX25519AesGcmCryptoProvider(t)
+
+	// when
+	err := underTest.AddPrivateKey("pk1", mustDecodeHex(t, secretKey))
+
+	// then
+	assert.NotNil(t, err)
+}
+
+func TestPublicKeyKeyManagementService_EncryptOnly(t *testing.T) {
+	// given
+	underTest := NewStandardTransactionCryptoProvider(
+		NewPublicKeyKeyManagementService(newX25519AesGcmCryptoProvider(t)), newBlindIndex(t), AlgorithmAesGcm256)
+
+	transaction := newTransaction()
+
+	// when
+	require.Nil(t, underTest.Encrypt(transaction))
+	err := underTest.Decrypt(transaction)
+
+	// then
+	assert.NotNil(t, err)
+	assert.True(t, isEnvelopeOn(t, transaction.DataKey, "pk1"))
+}
+
+func newX25519AesGcmCryptoProvider(t *testing.T) *X25519AesGcmCryptoProvider {
+	cp, err := NewX25519AesGcmCryptoProvider("pk1", mustDecodeHex(t, x25519PublicKey))
+	require.Nil(t, err)
+
+	return cp
+}
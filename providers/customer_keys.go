@@ -0,0 +1,156 @@
+package providers
+
+import (
+	"crypto-challenge/database/repositories"
+	"crypto-challenge/entities"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+)
+
+const (
+	// CustomerKeyID is the key ID of the data keys wrapped by their
+	// customer's key.
+	CustomerKeyID = "customer"
+
+	customerKeysTable = "customer_keys"
+)
+
+// ErrErased is returned when decrypting data whose customer was forgotten:
+// their key was destroyed, so it can't be decrypted anymore.
+var ErrErased = errors.New("erased at the customer's request")
+
+// CustomerKeys gives each customer an X25519 key pair of their own, wrapping
+// the data keys of their transactions, so destroying a customer's key makes
+// all of them unreadable. Wrapping only takes the public key, the private key
+// is stored wrapped by the KMS: a backup holding the customer's key can still
+// be read with the master key, so destroying it doesn't reach the backups.
+type CustomerKeys struct {
+	repository repositories.CustomerKeyRepository
+	kms        KeyManagementService
+}
+
+func NewCustomerKeys(repository repositories.CustomerKeyRepository, kms KeyManagementService) *CustomerKeys {
+	return &CustomerKeys{repository, kms}
+}
+
+// WrappingService returns a KMS wrapping data keys with the customer's key,
+// which is created on first use. It can't unwrap them.
+func (ck *CustomerKeys) WrappingService(userDocumentIndex string) (KeyManagementService, error) {
+	customerKey, err := ck.repository.FindByUserDocumentIndex(userDocumentIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	if customerKey == nil {
+		if customerKey, err = ck.create(userDocumentIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	cp, err := ck.cryptoProvider(customerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPublicKeyKeyManagementService(cp), nil
+}
+
+// UnwrappingService returns a KMS unwrapping the data keys wrapped with the
+// customer's key, or ErrErased when the customer was forgotten.
+func (ck *CustomerKeys) UnwrappingService(userDocumentIndex string) (KeyManagementService, error) {
+	customerKey, err := ck.repository.FindByUserDocumentIndex(userDocumentIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	if customerKey == nil {
+		return nil, ErrErased
+	}
+
+	cp, err := ck.cryptoProvider(customerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := ck.kms.UnwrapKey(customerKey.PrivateKey, customerKeyAdditionalData(userDocumentIndex))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cp.AddPrivateKey(CustomerKeyID, privateKey); err != nil {
+		return nil, err
+	}
+
+	return NewPublicKeyKeyManagementService(cp), nil
+}
+
+// Rewrap wraps the customer's private key again under the current master
+// key.
+func (ck *CustomerKeys) Rewrap(customerKey *entities.CustomerKey) error {
+	additionalData := customerKeyAdditionalData(customerKey.UserDocumentIndex)
+
+	privateKey, err := ck.kms.UnwrapKey(customerKey.PrivateKey, additionalData)
+	if err != nil {
+		return err
+	}
+
+	wrappedPrivateKey, err := ck.kms.WrapKey(privateKey, additionalData)
+	if err != nil {
+		return err
+	}
+
+	customerKey.PrivateKey = wrappedPrivateKey
+
+	return nil
+}
+
+// create stores a new key for the customer, it returns the one created in
+// the meantime by a concurrent request, if any.
+func (ck *CustomerKeys) create(userDocumentIndex string) (*entities.CustomerKey, error) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedPrivateKey, err := ck.kms.WrapKey(privateKey.Bytes(), customerKeyAdditionalData(userDocumentIndex))
+	if err != nil {
+		return nil, err
+	}
+
+	newCustomerKey := &entities.CustomerKey{
+		UserDocumentIndex: userDocumentIndex,
+		PublicKey:         hex.EncodeToString(privateKey.PublicKey().Bytes()),
+		PrivateKey:        wrappedPrivateKey,
+	}
+
+	if err := ck.repository.Create(newCustomerKey); err != nil {
+		return nil, err
+	}
+
+	customerKey, err := ck.repository.FindByUserDocumentIndex(userDocumentIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Forgotten between both queries.
+	if customerKey == nil {
+		return nil, ErrErased
+	}
+
+	return customerKey, nil
+}
+
+func (ck *CustomerKeys) cryptoProvider(customerKey *entities.CustomerKey) (*X25519AesGcmCryptoProvider, error) {
+	publicKey, err := hex.DecodeString(customerKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewX25519AesGcmCryptoProvider(CustomerKeyID, publicKey)
+}
+
+func customerKeyAdditionalData(userDocumentIndex string) []byte {
+	return []byte(customerKeysTable + "/" + userDocumentIndex + "/private_key")
+}
@@ -0,0 +1,175 @@
+package providers
+
+import (
+	"crypto-challenge/entities"
+	"crypto-challenge/mocks/crypto-challenge/database/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptTransaction_WithCustomerKeys(t *testing.T) {
+	// given
+	customerKeys := newCustomerKeyRepository()
+	underTest := newStandardTransactionCryptoProviderWithCustomerKeys(t, customerKeys)
+
+	expected := newTransaction()
+	transaction := *expected
+
+	// when
+	require.Nil(t, underTest.Encrypt(&transaction))
+
+	// then
+	dataKeyEnvelope, err := ParseEnvelope(transaction.DataKey)
+	require.Nil(t, err)
+	assert.Equal(t, CustomerKeyID, dataKeyEnvelope.KeyID)
+	assert.Equal(t, AlgorithmX25519AesGcm256, dataKeyEnvelope.Algorithm)
+
+	customerKey := customerKeys[transaction.UserDocumentIndex]
+	require.NotNil(t, customerKey)
+	assert.True(t, isEnvelopeOn(t, customerKey.PrivateKey, "k1"))
+
+	require.Nil(t, underTest.Decrypt(&transaction))
+	assert.Equal(t, *expected, transaction)
+}
+
+func TestEncryptTransaction_ReusesCustomerKey(t *testing.T) {
+	// given
+	customerKeys := newCustomerKeyRepository()
+	underTest := newStandardTransactionCryptoProviderWithCustomerKeys(t, customerKeys)
+
+	first, second := newTransaction(), newTransaction()
+
+	// when
+	require.Nil(t, underTest.Encrypt(first))
+	require.Nil(t, underTest.Encrypt(second))
+
+	// then
+	assert.Len(t, customerKeys, 1)
+}
+
+func TestDecryptTransaction_WithDestroyedCustomerKey(t *testing.T) {
+	// given
+	customerKeys := newCustomerKeyRepository()
+	underTest := newStandardTransactionCryptoProviderWithCustomerKeys(t, customerKeys)
+
+	transaction := newTransaction()
+	require.Nil(t, underTest.Encrypt(transaction))
+
+	delete(customerKeys, transaction.UserDocumentIndex)
+
+	// when
+	err := underTest.Decrypt(transaction)
+
+	// then
+	assert.ErrorIs(t, err, ErrErased)
+}
+
+func TestDecryptTransaction_Blanked(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProviderWithCustomerKeys(t, newCustomerKeyRepository())
+
+	transaction := &entities.Transaction{ID: newTransaction().ID, Value: 1299.80}
+
+	// when
+	err := underTest.Decrypt(transaction)
+
+	// then
+	assert.ErrorIs(t, err, ErrErased)
+}
+
+func TestRewrapDataKey_MovesUnderCustomerKey(t *testing.T) {
+	// given
+	expected := newTransaction()
+
+	transaction := *expected
+	require.Nil(t, newStandardTransactionCryptoProvider(t).Encrypt(&transaction))
+
+	underTest := newStandardTransactionCryptoProviderWithCustomerKeys(t, newCustomerKeyRepository())
+
+	// when
+	require.Nil(t, underTest.RewrapDataKey(&transaction))
+
+	// then
+	assert.True(t, isEnvelopeOn(t, transaction.DataKey, CustomerKeyID))
+
+	require.Nil(t, underTest.Decrypt(&transaction))
+	assert.Equal(t, *expected, transaction)
+}
+
+func TestEncryptTransaction_WithCustomerKeysAndEncryptOnlyKms(t *testing.T) {
+	// given
+	kms := NewPublicKeyKeyManagementService(newX25519AesGcmCryptoProvider(t))
+	underTest := NewStandardTransactionCryptoProvider(kms, newBlindIndex(t), AlgorithmAesGcm256)
+	underTest.UseCustomerKeys(NewCustomerKeys(mockCustomerKeyRepository(t, newCustomerKeyRepository()), kms))
+
+	transaction := newTransaction()
+
+	// when
+	err := underTest.Encrypt(transaction)
+
+	// then
+	require.Nil(t, err)
+	assert.True(t, isEnvelopeOn(t, transaction.DataKey, CustomerKeyID))
+}
+
+func TestCustomerKeysRewrap(t *testing.T) {
+	// given
+	customerKeys := newCustomerKeyRepository()
+	require.Nil(t, newStandardTransactionCryptoProviderWithCustomerKeys(t, customerKeys).Encrypt(newTransaction()))
+
+	rotatedKeyring := newKeyring(t, "k2", anotherSecretKey)
+	require.Nil(t, rotatedKeyring.AddDecryptOnlyKey("k1", mustDecodeHex(t, secretKey)))
+	underTest := NewCustomerKeys(mockCustomerKeyRepository(t, customerKeys),
+		NewLocalKeyManagementService(rotatedKeyring, AlgorithmAesGcm256))
+
+	var customerKey entities.CustomerKey
+	for _, stored := range customerKeys {
+		customerKey = *stored
+	}
+
+	// when
+	err := underTest.Rewrap(&customerKey)
+
+	// then
+	require.Nil(t, err)
+	assert.True(t, isEnvelopeOn(t, customerKey.PrivateKey, "k2"))
+}
+
+// customerKeyRepository stands in for the customer_keys table.
+type customerKeyRepository map[string]*entities.CustomerKey
+
+func newCustomerKeyRepository() customerKeyRepository {
+	return make(customerKeyRepository)
+}
+
+func mockCustomerKeyRepository(t *testing.T, customerKeys customerKeyRepository) *repositories.MockCustomerKeyRepository {
+	repositoryMock := repositories.NewMockCustomerKeyRepository(t)
+
+	repositoryMock.EXPECT().FindByUserDocumentIndex(mock.Anything).RunAndReturn(
+		func(userDocumentIndex string) (*entities.CustomerKey, error) {
+			return customerKeys[userDocumentIndex], nil
+		}).Maybe()
+
+	repositoryMock.EXPECT().Create(mock.Anything).RunAndReturn(func(newCustomerKey *entities.CustomerKey) error {
+		if _, ok := customerKeys[newCustomerKey.UserDocumentIndex]; !ok {
+			customerKeys[newCustomerKey.UserDocumentIndex] = newCustomerKey
+		}
+
+		return nil
+	}).Maybe()
+
+	return repositoryMock
+}
+
+func newStandardTransactionCryptoProviderWithCustomerKeys(t *testing.T,
+	customerKeys customerKeyRepository) *StandardTransactionCryptoProvider {
+	kms := NewLocalKeyManagementService(newKeyring(t, "k1", secretKey), AlgorithmAesGcm256)
+
+	underTest := NewStandardTransactionCryptoProvider(kms, newBlindIndex(t), AlgorithmAesGcm256)
+	underTest.UseCustomerKeys(NewCustomerKeys(mockCustomerKeyRepository(t, customerKeys), kms))
+
+	return underTest
+}
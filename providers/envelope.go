@@ -0,0 +1,155 @@
+package providers
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	EnvelopeVersionLegacy = ""
+	EnvelopeVersion1      = "v1"
+	// EnvelopeVersion2 ciphertexts authenticate the additional data given on
+	// encryption, legacy and version 1 ones were sealed without any.
+	EnvelopeVersion2 = "v2"
+
+	AlgorithmAesGcm256        = "aes-256-gcm"
+	AlgorithmChaCha20Poly1305 = "chacha20-poly1305"
+	// AlgorithmXChaCha20Poly1305 takes 192-bit nonces, which can be drawn at
+	// random for many more messages under the same key than AES-GCM's 96-bit ones.
+	AlgorithmXChaCha20Poly1305 = "xchacha20-poly1305"
+	// AlgorithmAesSiv is deterministic: the envelope's nonce holds the
+	// synthetic IV computed from the plaintext.
+	AlgorithmAesSiv = "aes-siv"
+	// AlgorithmX25519AesGcm256 is hybrid public-key encryption: the envelope's
+	// nonce holds the ephemeral X25519 public key and the AES-GCM nonce.
+	AlgorithmX25519AesGcm256 = "x25519-aes-256-gcm"
+
+	envelopeSeparator = ":"
+)
+
+// The errors decrypting can fail with because of the stored ciphertext, they
+// are wrapped with the details of each failure.
+var (
+	// ErrMalformedCiphertext is returned for ciphertexts that can't be parsed,
+	// or whose algorithm or nonce don't fit.
+	ErrMalformedCiphertext = errors.New("malformed ciphertext")
+	// ErrUnknownKeyID is returned for ciphertexts encrypted under a key that
+	// isn't available anymore, or never was.
+	ErrUnknownKeyID = errors.New("unknown key ID")
+	// ErrAuthenticationFailed is returned for ciphertexts that were tampered
+	// with, or moved to another row or column.
+	ErrAuthenticationFailed = errors.New("ciphertext authentication failed")
+)
+
+var errMalformedEnvelope = fmt.Errorf("%w: invalid envelope", ErrMalformedCiphertext)
+
+var decryptionErrorReasons = map[error]string{
+	ErrMalformedCiphertext:  "malformed-ciphertext",
+	ErrUnknownKeyID:         "unknown-key-id",
+	ErrAuthenticationFailed: "authentication-failed",
+}
+
+// DecryptionErrorReason names the typed decryption error wrapped by err, as
+// reported by the APIs, or returns "" when it wraps none.
+func DecryptionErrorReason(err error) string {
+	for decryptionErr, reason := range decryptionErrorReasons {
+		if errors.Is(err, decryptionErr) {
+			return reason
+		}
+	}
+
+	return ""
+}
+
+// decryptionErrorFromReason returns the typed decryption error named by the
+// reason, or nil for an unknown one.
+func decryptionErrorFromReason(reason string) error {
+	for decryptionErr, decryptionErrReason := range decryptionErrorReasons {
+		if reason == decryptionErrReason {
+			return decryptionErr
+		}
+	}
+
+	return nil
+}
+
+// Envelope is the self-describing form in which ciphertexts are stored:
+// "<version>:<key id>:<algorithm>:<hex nonce>:<hex ciphertext>".
+//
+// Values written before the envelope existed have the "<hex nonce>-<hex ciphertext>"
+// format, they are parsed with EnvelopeVersionLegacy and no key ID nor algorithm.
+type Envelope struct {
+	Version    string
+	KeyID      string
+	Algorithm  string
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+func (e *Envelope) String() string {
+	return strings.Join([]string{
+		e.Version,
+		e.KeyID,
+		e.Algorithm,
+		hex.EncodeToString(e.Nonce),
+		hex.EncodeToString(e.Ciphertext),
+	}, envelopeSeparator)
+}
+
+func ParseEnvelope(toParse string) (*Envelope, error) {
+	if !strings.Contains(toParse, envelopeSeparator) {
+		return parseLegacyEnvelope(toParse)
+	}
+
+	parts := strings.Split(toParse, envelopeSeparator)
+	if len(parts) != 5 {
+		return nil, errMalformedEnvelope
+	}
+
+	nonce, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return nil, errMalformedEnvelope
+	}
+
+	ciphertext, err := hex.DecodeString(parts[4])
+	if err != nil {
+		return nil, errMalformedEnvelope
+	}
+
+	if parts[0] != EnvelopeVersion1 && parts[0] != EnvelopeVersion2 {
+		return nil, fmt.Errorf("%w: unsupported envelope version %q", ErrMalformedCiphertext, parts[0])
+	}
+
+	return &Envelope{
+		Version:    parts[0],
+		KeyID:      parts[1],
+		Algorithm:  parts[2],
+		Nonce:      nonce,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+func parseLegacyEnvelope(toParse string) (*Envelope, error) {
+	nonceWithCiphertextSplitted := strings.Split(toParse, "-")
+	if len(nonceWithCiphertextSplitted) != 2 {
+		return nil, errMalformedEnvelope
+	}
+
+	nonce, err := hex.DecodeString(nonceWithCiphertextSplitted[0])
+	if err != nil {
+		return nil, errMalformedEnvelope
+	}
+
+	ciphertext, err := hex.DecodeString(nonceWithCiphertextSplitted[1])
+	if err != nil {
+		return nil, errMalformedEnvelope
+	}
+
+	return &Envelope{
+		Version:    EnvelopeVersionLegacy,
+		Nonce:      nonce,
+		Ciphertext: ciphertext,
+	}, nil
+}
@@ -0,0 +1,535 @@
+package providers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	encryptTag = "encrypt"
+
+	encryptTagID            = "id"
+	encryptTagDataKey       = "data-key"
+	encryptTagAead          = "aead"
+	encryptTagAesGcm        = "aes-gcm"
+	encryptTagDeterministic = "deterministic"
+	encryptTagBlindIndex    = "blind-index"
+	encryptTagOmitEmpty     = "omitempty"
+
+	// DataKeyID is the key ID of an entity's own data key, recorded in the
+	// envelope of its fields as DerivedKeyID(DataKeyID, <field>) since each
+	// field is encrypted under a subkey of its own.
+	DataKeyID = "dek"
+
+	dataKeyField = "data_key"
+)
+
+// FieldEncryptor encrypts the string fields of an entity as declared by their
+// "encrypt" struct tags:
+//
+//	ID                string `encrypt:"id"`
+//	DataKey           string `encrypt:"data-key"`
+//	UserDocument      string `encrypt:"aead,blind-index=UserDocumentIndex"`
+//	CreditCardToken   string `encrypt:"deterministic"`
+//	Note              string `encrypt:"aead,omitempty"`
+//	UserDocumentIndex string
+//
+// Each entity gets a random data key of its own, stored wrapped by the KMS
+// master key in its "data-key" field, and each "aead" field is encrypted under
+// a subkey of it, bound to the table, the "id" field and the field's
+// snake_case name, with the configured algorithm, or with AES-256-GCM whatever
+// the configured one for the "aes-gcm" fields. The "deterministic" fields are encrypted with AES-SIV
+// instead, "blind-index" stores the field's blind index, under a subkey of the
+// table and field, in the named field and "omitempty" leaves the field empty,
+// rather than encrypting it, when empty.
+type FieldEncryptor struct {
+	kms        KeyManagementService
+	blindIndex *HmacSha256BlindIndex
+	algorithm  string
+
+	deterministicKeyring  *Keyring
+	deterministicFields   map[string]bool
+	blindIndexNormalizers map[string]func(string) string
+	rejectUnbound         bool
+
+	structs sync.Map
+	// blindIndexes holds the blind index of each table and field, under a
+	// subkey of the blind index key.
+	blindIndexes sync.Map
+	// deterministicCryptoProviders holds the provider of each deterministic
+	// field, shared by every entity since they don't depend on its data key.
+	deterministicCryptoProviders sync.Map
+}
+
+// entityDataKey is an entity's data key, the subkeys of its fields are
+// derived from it. The HKDF pseudorandom key is extracted once per entity, but
+// the subkeys and their AEADs can't be shared with any other entity, each
+// having a data key of its own.
+type entityDataKey struct {
+	key     []byte
+	deriver *keyDeriver
+}
+
+func newEntityDataKey(key []byte) *entityDataKey {
+	return &entityDataKey{key, newKeyDeriver(key)}
+}
+
+type encryptedStruct struct {
+	id      int
+	dataKey int
+	fields  []encryptedField
+}
+
+type encryptedField struct {
+	index         int
+	name          string
+	deterministic bool
+	// algorithm is the one the field is encrypted with, the configured one
+	// when empty.
+	algorithm  string
+	blindIndex int
+	omitEmpty  bool
+}
+
+func NewFieldEncryptor(kms KeyManagementService, blindIndex *HmacSha256BlindIndex, algorithm string) *FieldEncryptor {
+	return &FieldEncryptor{
+		kms:                   kms,
+		blindIndex:            blindIndex,
+		algorithm:             algorithm,
+		deterministicFields:   make(map[string]bool),
+		blindIndexNormalizers: make(map[string]func(string) string),
+	}
+}
+
+// EncryptDeterministically has the given fields encrypted with AES-SIV under
+// subkeys of the keyring's keys, on top of the ones tagged as deterministic.
+// The keyring also decrypts the deterministic fields.
+func (fe *FieldEncryptor) EncryptDeterministically(keyring *Keyring, fields ...string) {
+	fe.deterministicKeyring = keyring
+	fe.deterministicCryptoProviders = sync.Map{}
+
+	for _, field := range fields {
+		fe.deterministicFields[field] = true
+	}
+}
+
+// NormalizeBlindIndex has the field's values normalized before their blind
+// index is computed, so different spellings of a value can be matched.
+func (fe *FieldEncryptor) NormalizeBlindIndex(field string, normalize func(string) string) {
+	fe.blindIndexNormalizers[field] = normalize
+}
+
+// RejectUnboundCiphertexts stops decrypting the fields sealed before envelope
+// version 2 under the data keys, see AeadCryptoProvider. The ones under the
+// master key are up to its KMS.
+func (fe *FieldEncryptor) RejectUnboundCiphertexts() {
+	fe.rejectUnbound = true
+}
+
+// BlindIndex computes the blind index of a value of the table's field, under
+// a subkey of the blind index key derived for them.
+func (fe *FieldEncryptor) BlindIndex(table, field, value string) string {
+	if normalize, ok := fe.blindIndexNormalizers[field]; ok {
+		value = normalize(value)
+	}
+
+	return fe.fieldBlindIndex(table, field).Compute(value)
+}
+
+// fieldBlindIndex returns the blind index of the table's field, deriving its
+// subkey on first use.
+func (fe *FieldEncryptor) fieldBlindIndex(table, field string) *HmacSha256BlindIndex {
+	cacheKey := table + "/" + field
+
+	if blindIndex, ok := fe.blindIndexes.Load(cacheKey); ok {
+		return blindIndex.(*HmacSha256BlindIndex)
+	}
+
+	// HKDF-SHA256 only fails to expand past 8160 bytes, the subkeys are 32.
+	blindIndex, err := fe.blindIndex.Derive(table, field)
+	if err != nil {
+		panic(err)
+	}
+
+	cached, _ := fe.blindIndexes.LoadOrStore(cacheKey, blindIndex)
+
+	return cached.(*HmacSha256BlindIndex)
+}
+
+// Encrypt encrypts the fields of the entity, a pointer to a tagged struct,
+// that is stored in the given table.
+func (fe *FieldEncryptor) Encrypt(table string, entity any) error {
+	return fe.EncryptWith(fe.kms, table, entity)
+}
+
+// EncryptWith encrypts the entity like Encrypt, but has its data key wrapped
+// by the given KMS instead of the master key.
+func (fe *FieldEncryptor) EncryptWith(kms KeyManagementService, table string, entity any) error {
+	v, es, err := fe.describe(entity)
+	if err != nil {
+		return err
+	}
+
+	id := v.Field(es.id).String()
+
+	dataKey, wrappedDataKey, err := kms.GenerateDataKey(fieldAdditionalData(table, id, dataKeyField))
+	if err != nil {
+		return err
+	}
+
+	entityKey := newEntityDataKey(dataKey)
+
+	for _, field := range es.fields {
+		value := v.Field(field.index).String()
+
+		if field.blindIndex >= 0 {
+			v.Field(field.blindIndex).SetString(fe.BlindIndex(table, field.name, value))
+		}
+
+		if field.omitEmpty && value == "" {
+			continue
+		}
+
+		encrypted, err := fe.encryptField(entityKey, table, id, field, value)
+		if err != nil {
+			return err
+		}
+
+		v.Field(field.index).SetString(encrypted)
+	}
+
+	v.Field(es.dataKey).SetString(wrappedDataKey)
+
+	return nil
+}
+
+func (fe *FieldEncryptor) Decrypt(table string, entity any) error {
+	return fe.DecryptWith(fe.kms, table, entity)
+}
+
+// DecryptWith decrypts the entity like Decrypt, but has its data key
+// unwrapped by the given KMS instead of the master key.
+func (fe *FieldEncryptor) DecryptWith(kms KeyManagementService, table string, entity any) error {
+	v, es, err := fe.describe(entity)
+	if err != nil {
+		return err
+	}
+
+	id := v.Field(es.id).String()
+
+	entityKey, err := fe.entityDataKey(kms, table, id, v.Field(es.dataKey).String())
+	if err != nil {
+		return err
+	}
+
+	decryptedValues := make([]string, len(es.fields))
+
+	for i, field := range es.fields {
+		ciphertext := v.Field(field.index).String()
+		if field.omitEmpty && ciphertext == "" {
+			continue
+		}
+
+		decrypted, err := fe.decryptField(entityKey, table, id, field, ciphertext)
+		if err != nil {
+			return err
+		}
+
+		decryptedValues[i] = string(decrypted)
+	}
+
+	// The entity is only touched once every field decrypted.
+	for i, field := range es.fields {
+		v.Field(field.index).SetString(decryptedValues[i])
+
+		if field.blindIndex >= 0 {
+			v.Field(field.blindIndex).SetString("")
+		}
+	}
+
+	v.Field(es.dataKey).SetString("")
+
+	return nil
+}
+
+// RewrapDataKey wraps the entity's data key again under the current master
+// key, leaving its encrypted fields untouched.
+func (fe *FieldEncryptor) RewrapDataKey(table string, entity any) error {
+	return fe.RewrapDataKeyWith(fe.kms, fe.kms, table, entity)
+}
+
+// RewrapDataKeyWith unwraps the entity's data key with one KMS and wraps it
+// again with another, leaving its encrypted fields untouched.
+func (fe *FieldEncryptor) RewrapDataKeyWith(from, to KeyManagementService, table string, entity any) error {
+	v, es, err := fe.describe(entity)
+	if err != nil {
+		return err
+	}
+
+	additionalData := fieldAdditionalData(table, v.Field(es.id).String(), dataKeyField)
+
+	dataKey, err := from.UnwrapKey(v.Field(es.dataKey).String(), additionalData)
+	if err != nil {
+		return err
+	}
+
+	wrappedDataKey, err := to.WrapKey(dataKey, additionalData)
+	if err != nil {
+		return err
+	}
+
+	v.Field(es.dataKey).SetString(wrappedDataKey)
+
+	return nil
+}
+
+func (fe *FieldEncryptor) encryptField(entityKey *entityDataKey, table, id string, field encryptedField, value string) (string, error) {
+	if field.deterministic || fe.deterministicFields[field.name] {
+		cp, err := fe.deterministicCryptoProvider(field.name)
+		if err != nil {
+			return "", err
+		}
+
+		return cp.Encrypt([]byte(value), deterministicFieldAdditionalData(table, field.name))
+	}
+
+	algorithm := field.algorithm
+	if algorithm == "" {
+		algorithm = fe.algorithm
+	}
+
+	cp, err := fe.fieldCryptoProvider(entityKey, field.name, DerivedKeyID(DataKeyID, field.name), algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	return cp.Encrypt([]byte(value), fieldAdditionalData(table, id, field.name))
+}
+
+// decryptField tells the deterministic ciphertexts apart by their algorithm,
+// so the fields keep decrypting after being switched in or out of the
+// deterministic ones.
+func (fe *FieldEncryptor) decryptField(entityKey *entityDataKey, table, id string, field encryptedField, ciphertext string) ([]byte, error) {
+	envelope, err := ParseEnvelope(ciphertext)
+	if err == nil && envelope.Algorithm == AlgorithmAesSiv {
+		cp, err := fe.deterministicCryptoProvider(field.name)
+		if err != nil {
+			return nil, err
+		}
+
+		return cp.Decrypt(ciphertext, deterministicFieldAdditionalData(table, field.name))
+	}
+
+	var keyID string
+	if err == nil {
+		keyID = envelope.KeyID
+	}
+
+	// The algorithm is the envelope's, the configured one only encrypts.
+	cp, err := fe.fieldCryptoProvider(entityKey, field.name, keyID, fe.algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	return cp.Decrypt(ciphertext, fieldAdditionalData(table, id, field.name))
+}
+
+// entityDataKey unwraps the entity's data key, it returns nil for the
+// entities from before data keys existed.
+func (fe *FieldEncryptor) entityDataKey(kms KeyManagementService, table, id, wrappedDataKey string) (*entityDataKey, error) {
+	if wrappedDataKey == "" {
+		return nil, nil
+	}
+
+	dataKey, err := kms.UnwrapKey(wrappedDataKey, fieldAdditionalData(table, id, dataKeyField))
+	if err != nil {
+		return nil, err
+	}
+
+	return newEntityDataKey(dataKey), nil
+}
+
+// fieldCryptoProvider returns the provider of the key the field is encrypted
+// under, given by its envelope: the field's subkey or, for the fields from
+// before subkeys, the data key itself, which takes no derivation. Without a
+// data key, the fields were encrypted straight under the master key. The
+// provider encrypts with the given algorithm.
+func (fe *FieldEncryptor) fieldCryptoProvider(entityKey *entityDataKey, field, keyID, algorithm string) (CryptoProvider, error) {
+	if entityKey == nil {
+		return &masterKeyCryptoProvider{fe.kms}, nil
+	}
+
+	keyring := NewKeyring(DataKeyID, entityKey.key)
+
+	if keyID != DataKeyID {
+		subkey, err := entityKey.deriver.derive(KeyPurposeEncryption, field)
+		if err != nil {
+			return nil, err
+		}
+
+		keyring = NewKeyring(DerivedKeyID(DataKeyID, field), subkey)
+	}
+
+	cp := NewAeadCryptoProvider(keyring, algorithm)
+	if fe.rejectUnbound {
+		cp.RejectUnboundCiphertexts()
+	}
+
+	return cp, nil
+}
+
+// deterministicCryptoProvider returns the field's provider, built on first
+// use. Concurrent first uses may build it more than once, only one of them is
+// kept.
+func (fe *FieldEncryptor) deterministicCryptoProvider(field string) (CryptoProvider, error) {
+	if fe.deterministicKeyring == nil {
+		return nil, fmt.Errorf("no deterministic key to encrypt the %s field", field)
+	}
+
+	if cp, ok := fe.deterministicCryptoProviders.Load(field); ok {
+		return cp.(CryptoProvider), nil
+	}
+
+	fieldKeyring, err := fe.deterministicKeyring.Derive(KeyPurposeDeterministicEncryption, field)
+	if err != nil {
+		return nil, err
+	}
+
+	cp, _ := fe.deterministicCryptoProviders.LoadOrStore(field, NewAesSivCryptoProvider(fieldKeyring))
+
+	return cp.(CryptoProvider), nil
+}
+
+// describe reads the "encrypt" tags of the entity's type, once per type.
+func (fe *FieldEncryptor) describe(entity any) (reflect.Value, *encryptedStruct, error) {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, nil, fmt.Errorf("can't encrypt a %T, expected a pointer to a struct", entity)
+	}
+
+	v = v.Elem()
+
+	if es, ok := fe.structs.Load(v.Type()); ok {
+		return v, es.(*encryptedStruct), nil
+	}
+
+	es, err := parseEncryptTags(v.Type())
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+
+	fe.structs.Store(v.Type(), es)
+
+	return v, es, nil
+}
+
+func parseEncryptTags(t reflect.Type) (*encryptedStruct, error) {
+	es := &encryptedStruct{id: -1, dataKey: -1}
+
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+
+		tag, ok := structField.Tag.Lookup(encryptTag)
+		if !ok {
+			continue
+		}
+
+		if structField.Type.Kind() != reflect.String {
+			return nil, fmt.Errorf("%s.%s: only string fields can be encrypted", t.Name(), structField.Name)
+		}
+
+		options := strings.Split(tag, ",")
+
+		switch options[0] {
+		case encryptTagID:
+			es.id = i
+		case encryptTagDataKey:
+			es.dataKey = i
+		case encryptTagAead, encryptTagAesGcm, encryptTagDeterministic:
+			field := encryptedField{
+				index:         i,
+				name:          toSnakeCase(structField.Name),
+				deterministic: options[0] == encryptTagDeterministic,
+				blindIndex:    -1,
+			}
+
+			if options[0] == encryptTagAesGcm {
+				field.algorithm = AlgorithmAesGcm256
+			}
+
+			for _, option := range options[1:] {
+				if option == encryptTagOmitEmpty {
+					field.omitEmpty = true
+					continue
+				}
+
+				name, value, _ := strings.Cut(option, "=")
+				if name != encryptTagBlindIndex {
+					return nil, fmt.Errorf("%s.%s: unknown encrypt option %q", t.Name(), structField.Name, option)
+				}
+
+				blindIndexField, ok := t.FieldByName(value)
+				if !ok || len(blindIndexField.Index) != 1 || blindIndexField.Type.Kind() != reflect.String {
+					return nil, fmt.Errorf("%s.%s: blind index field %q must be a string field", t.Name(), structField.Name, value)
+				}
+
+				field.blindIndex = blindIndexField.Index[0]
+			}
+
+			es.fields = append(es.fields, field)
+		default:
+			return nil, fmt.Errorf("%s.%s: unknown encrypt mode %q", t.Name(), structField.Name, options[0])
+		}
+	}
+
+	if es.id < 0 || es.dataKey < 0 {
+		return nil, fmt.Errorf("%s must have both an id and a data-key encrypt tag", t.Name())
+	}
+
+	return es, nil
+}
+
+func toSnakeCase(name string) string {
+	var b strings.Builder
+
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+
+			r = unicode.ToLower(r)
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// fieldAdditionalData binds a field's ciphertext to its row and column, so it
+// doesn't decrypt if copied anywhere else.
+func fieldAdditionalData(table, id, field string) []byte {
+	return []byte(table + "/" + id + "/" + field)
+}
+
+func deterministicFieldAdditionalData(table, field string) []byte {
+	return []byte(table + "/" + field)
+}
+
+// masterKeyCryptoProvider decrypts the fields written before data keys
+// existed: they are sealed like a wrapped key, so the KMS can unwrap them.
+type masterKeyCryptoProvider struct {
+	kms KeyManagementService
+}
+
+func (cp *masterKeyCryptoProvider) Encrypt(toEncrypt, additionalData []byte) (string, error) {
+	return cp.kms.WrapKey(toEncrypt, additionalData)
+}
+
+func (cp *masterKeyCryptoProvider) Decrypt(toDecrypt string, additionalData []byte) ([]byte, error) {
+	return cp.kms.UnwrapKey(toDecrypt, additionalData)
+}
@@ -0,0 +1,180 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type taggedCustomer struct {
+	ID         string `encrypt:"id"`
+	Email      string `encrypt:"deterministic,blind-index=EmailIndex"`
+	Phone      string `encrypt:"aead"`
+	Nickname   string `encrypt:"aead,omitempty"`
+	Name       string
+	DataKey    string `encrypt:"data-key"`
+	EmailIndex string
+}
+
+func TestFieldEncryptor_EncryptAndDecrypt(t *testing.T) {
+	// given
+	underTest := newFieldEncryptor(t)
+	underTest.EncryptDeterministically(newKeyring(t, "d1", aesSivKey))
+
+	expected := &taggedCustomer{ID: uuid.NewString(), Email: "john@example.com", Phone: "+55 11 91234-5678", Name: "John"}
+	actual, other := *expected, *expected
+	other.ID = uuid.NewString()
+
+	// when
+	require.Nil(t, underTest.Encrypt("customers", &actual))
+	require.Nil(t, underTest.Encrypt("customers", &other))
+
+	// then
+	assert.True(t, isEnvelopeOn(t, actual.Email, "d1/email"))
+	assert.True(t, isEnvelopeOn(t, actual.Phone, "dek/phone"))
+	assert.Equal(t, expected.Name, actual.Name)
+	assert.Equal(t, underTest.BlindIndex("customers", "email", expected.Email), actual.EmailIndex)
+	assert.Equal(t, actual.Email, other.Email)
+	assert.NotEqual(t, actual.Phone, other.Phone)
+
+	require.Nil(t, underTest.Decrypt("customers", &actual))
+	assert.Equal(t, *expected, actual)
+}
+
+func TestFieldEncryptor_OmitEmpty(t *testing.T) {
+	// given
+	underTest := newFieldEncryptor(t)
+	underTest.EncryptDeterministically(newKeyring(t, "d1", aesSivKey))
+
+	expected := &taggedCustomer{ID: uuid.NewString(), Email: "john@example.com", Phone: "", Nickname: "", Name: "John"}
+	actual, withNickname := *expected, *expected
+	withNickname.Nickname = "Johnny"
+
+	// when
+	require.Nil(t, underTest.Encrypt("customers", &actual))
+	require.Nil(t, underTest.Encrypt("customers", &withNickname))
+
+	// then
+	assert.Equal(t, "", actual.Nickname)
+	assert.True(t, isEnvelopeOn(t, actual.Phone, "dek/phone"))
+	assert.True(t, isEnvelopeOn(t, withNickname.Nickname, "dek/nickname"))
+
+	require.Nil(t, underTest.Decrypt("customers", &actual))
+	assert.Equal(t, *expected, actual)
+
+	require.Nil(t, underTest.Decrypt("customers", &withNickname))
+	assert.Equal(t, "Johnny", withNickname.Nickname)
+}
+
+func TestFieldEncryptor_WithInvalidTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity any
+	}{
+		{"non-pointer", taggedCustomer{}},
+		{"non-string field", &struct {
+			ID      string `encrypt:"id"`
+			DataKey string `encrypt:"data-key"`
+			Value   int    `encrypt:"aead"`
+		}{}},
+		{"unknown mode", &struct {
+			ID      string `encrypt:"id"`
+			DataKey string `encrypt:"data-key"`
+			Value   string `encrypt:"rot13"`
+		}{}},
+		{"missing blind index field", &struct {
+			ID      string `encrypt:"id"`
+			DataKey string `encrypt:"data-key"`
+			Value   string `encrypt:"aead,blind-index=ValueIndex"`
+		}{}},
+		{"unknown option", &struct {
+			ID      string `encrypt:"id"`
+			DataKey string `encrypt:"data-key"`
+			Value   string `encrypt:"aead,omitzero"`
+		}{}},
+		{"missing data key", &struct {
+			ID    string `encrypt:"id"`
+			Value string `encrypt:"aead"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			err := newFieldEncryptor(t).Encrypt("entities", tt.entity)
+
+			// then
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestFieldEncryptor_BlindIndexOfEachField(t *testing.T) {
+	// given
+	underTest := newFieldEncryptor(t)
+	blindIndex := newBlindIndex(t)
+
+	// when
+	emailIndex := underTest.BlindIndex("customers", "email", "50277613433")
+	phoneIndex := underTest.BlindIndex("customers", "phone", "50277613433")
+	otherTableIndex := underTest.BlindIndex("suppliers", "email", "50277613433")
+
+	// then
+	expected, err := blindIndex.Derive("customers", "email")
+	require.Nil(t, err)
+
+	assert.Equal(t, expected.Compute("50277613433"), emailIndex)
+	assert.NotEqual(t, blindIndex.Compute("50277613433"), emailIndex)
+	assert.NotEqual(t, emailIndex, phoneIndex)
+	assert.NotEqual(t, emailIndex, otherTableIndex)
+}
+
+func TestFieldEncryptor_WithAesGcmTag(t *testing.T) {
+	// given
+	underTest := NewFieldEncryptor(NewLocalKeyManagementService(newKeyring(t, "k1", secretKey), AlgorithmAesGcm256),
+		newBlindIndex(t), AlgorithmChaCha20Poly1305)
+
+	expected := &struct {
+		ID      string `encrypt:"id"`
+		DataKey string `encrypt:"data-key"`
+		Phone   string `encrypt:"aes-gcm"`
+		Email   string `encrypt:"aead"`
+	}{ID: uuid.NewString(), Phone: "+55 11 91234-5678", Email: "jane@example.com"}
+	actual := *expected
+
+	// when
+	require.Nil(t, underTest.Encrypt("customers", &actual))
+
+	// then
+	phone, err := ParseEnvelope(actual.Phone)
+	require.Nil(t, err)
+	assert.Equal(t, "dek/phone", phone.KeyID)
+	assert.Equal(t, AlgorithmAesGcm256, phone.Algorithm)
+
+	email, err := ParseEnvelope(actual.Email)
+	require.Nil(t, err)
+	assert.Equal(t, AlgorithmChaCha20Poly1305, email.Algorithm)
+
+	require.Nil(t, underTest.Decrypt("customers", &actual))
+	assert.Equal(t, *expected, actual)
+}
+
+func TestToSnakeCase(t *testing.T) {
+	assert.Equal(t, "user_document", toSnakeCase("UserDocument"))
+	assert.Equal(t, "credit_card_token", toSnakeCase("CreditCardToken"))
+	assert.Equal(t, "email", toSnakeCase("Email"))
+}
+
+func newFieldEncryptor(t *testing.T) *FieldEncryptor {
+	return NewFieldEncryptor(NewLocalKeyManagementService(newKeyring(t, "k1", secretKey), AlgorithmAesGcm256),
+		newBlindIndex(t), AlgorithmAesGcm256)
+}
+
+func isEnvelopeOn(t *testing.T, ciphertext, keyID string) bool {
+	envelope, err := ParseEnvelope(ciphertext)
+	require.Nil(t, err)
+
+	return envelope.KeyID == keyID
+}
@@ -0,0 +1,51 @@
+package providers
+
+import (
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	KeyPurposeEncryption              = "encryption"
+	KeyPurposeDeterministicEncryption = "deterministic-encryption"
+	KeyPurposeBlindIndex              = "blind-index"
+)
+
+// DeriveKey derives, with HKDF-SHA256, a subkey of the key's size that is
+// only used for the given purpose and field: leaking it exposes nothing else.
+func DeriveKey(key []byte, purpose, field string) ([]byte, error) {
+	return newKeyDeriver(key).derive(purpose, field)
+}
+
+// keyDeriver derives several subkeys of a key like DeriveKey, extracting the
+// HKDF pseudorandom key once for all of them.
+type keyDeriver struct {
+	pseudorandomKey []byte
+	size            int
+}
+
+func newKeyDeriver(key []byte) *keyDeriver {
+	return newSizedKeyDeriver(key, len(key))
+}
+
+// newSizedKeyDeriver derives subkeys of the given size instead, for the
+// algorithms whose keys aren't the size of the key they're derived from.
+func newSizedKeyDeriver(key []byte, size int) *keyDeriver {
+	return &keyDeriver{hkdf.Extract(sha256.New, key, nil), size}
+}
+
+func (kd *keyDeriver) derive(purpose, field string) ([]byte, error) {
+	derived := make([]byte, kd.size)
+
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, kd.pseudorandomKey, []byte(purpose+"/"+field)), derived); err != nil {
+		return nil, err
+	}
+
+	return derived, nil
+}
+
+func DerivedKeyID(keyID, field string) string {
+	return keyID + "/" + field
+}
@@ -0,0 +1,240 @@
+package providers
+
+import (
+	"crypto-challenge/config"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	keyFileExtension   = ".key"
+	primaryKeyFileName = "primary"
+	legacyKeyFileName  = "legacy"
+)
+
+// Keyring holds the primary key, used for every new encryption, and the
+// decrypt-only keys that are kept around until no ciphertext references them.
+type Keyring struct {
+	primaryID string
+	legacyID  string
+	keys      map[string][]byte
+}
+
+func NewKeyring(primaryID string, primaryKey []byte) *Keyring {
+	return &Keyring{
+		primaryID: primaryID,
+		legacyID:  primaryID,
+		keys:      map[string][]byte{primaryID: primaryKey},
+	}
+}
+
+func NewKeyringFromConfig(cfg *config.AppConfig) (*Keyring, error) {
+	primaryKey, err := DecodeLockedKey(cfg.Cryptography.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUnsealedKeyringFromConfig(cfg, primaryKey)
+}
+
+// NewUnsealedKeyringFromConfig loads the keyring around the given primary
+// key, for the deployments that start sealed and rebuild it from its shares.
+func NewUnsealedKeyringFromConfig(cfg *config.AppConfig, primaryKey []byte) (*Keyring, error) {
+	keyring := NewKeyring(cfg.Cryptography.SecretKeyID, primaryKey)
+
+	for id, hexKey := range cfg.Cryptography.DecryptOnlyKeys {
+		key, err := DecodeLockedKey(hexKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := keyring.AddDecryptOnlyKey(id, key); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Cryptography.LegacyKeyID != "" {
+		if err := keyring.SetLegacyKeyID(cfg.Cryptography.LegacyKeyID); err != nil {
+			return nil, err
+		}
+	}
+
+	return keyring, nil
+}
+
+// NewKeyringFromDir loads a keyring from a directory holding one
+// "<key id>.key" file with the hex-encoded key for each key, plus a "primary"
+// file with the ID of the primary key and, optionally, a "legacy" one with the
+// ID of the legacy key.
+func NewKeyringFromDir(dir string) (*Keyring, error) {
+	primaryID, err := readKeyringFile(filepath.Join(dir, primaryKeyFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	keyFilePaths, err := filepath.Glob(filepath.Join(dir, "*"+keyFileExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string][]byte, len(keyFilePaths))
+
+	for _, keyFilePath := range keyFilePaths {
+		id := strings.TrimSuffix(filepath.Base(keyFilePath), keyFileExtension)
+		if id == CustomerKeyID {
+			return nil, fmt.Errorf("key file %s: the key ID %q is reserved for the customer keys", keyFilePath, id)
+		}
+
+		hexKey, err := readKeyringFile(keyFilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := DecodeLockedKey(hexKey)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("key file %s must hold exactly 32 hex-encoded bytes", keyFilePath)
+		}
+
+		keys[id] = key
+	}
+
+	primaryKey, ok := keys[primaryID]
+	if !ok {
+		return nil, fmt.Errorf("no key file for the primary key ID %q in %s", primaryID, dir)
+	}
+
+	keyring := NewKeyring(primaryID, primaryKey)
+
+	for id, key := range keys {
+		if id == primaryID {
+			continue
+		}
+
+		if err := keyring.AddDecryptOnlyKey(id, key); err != nil {
+			return nil, err
+		}
+	}
+
+	legacyID, err := readKeyringFile(filepath.Join(dir, legacyKeyFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if legacyID != "" {
+		if err := keyring.SetLegacyKeyID(legacyID); err != nil {
+			return nil, err
+		}
+	}
+
+	return keyring, nil
+}
+
+func readKeyringFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+func (kr *Keyring) AddDecryptOnlyKey(id string, key []byte) error {
+	if _, ok := kr.keys[id]; ok {
+		return fmt.Errorf("duplicated key ID %q", id)
+	}
+
+	kr.keys[id] = key
+
+	return nil
+}
+
+// SetLegacyKeyID tells which key decrypts the values written before the
+// ciphertext envelope existed, they carry no key ID of their own.
+func (kr *Keyring) SetLegacyKeyID(id string) error {
+	if _, ok := kr.keys[id]; !ok {
+		return fmt.Errorf("unknown key ID %q", id)
+	}
+
+	kr.legacyID = id
+
+	return nil
+}
+
+// Derive returns the keyring of the subkeys derived from each key for the
+// given purpose and field, identified as "<key id>/<field>". The keys
+// themselves are kept as decrypt-only, for what was encrypted before subkeys.
+func (kr *Keyring) Derive(purpose, field string) (*Keyring, error) {
+	primaryKey, err := DeriveKey(kr.keys[kr.primaryID], purpose, field)
+	if err != nil {
+		return nil, err
+	}
+
+	derived := NewKeyring(DerivedKeyID(kr.primaryID, field), primaryKey)
+	derived.legacyID = kr.legacyID
+
+	for id, key := range kr.keys {
+		if id != kr.primaryID {
+			subkey, err := DeriveKey(key, purpose, field)
+			if err != nil {
+				return nil, err
+			}
+
+			derived.keys[DerivedKeyID(id, field)] = subkey
+		}
+
+		derived.keys[id] = key
+	}
+
+	return derived, nil
+}
+
+// DeriveKeyring returns the keyring of the keys of the given size derived
+// from each key for the given purpose, under the same IDs, so a rotation of
+// the keyring rotates them as well. They are kept in locked memory.
+func (kr *Keyring) DeriveKeyring(purpose string, size int) (*Keyring, error) {
+	derived := &Keyring{primaryID: kr.primaryID, legacyID: kr.legacyID, keys: make(map[string][]byte, len(kr.keys))}
+
+	for id, key := range kr.keys {
+		subkey, err := newSizedKeyDeriver(key, size).derive(purpose, "")
+		if err != nil {
+			return nil, err
+		}
+
+		lockKey(subkey)
+		derived.keys[id] = subkey
+	}
+
+	return derived, nil
+}
+
+func (kr *Keyring) PrimaryKey() (string, []byte) {
+	return kr.primaryID, kr.keys[kr.primaryID]
+}
+
+func (kr *Keyring) Key(id string) ([]byte, error) {
+	key, ok := kr.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKeyID, id)
+	}
+
+	return key, nil
+}
+
+func (kr *Keyring) LegacyKey() (string, []byte) {
+	return kr.legacyID, kr.keys[kr.legacyID]
+}
+
+func (kr *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(kr.keys))
+
+	for id := range kr.keys {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
@@ -0,0 +1,91 @@
+package providers
+
+import (
+	"crypto-challenge/config"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	KmsProviderLocal     = "local"
+	KmsProviderHttp      = "http"
+	KmsProviderPublicKey = "public-key"
+)
+
+type KeyMetadata struct {
+	KeyID     string `json:"keyId"`
+	Algorithm string `json:"algorithm"`
+	Primary   bool   `json:"primary"`
+}
+
+// KeyManagementService holds the master keys, which never leave it: callers
+// only get data keys wrapped and unwrapped by them. KeyMetadata takes an
+// empty key ID for the primary key.
+type KeyManagementService interface {
+	WrapKey(plaintextKey, additionalData []byte) (string, error)
+	UnwrapKey(wrappedKey string, additionalData []byte) ([]byte, error)
+	GenerateDataKey(additionalData []byte) ([]byte, string, error)
+	KeyMetadata(keyID string) (*KeyMetadata, error)
+}
+
+func NewKeyManagementServiceFromConfig(cfg *config.AppConfig) (KeyManagementService, error) {
+	switch cfg.Kms.Provider {
+	case KmsProviderHttp:
+		return NewHttpKeyManagementService(cfg.Kms.URL, cfg.Kms.Token, &http.Client{Timeout: cfg.Kms.Timeout}), nil
+	case KmsProviderPublicKey:
+		return newPublicKeyKeyManagementServiceFromConfig(cfg)
+	}
+
+	keyring, err := NewLocalKeyringFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLocalKeyManagementServiceFromConfig(cfg, keyring), nil
+}
+
+// NewLocalKeyManagementServiceFromConfig serves the keyring with the
+// Cryptography settings.
+func NewLocalKeyManagementServiceFromConfig(cfg *config.AppConfig, keyring *Keyring) *LocalKeyManagementService {
+	kms := NewLocalKeyManagementService(keyring, cfg.Cryptography.Algorithm)
+	if cfg.Cryptography.RejectUnbound {
+		kms.RejectUnboundCiphertexts()
+	}
+
+	return kms
+}
+
+// NewLocalKeyringFromConfig loads the keyring from the keys directory when
+// one is configured, otherwise from the Cryptography settings.
+func NewLocalKeyringFromConfig(cfg *config.AppConfig) (*Keyring, error) {
+	if cfg.Kms.KeysDir != "" {
+		return NewKeyringFromDir(cfg.Kms.KeysDir)
+	}
+
+	return NewKeyringFromConfig(cfg)
+}
+
+func newPublicKeyKeyManagementServiceFromConfig(cfg *config.AppConfig) (*PublicKeyKeyManagementService, error) {
+	publicKey, err := hex.DecodeString(cfg.Kms.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	cp, err := NewX25519AesGcmCryptoProvider(cfg.Kms.PublicKeyID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, hexKey := range cfg.Kms.PrivateKeys {
+		privateKey, err := DecodeLockedKey(hexKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := cp.AddPrivateKey(id, privateKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewPublicKeyKeyManagementService(cp), nil
+}
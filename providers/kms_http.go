@@ -0,0 +1,138 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type KmsWrapKeyRequest struct {
+	PlaintextKey   []byte `json:"plaintextKey,omitempty"`
+	AdditionalData []byte `json:"additionalData,omitempty"`
+}
+
+type KmsUnwrapKeyRequest struct {
+	WrappedKey     string `json:"wrappedKey"`
+	AdditionalData []byte `json:"additionalData,omitempty"`
+}
+
+type KmsKeyResponse struct {
+	PlaintextKey []byte `json:"plaintextKey,omitempty"`
+	WrappedKey   string `json:"wrappedKey,omitempty"`
+}
+
+// KmsErrorResponse tells the typed decryption error of a key that couldn't
+// be unwrapped, see DecryptionErrorReason.
+type KmsErrorResponse struct {
+	Error  string `json:"error"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// HttpKeyManagementService is a client of a KMS exposing the API served by
+// handlers.NewKeyManagementRouter.
+type HttpKeyManagementService struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func NewHttpKeyManagementService(baseURL, token string, client *http.Client) *HttpKeyManagementService {
+	return &HttpKeyManagementService{strings.TrimSuffix(baseURL, "/"), token, client}
+}
+
+func (kms *HttpKeyManagementService) WrapKey(plaintextKey, additionalData []byte) (string, error) {
+	var res KmsKeyResponse
+
+	err := kms.do(http.MethodPost, "/keys/wrap", &KmsWrapKeyRequest{plaintextKey, additionalData}, &res)
+	if err != nil {
+		return "", err
+	}
+
+	return res.WrappedKey, nil
+}
+
+func (kms *HttpKeyManagementService) UnwrapKey(wrappedKey string, additionalData []byte) ([]byte, error) {
+	var res KmsKeyResponse
+
+	err := kms.do(http.MethodPost, "/keys/unwrap", &KmsUnwrapKeyRequest{wrappedKey, additionalData}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.PlaintextKey, nil
+}
+
+func (kms *HttpKeyManagementService) GenerateDataKey(additionalData []byte) ([]byte, string, error) {
+	var res KmsKeyResponse
+
+	err := kms.do(http.MethodPost, "/keys/generate-data-key", &KmsWrapKeyRequest{AdditionalData: additionalData}, &res)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return res.PlaintextKey, res.WrappedKey, nil
+}
+
+func (kms *HttpKeyManagementService) KeyMetadata(keyID string) (*KeyMetadata, error) {
+	path := "/keys/primary"
+	if keyID != "" {
+		path = "/keys/" + url.PathEscape(keyID)
+	}
+
+	var res KeyMetadata
+
+	if err := kms.do(http.MethodGet, path, nil, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (kms *HttpKeyManagementService) do(method, path string, reqBody, resBody any) error {
+	var body bytes.Buffer
+
+	if reqBody != nil {
+		if err := json.NewEncoder(&body).Encode(reqBody); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, kms.baseURL+path, &body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if kms.token != "" {
+		req.Header.Set("Authorization", "Bearer "+kms.token)
+	}
+
+	res, err := kms.client.Do(req)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		err := fmt.Errorf("KMS answered %s %s with status %d", method, path, res.StatusCode)
+
+		var errRes KmsErrorResponse
+		if json.NewDecoder(res.Body).Decode(&errRes) == nil {
+			if decryptionErr := decryptionErrorFromReason(errRes.Reason); decryptionErr != nil {
+				err = fmt.Errorf("%w: %v", decryptionErr, err)
+			}
+		}
+
+		log.Println(err)
+		return err
+	}
+
+	return json.NewDecoder(res.Body).Decode(resBody)
+}
@@ -0,0 +1,61 @@
+package providers
+
+import (
+	"crypto/rand"
+	"io"
+)
+
+type LocalKeyManagementService struct {
+	keyring *Keyring
+	cp      *AeadCryptoProvider
+}
+
+func NewLocalKeyManagementService(keyring *Keyring, algorithm string) *LocalKeyManagementService {
+	return &LocalKeyManagementService{keyring, NewAeadCryptoProvider(keyring, algorithm)}
+}
+
+// RejectUnboundCiphertexts stops unwrapping the keys, and the fields written
+// before data keys existed, sealed before envelope version 2.
+func (kms *LocalKeyManagementService) RejectUnboundCiphertexts() {
+	kms.cp.RejectUnboundCiphertexts()
+}
+
+func (kms *LocalKeyManagementService) WrapKey(plaintextKey, additionalData []byte) (string, error) {
+	return kms.cp.Encrypt(plaintextKey, additionalData)
+}
+
+func (kms *LocalKeyManagementService) UnwrapKey(wrappedKey string, additionalData []byte) ([]byte, error) {
+	return kms.cp.Decrypt(wrappedKey, additionalData)
+}
+
+func (kms *LocalKeyManagementService) GenerateDataKey(additionalData []byte) ([]byte, string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, "", err
+	}
+
+	wrappedDataKey, err := kms.WrapKey(dataKey, additionalData)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return dataKey, wrappedDataKey, nil
+}
+
+func (kms *LocalKeyManagementService) KeyMetadata(keyID string) (*KeyMetadata, error) {
+	primaryKeyID, _ := kms.keyring.PrimaryKey()
+
+	if keyID == "" {
+		keyID = primaryKeyID
+	}
+
+	if _, err := kms.keyring.Key(keyID); err != nil {
+		return nil, err
+	}
+
+	return &KeyMetadata{
+		KeyID:     keyID,
+		Algorithm: kms.cp.algorithm,
+		Primary:   keyID == primaryKeyID,
+	}, nil
+}
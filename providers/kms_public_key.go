@@ -0,0 +1,56 @@
+package providers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// PublicKeyKeyManagementService wraps the data keys with an X25519 public
+// key. Without private keys it is encrypt-only: it creates data keys, but
+// can't unwrap any of them, not even its own.
+type PublicKeyKeyManagementService struct {
+	cp *X25519AesGcmCryptoProvider
+}
+
+func NewPublicKeyKeyManagementService(cp *X25519AesGcmCryptoProvider) *PublicKeyKeyManagementService {
+	return &PublicKeyKeyManagementService{cp}
+}
+
+func (kms *PublicKeyKeyManagementService) WrapKey(plaintextKey, additionalData []byte) (string, error) {
+	return kms.cp.Encrypt(plaintextKey, additionalData)
+}
+
+func (kms *PublicKeyKeyManagementService) UnwrapKey(wrappedKey string, additionalData []byte) ([]byte, error) {
+	return kms.cp.Decrypt(wrappedKey, additionalData)
+}
+
+func (kms *PublicKeyKeyManagementService) GenerateDataKey(additionalData []byte) ([]byte, string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, "", err
+	}
+
+	wrappedDataKey, err := kms.WrapKey(dataKey, additionalData)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return dataKey, wrappedDataKey, nil
+}
+
+func (kms *PublicKeyKeyManagementService) KeyMetadata(keyID string) (*KeyMetadata, error) {
+	if keyID == "" {
+		keyID = kms.cp.publicKeyID
+	}
+
+	if _, ok := kms.cp.privateKeys[keyID]; !ok && keyID != kms.cp.publicKeyID {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKeyID, keyID)
+	}
+
+	return &KeyMetadata{
+		KeyID:     keyID,
+		Algorithm: AlgorithmX25519AesGcm256,
+		Primary:   keyID == kms.cp.publicKeyID,
+	}, nil
+}
@@ -0,0 +1,172 @@
+package providers_test
+
+import (
+	"crypto-challenge/handlers"
+	"crypto-challenge/providers"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	kmsSecretKey = "c7b81104b9fc8b05ff85995f6d34d5b18cfbb0cff21ff2ceab154a3bcfae3aba"
+	kmsToken     = "s3cr3t"
+)
+
+func TestHttpKeyManagementService(t *testing.T) {
+	// given
+	underTest, localKms := newHttpKeyManagementService(t, kmsToken, kmsToken)
+	additionalData := []byte("transactions/1/data_key")
+
+	// when
+	dataKey, wrappedDataKey, err := underTest.GenerateDataKey(additionalData)
+	require.Nil(t, err)
+
+	unwrappedDataKey, err := underTest.UnwrapKey(wrappedDataKey, additionalData)
+	require.Nil(t, err)
+
+	locallyUnwrappedDataKey, err := localKms.UnwrapKey(wrappedDataKey, additionalData)
+	require.Nil(t, err)
+
+	keyMetadata, err := underTest.KeyMetadata("")
+	require.Nil(t, err)
+
+	// then
+	assert.Len(t, dataKey, 32)
+	assert.Equal(t, dataKey, unwrappedDataKey)
+	assert.Equal(t, dataKey, locallyUnwrappedDataKey)
+	assert.Equal(t, providers.KeyMetadata{KeyID: "k1", Algorithm: providers.AlgorithmAesGcm256, Primary: true}, *keyMetadata)
+}
+
+func TestHttpKeyManagementService_WithWrongAdditionalData(t *testing.T) {
+	// given
+	underTest, _ := newHttpKeyManagementService(t, kmsToken, kmsToken)
+
+	wrappedKey, err := underTest.WrapKey([]byte("data key"), []byte("transactions/1/data_key"))
+	require.Nil(t, err)
+
+	// when
+	actual, err := underTest.UnwrapKey(wrappedKey, []byte("transactions/2/data_key"))
+
+	// then
+	assert.ErrorIs(t, err, providers.ErrAuthenticationFailed)
+	assert.Nil(t, actual)
+}
+
+func TestHttpKeyManagementService_WithUnknownKeyID(t *testing.T) {
+	// given
+	underTest, _ := newHttpKeyManagementService(t, kmsToken, kmsToken)
+
+	wrappedKey, err := underTest.WrapKey([]byte("data key"), nil)
+	require.Nil(t, err)
+
+	// when
+	actual, err := underTest.UnwrapKey(strings.Replace(wrappedKey, ":k1:", ":k9:", 1), nil)
+
+	// then
+	assert.ErrorIs(t, err, providers.ErrUnknownKeyID)
+	assert.Nil(t, actual)
+}
+
+func TestHttpKeyManagementService_WithWrongToken(t *testing.T) {
+	// given
+	underTest, _ := newHttpKeyManagementService(t, kmsToken, "wrong token")
+
+	// when
+	_, err := underTest.WrapKey([]byte("data key"), nil)
+
+	// then
+	assert.NotNil(t, err)
+}
+
+func TestHttpKeyManagementService_WhenServerHasNoToken(t *testing.T) {
+	// given
+	underTest, _ := newHttpKeyManagementService(t, "", "")
+
+	// when
+	_, err := underTest.WrapKey([]byte("data key"), nil)
+
+	// then
+	assert.NotNil(t, err)
+}
+
+func TestNewKeyringFromDir(t *testing.T) {
+	// given
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "k1.key"), kmsSecretKey+"\n")
+	writeFile(t, filepath.Join(dir, "k2.key"), "5be3c9e1b0e4bb3f0d4f0b1b8e1f0cc1a4c3d2f1e0a9b8c7d6e5f4a3b2c1d0e9")
+	writeFile(t, filepath.Join(dir, "primary"), "k2\n")
+
+	// when
+	keyring, err := providers.NewKeyringFromDir(dir)
+	require.Nil(t, err)
+
+	// then
+	primaryKeyID, _ := keyring.PrimaryKey()
+
+	assert.Equal(t, "k2", primaryKeyID)
+	assert.Equal(t, []string{"k1", "k2"}, keyring.KeyIDs())
+}
+
+func TestNewKeyringFromDir_WithCustomerKeyID(t *testing.T) {
+	// given
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, providers.CustomerKeyID+".key"), kmsSecretKey)
+	writeFile(t, filepath.Join(dir, "primary"), providers.CustomerKeyID)
+
+	// when
+	_, err := providers.NewKeyringFromDir(dir)
+
+	// then
+	assert.NotNil(t, err)
+}
+
+func TestKeyring_DeriveKeyring(t *testing.T) {
+	// given
+	oldKey, err := hex.DecodeString(kmsSecretKey)
+	require.Nil(t, err)
+
+	keyring := providers.NewKeyring("k2", make([]byte, 32))
+	require.Nil(t, keyring.AddDecryptOnlyKey("k1", oldKey))
+
+	// when
+	derived, err := keyring.DeriveKeyring(providers.KeyPurposeDeterministicEncryption, providers.AesSivKeySize)
+	require.Nil(t, err)
+
+	// then
+	primaryKeyID, primaryKey := derived.PrimaryKey()
+	derivedOldKey, err := derived.Key("k1")
+	require.Nil(t, err)
+
+	assert.Equal(t, "k2", primaryKeyID)
+	assert.Equal(t, []string{"k1", "k2"}, derived.KeyIDs())
+	assert.Len(t, primaryKey, providers.AesSivKeySize)
+	assert.Len(t, derivedOldKey, providers.AesSivKeySize)
+	assert.NotEqual(t, oldKey, derivedOldKey[:32])
+}
+
+func newHttpKeyManagementService(t *testing.T, serverToken, token string) (*providers.HttpKeyManagementService,
+	providers.KeyManagementService) {
+	key, err := hex.DecodeString(kmsSecretKey)
+	require.Nil(t, err)
+
+	localKms := providers.NewLocalKeyManagementService(providers.NewKeyring("k1", key), providers.AlgorithmAesGcm256)
+
+	server := httptest.NewServer(handlers.NewKeyManagementRouter(localKms, serverToken))
+	t.Cleanup(server.Close)
+
+	return providers.NewHttpKeyManagementService(server.URL, token, http.DefaultClient), localKms
+}
+
+func writeFile(t *testing.T, path, content string) {
+	require.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+}
@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+)
+
+var errInvalidHexKey = errors.New("the key must be hex-encoded")
+
+// lockedKeys tracks the keys held in locked memory, so they can all be
+// zeroed on shutdown.
+var lockedKeys struct {
+	sync.Mutex
+	keys    [][]byte
+	warning sync.Once
+}
+
+// DecodeLockedKey decodes a hex-encoded key into memory locked out of swap,
+// where possible, that WipeLockedKeys zeroes. Its errors never hold any part
+// of the key.
+func DecodeLockedKey(hexKey string) ([]byte, error) {
+	encoded := []byte(hexKey)
+	defer wipe(encoded)
+
+	key := make([]byte, hex.DecodedLen(len(encoded)))
+
+	if _, err := hex.Decode(key, encoded); err != nil {
+		wipe(key)
+		return nil, errInvalidHexKey
+	}
+
+	lockKey(key)
+
+	return key, nil
+}
+
+// lockKey moves the key out of swap, where possible, and tracks it so that
+// WipeLockedKeys zeroes it.
+func lockKey(key []byte) {
+	lockedKeys.Lock()
+	defer lockedKeys.Unlock()
+
+	// Locking fails past RLIMIT_MEMLOCK or on the platforms without mlock,
+	// the key is still zeroed on shutdown.
+	if err := lockMemory(key); err != nil {
+		lockedKeys.warning.Do(func() {
+			log.Printf("Keys can't be locked in memory, they may be swapped to disk: %v\n", err)
+		})
+	}
+
+	lockedKeys.keys = append(lockedKeys.keys, key)
+}
+
+// WipeLockedKeys zeroes and unlocks every key decoded by DecodeLockedKey, they
+// must not be used afterwards. The copies the crypto libraries make, such as
+// the cached AES key schedules and HMAC states, are left as they are.
+func WipeLockedKeys() {
+	lockedKeys.Lock()
+	defer lockedKeys.Unlock()
+
+	for _, key := range lockedKeys.keys {
+		wipe(key)
+	}
+
+	// Pages are unlocked only once every key is zeroed, since keys may share
+	// them.
+	for _, key := range lockedKeys.keys {
+		unlockMemory(key)
+	}
+
+	lockedKeys.keys = nil
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
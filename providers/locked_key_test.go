@@ -0,0 +1,45 @@
+package providers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeLockedKey(t *testing.T) {
+	// when
+	actual, err := DecodeLockedKey(secretKey)
+	require.Nil(t, err)
+
+	// then
+	assert.Equal(t, mustDecodeHex(t, secretKey), actual)
+}
+
+func TestDecodeLockedKey_WithInvalidKey(t *testing.T) {
+	// given
+	invalidKey := secretKey[:len(secretKey)-1] + "g"
+
+	// when
+	actual, err := DecodeLockedKey(invalidKey)
+
+	// then
+	require.NotNil(t, err)
+	assert.Nil(t, actual)
+	assert.False(t, strings.Contains(err.Error(), "g"), err.Error())
+	assert.False(t, strings.Contains(err.Error(), secretKey[:8]), err.Error())
+}
+
+func TestWipeLockedKeys(t *testing.T) {
+	// given
+	key, err := DecodeLockedKey(secretKey)
+	require.Nil(t, err)
+
+	// when
+	WipeLockedKeys()
+
+	// then
+	assert.True(t, bytes.Equal(make([]byte, 32), key))
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package providers
+
+import "errors"
+
+func lockMemory(b []byte) error {
+	return errors.New("mlock isn't supported on this platform")
+}
+
+func unlockMemory(b []byte) {}
@@ -0,0 +1,19 @@
+//go:build linux || darwin
+
+package providers
+
+import "syscall"
+
+func lockMemory(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+
+	return syscall.Mlock(b)
+}
+
+func unlockMemory(b []byte) {
+	if len(b) > 0 {
+		syscall.Munlock(b)
+	}
+}
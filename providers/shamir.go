@@ -0,0 +1,221 @@
+package providers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	keyPurposeShareCheck = "key-share-check"
+	shareCheckSize       = 8
+	maxKeyShares         = 255
+)
+
+var (
+	ErrInvalidKeyShare = errors.New("invalid key share")
+	// ErrKeySharesMismatch is returned when the shares don't come from the
+	// same split or don't rebuild the key they were split from.
+	ErrKeySharesMismatch = errors.New("the key shares don't rebuild the same key")
+)
+
+// KeyShare is one of the shares a key is split into with Shamir's secret
+// sharing: any Threshold of them rebuild the key, fewer tell nothing about it.
+// Check identifies the key, so a wrong share is caught once the key is rebuilt.
+type KeyShare struct {
+	Threshold int
+	Index     byte
+	Value     []byte
+	Check     []byte
+}
+
+// SplitKey splits the key into the given number of shares, any threshold of
+// them rebuilding it, encoded as "<threshold>-<index>-<hex value>-<hex check>".
+func SplitKey(key []byte, shares, threshold int) ([]string, error) {
+	if threshold < 2 || threshold > shares || shares > maxKeyShares {
+		return nil, fmt.Errorf("the threshold must be between 2 and the number of shares, at most %d", maxKeyShares)
+	}
+
+	if len(key) == 0 {
+		return nil, errors.New("the key must not be empty")
+	}
+
+	check, err := keyShareCheck(key)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, shares)
+	for i := range values {
+		values[i] = make([]byte, len(key))
+	}
+
+	// Each byte of the key is the constant term of its own random polynomial
+	// of degree threshold - 1, share i holds the polynomials evaluated at i.
+	coefficients := make([]byte, threshold)
+	defer wipe(coefficients)
+
+	for b, secret := range key {
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, err
+		}
+
+		coefficients[0] = secret
+
+		for i := range values {
+			values[i][b] = evaluatePolynomial(coefficients, byte(i+1))
+		}
+	}
+
+	encoded := make([]string, shares)
+	for i, value := range values {
+		encoded[i] = KeyShare{threshold, byte(i + 1), value, check}.String()
+		wipe(value)
+	}
+
+	return encoded, nil
+}
+
+// CombineKeyShares rebuilds the key from at least the threshold of its shares.
+func CombineKeyShares(shares []KeyShare) ([]byte, error) {
+	if len(shares) == 0 || len(shares) < shares[0].Threshold {
+		return nil, fmt.Errorf("%w: not enough shares", ErrInvalidKeyShare)
+	}
+
+	first := shares[0]
+	indexes := make(map[byte]bool, len(shares))
+
+	for _, share := range shares {
+		if share.Threshold != first.Threshold || len(share.Value) != len(first.Value) ||
+			subtle.ConstantTimeCompare(share.Check, first.Check) != 1 {
+			return nil, ErrKeySharesMismatch
+		}
+
+		if indexes[share.Index] {
+			return nil, fmt.Errorf("%w: duplicated share %d", ErrInvalidKeyShare, share.Index)
+		}
+
+		indexes[share.Index] = true
+	}
+
+	key := make([]byte, len(first.Value))
+
+	// Lagrange interpolation at zero, byte by byte.
+	for i, share := range shares {
+		basis := byte(1)
+
+		for j, other := range shares {
+			if i != j {
+				basis = gfMul(basis, gfDiv(other.Index, other.Index^share.Index))
+			}
+		}
+
+		for b := range key {
+			key[b] ^= gfMul(share.Value[b], basis)
+		}
+	}
+
+	check, err := keyShareCheck(key)
+	if err != nil {
+		wipe(key)
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare(check, first.Check) != 1 {
+		wipe(key)
+		return nil, ErrKeySharesMismatch
+	}
+
+	return key, nil
+}
+
+func ParseKeyShare(encoded string) (KeyShare, error) {
+	parts := strings.Split(strings.TrimSpace(encoded), "-")
+	if len(parts) != 4 {
+		return KeyShare{}, ErrInvalidKeyShare
+	}
+
+	threshold, err := strconv.Atoi(parts[0])
+	if err != nil || threshold < 2 || threshold > maxKeyShares {
+		return KeyShare{}, ErrInvalidKeyShare
+	}
+
+	index, err := strconv.Atoi(parts[1])
+	if err != nil || index < 1 || index > maxKeyShares {
+		return KeyShare{}, ErrInvalidKeyShare
+	}
+
+	value, err := hex.DecodeString(parts[2])
+	if err != nil || len(value) == 0 {
+		return KeyShare{}, ErrInvalidKeyShare
+	}
+
+	check, err := hex.DecodeString(parts[3])
+	if err != nil || len(check) != shareCheckSize {
+		return KeyShare{}, ErrInvalidKeyShare
+	}
+
+	return KeyShare{threshold, byte(index), value, check}, nil
+}
+
+func (s KeyShare) String() string {
+	return fmt.Sprintf("%d-%d-%s-%s", s.Threshold, s.Index, hex.EncodeToString(s.Value), hex.EncodeToString(s.Check))
+}
+
+// keyShareCheck identifies the key without revealing it, being a truncated
+// subkey.
+func keyShareCheck(key []byte) ([]byte, error) {
+	derived, err := DeriveKey(key, keyPurposeShareCheck, "")
+	if err != nil {
+		return nil, err
+	}
+
+	defer wipe(derived)
+
+	check := make([]byte, shareCheckSize)
+	copy(check, derived)
+
+	return check, nil
+}
+
+// evaluatePolynomial evaluates, with Horner's method, the polynomial with the
+// given coefficients, lowest degree first.
+func evaluatePolynomial(coefficients []byte, x byte) byte {
+	y := byte(0)
+
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ coefficients[i]
+	}
+
+	return y
+}
+
+// gfMul multiplies in GF(2^8) modulo the AES polynomial, without branching on
+// the operands.
+func gfMul(a, b byte) byte {
+	var product byte
+
+	for i := 0; i < 8; i++ {
+		product ^= -(b & 1) & a
+		a = (a << 1) ^ (-(a >> 7) & 0x1b)
+		b >>= 1
+	}
+
+	return product
+}
+
+// gfDiv divides a by b, which must not be zero, multiplying a by b^254, the
+// inverse of b in GF(2^8).
+func gfDiv(a, b byte) byte {
+	inverse := byte(1)
+
+	for i := 0; i < 254; i++ {
+		inverse = gfMul(inverse, b)
+	}
+
+	return gfMul(a, inverse)
+}
@@ -0,0 +1,135 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitKey_AnyThresholdOfTheSharesRebuildTheKey(t *testing.T) {
+	// given
+	key := mustDecodeHex(t, secretKey)
+
+	// when
+	encoded, err := SplitKey(key, 5, 3)
+	require.Nil(t, err)
+
+	// then
+	shares := parseKeyShares(t, encoded)
+
+	for _, subset := range [][]int{{0, 1, 2}, {2, 3, 4}, {4, 0, 2}, {0, 1, 2, 3, 4}} {
+		var chosen []KeyShare
+		for _, i := range subset {
+			chosen = append(chosen, shares[i])
+		}
+
+		actual, err := CombineKeyShares(chosen)
+		require.Nil(t, err, subset)
+		assert.Equal(t, key, actual, subset)
+	}
+}
+
+func TestSplitKey_SharesDontHoldTheKey(t *testing.T) {
+	// given
+	key := mustDecodeHex(t, secretKey)
+
+	// when
+	encoded, err := SplitKey(key, 3, 2)
+	require.Nil(t, err)
+
+	// then
+	for _, share := range parseKeyShares(t, encoded) {
+		assert.NotEqual(t, key, share.Value)
+	}
+}
+
+func TestSplitKey_WithInvalidThreshold(t *testing.T) {
+	key := mustDecodeHex(t, secretKey)
+
+	for _, sharesAndThreshold := range [][2]int{{3, 1}, {3, 4}, {256, 2}} {
+		// when
+		actual, err := SplitKey(key, sharesAndThreshold[0], sharesAndThreshold[1])
+
+		// then
+		assert.NotNil(t, err, sharesAndThreshold)
+		assert.Nil(t, actual)
+	}
+}
+
+func TestCombineKeyShares_WithFewerSharesThanTheThreshold(t *testing.T) {
+	// given
+	encoded, err := SplitKey(mustDecodeHex(t, secretKey), 5, 3)
+	require.Nil(t, err)
+
+	// when
+	actual, err := CombineKeyShares(parseKeyShares(t, encoded)[:2])
+
+	// then
+	assert.ErrorIs(t, err, ErrInvalidKeyShare)
+	assert.Nil(t, actual)
+}
+
+func TestCombineKeyShares_WithTamperedShare(t *testing.T) {
+	// given
+	encoded, err := SplitKey(mustDecodeHex(t, secretKey), 3, 2)
+	require.Nil(t, err)
+
+	shares := parseKeyShares(t, encoded)
+	shares[1].Value[0] ^= 1
+
+	// when
+	actual, err := CombineKeyShares(shares[:2])
+
+	// then
+	assert.ErrorIs(t, err, ErrKeySharesMismatch)
+	assert.Nil(t, actual)
+}
+
+func TestCombineKeyShares_WithSharesOfDifferentKeys(t *testing.T) {
+	// given
+	encoded, err := SplitKey(mustDecodeHex(t, secretKey), 3, 2)
+	require.Nil(t, err)
+
+	otherEncoded, err := SplitKey(mustDecodeHex(t, anotherSecretKey), 3, 2)
+	require.Nil(t, err)
+
+	// when
+	actual, err := CombineKeyShares(parseKeyShares(t, []string{encoded[0], otherEncoded[1]}))
+
+	// then
+	assert.ErrorIs(t, err, ErrKeySharesMismatch)
+	assert.Nil(t, actual)
+}
+
+func TestParseKeyShare_WithInvalidShare(t *testing.T) {
+	for _, encoded := range []string{"", "3-1-abcd", "1-1-abcd-0011223344556677", "3-0-abcd-0011223344556677",
+		"3-1-xyz-0011223344556677", "3-1-abcd-0011"} {
+		// when
+		_, err := ParseKeyShare(encoded)
+
+		// then
+		assert.ErrorIs(t, err, ErrInvalidKeyShare, encoded)
+	}
+}
+
+func TestGfDiv_InvertsGfMul(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			assert.Equal(t, byte(a), gfDiv(gfMul(byte(a), byte(b)), byte(b)))
+		}
+	}
+}
+
+func parseKeyShares(t testing.TB, encoded []string) []KeyShare {
+	shares := make([]KeyShare, len(encoded))
+
+	for i, e := range encoded {
+		share, err := ParseKeyShare(e)
+		require.Nil(t, err)
+
+		shares[i] = share
+	}
+
+	return shares
+}
@@ -2,51 +2,314 @@ package providers
 
 import (
 	"crypto-challenge/entities"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
+const (
+	UserDocumentField    = "user_document"
+	CreditCardTokenField = "credit_card_token"
+
+	transactionsTable = "transactions"
+
+	userDocumentDigits = 11
+)
+
+var ErrInvalidUserDocument = errors.New("the CPF must have 11 digits")
+
 type TransactionCryptoProvider interface {
 	Encrypt(*entities.Transaction) error
 	Decrypt(*entities.Transaction) error
+	EncryptMany([]*entities.Transaction) error
+	DecryptMany([]*entities.Transaction) ([]error, error)
+	RewrapDataKey(*entities.Transaction) error
+	UserDocumentIndex(userDocument string) (string, error)
+	CreditCardTokenLast4Index(last4 string) string
 }
 
+// StandardTransactionCryptoProvider encrypts the fields of each transaction
+// as tagged on entities.Transaction, see FieldEncryptor.
 type StandardTransactionCryptoProvider struct {
-	cp CryptoProvider
+	kms            KeyManagementService
+	fieldEncryptor *FieldEncryptor
+	customerKeys   *CustomerKeys
+	workers        int
+
+	encryptValue     bool
+	valueBucketWidth float64
+}
+
+func NewStandardTransactionCryptoProvider(kms KeyManagementService, blindIndex *HmacSha256BlindIndex,
+	algorithm string) *StandardTransactionCryptoProvider {
+	fieldEncryptor := NewFieldEncryptor(kms, blindIndex, algorithm)
+	fieldEncryptor.NormalizeBlindIndex(UserDocumentField, DigitsOnly)
+	fieldEncryptor.NormalizeBlindIndex(CreditCardTokenField, lastFourDigits)
+
+	return &StandardTransactionCryptoProvider{kms: kms, fieldEncryptor: fieldEncryptor}
+}
+
+// UseCustomerKeys has the data keys of new and rewrapped transactions wrapped
+// by their customer's key instead of the master key, so forgetting a customer
+// makes their transactions unreadable.
+func (tcp *StandardTransactionCryptoProvider) UseCustomerKeys(customerKeys *CustomerKeys) {
+	tcp.customerKeys = customerKeys
+}
+
+// UseWorkers bounds the transactions encrypted or decrypted at once by
+// EncryptMany and DecryptMany, GOMAXPROCS when it isn't positive.
+func (tcp *StandardTransactionCryptoProvider) UseWorkers(workers int) {
+	tcp.workers = workers
+}
+
+// EncryptValue has the value of new and updated transactions encrypted too,
+// under their data key. With a positive bucket width, the bucket the value
+// falls in is stored in clear, so ranges of values can still be queried and
+// aggregated, at the cost of revealing that range.
+func (tcp *StandardTransactionCryptoProvider) EncryptValue(bucketWidth float64) {
+	tcp.encryptValue = true
+	tcp.valueBucketWidth = bucketWidth
+}
+
+// EncryptDeterministically has the given fields encrypted with AES-SIV under
+// subkeys of the keyring's keys instead of the transaction's data key, so
+// equal values of a field give equal ciphertexts in every transaction and can
+// be matched. Such ciphertexts are bound to their column only, not to their row.
+func (tcp *StandardTransactionCryptoProvider) EncryptDeterministically(keyring *Keyring, fields ...string) error {
+	for _, field := range fields {
+		if field != UserDocumentField && field != CreditCardTokenField {
+			return fmt.Errorf("unknown transaction field %q", field)
+		}
+	}
+
+	tcp.fieldEncryptor.EncryptDeterministically(keyring, fields...)
+
+	return nil
 }
 
-func NewStandardTransactionCryptoProvider(cp CryptoProvider) *StandardTransactionCryptoProvider {
-	return &StandardTransactionCryptoProvider{cp}
+// RejectUnboundCiphertexts stops decrypting the fields sealed before envelope
+// version 2 under the transactions' data keys, once the re-encryption job
+// brought them all to version 2.
+func (tcp *StandardTransactionCryptoProvider) RejectUnboundCiphertexts() {
+	tcp.fieldEncryptor.RejectUnboundCiphertexts()
 }
 
 func (tcp *StandardTransactionCryptoProvider) Encrypt(toEncrypt *entities.Transaction) error {
-	encryptedUserDocument, err := tcp.cp.Encrypt([]byte(toEncrypt.UserDocument))
+	userDocumentIndex, err := tcp.UserDocumentIndex(toEncrypt.UserDocument)
 	if err != nil {
 		return err
 	}
 
-	encryptedCreditCardToken, err := tcp.cp.Encrypt([]byte(toEncrypt.CreditCardToken))
+	kms, err := tcp.wrappingService(userDocumentIndex)
 	if err != nil {
 		return err
 	}
 
-	toEncrypt.UserDocument = encryptedUserDocument
-	toEncrypt.CreditCardToken = encryptedCreditCardToken
+	// The transaction is sealed and encrypted as a copy, so it is left as it
+	// was when either fails.
+	sealed := *toEncrypt
+
+	if tcp.encryptValue {
+		sealed.EncryptedValue, sealed.ValueBucket = tcp.sealValue(toEncrypt.Value)
+		sealed.Value = 0
+	}
+
+	if err := tcp.fieldEncryptor.EncryptWith(kms, transactionsTable, &sealed); err != nil {
+		return err
+	}
+
+	*toEncrypt = sealed
 
 	return nil
 }
 
+// Decrypt returns ErrErased for the transactions of forgotten customers.
 func (tcp *StandardTransactionCryptoProvider) Decrypt(toDecrypt *entities.Transaction) error {
-	decryptedUserDocument, err := tcp.cp.Decrypt(toDecrypt.UserDocument)
+	if IsErased(toDecrypt) {
+		return ErrErased
+	}
+
+	kms, err := tcp.unwrappingService(toDecrypt)
 	if err != nil {
 		return err
 	}
 
-	decryptedCreditCardToken, err := tcp.cp.Decrypt(toDecrypt.CreditCardToken)
+	if err := tcp.fieldEncryptor.DecryptWith(kms, transactionsTable, toDecrypt); err != nil {
+		return err
+	}
+
+	return openValue(toDecrypt)
+}
+
+// EncryptMany encrypts the transactions concurrently, stopping at the first
+// error.
+func (tcp *StandardTransactionCryptoProvider) EncryptMany(toEncrypt []*entities.Transaction) error {
+	return forEachConcurrently(len(toEncrypt), tcp.workers, func(i int) error {
+		return tcp.Encrypt(toEncrypt[i])
+	})
+}
+
+// DecryptMany decrypts the transactions concurrently, stopping at the first
+// error. The errors of the transactions themselves don't stop it: erased or
+// corrupted ones are left encrypted, with their error at the same index of
+// the returned slice, see IsRowError.
+func (tcp *StandardTransactionCryptoProvider) DecryptMany(toDecrypt []*entities.Transaction) ([]error, error) {
+	rowErrs := make([]error, len(toDecrypt))
+
+	err := forEachConcurrently(len(toDecrypt), tcp.workers, func(i int) error {
+		err := tcp.Decrypt(toDecrypt[i])
+		if IsRowError(err) {
+			rowErrs[i] = err
+			return nil
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rowErrs, nil
+}
+
+// RewrapDataKey also moves the data keys wrapped by the master key under
+// their customer's key, when customer keys are used.
+func (tcp *StandardTransactionCryptoProvider) RewrapDataKey(toRewrap *entities.Transaction) error {
+	from, err := tcp.unwrappingService(toRewrap)
 	if err != nil {
 		return err
 	}
 
-	toDecrypt.UserDocument = string(decryptedUserDocument)
-	toDecrypt.CreditCardToken = string(decryptedCreditCardToken)
+	to, err := tcp.wrappingService(toRewrap.UserDocumentIndex)
+	if err != nil {
+		return err
+	}
+
+	return tcp.fieldEncryptor.RewrapDataKeyWith(from, to, transactionsTable, toRewrap)
+}
+
+// UserDocumentIndex returns the blind index of a CPF, which ignores its
+// formatting: "502.776.134-33" and "50277613433" have the same index. It
+// returns ErrInvalidUserDocument unless the CPF has 11 digits, rather than
+// index what would be left of it.
+func (tcp *StandardTransactionCryptoProvider) UserDocumentIndex(userDocument string) (string, error) {
+	if !isUserDocument(userDocument) {
+		return "", ErrInvalidUserDocument
+	}
+
+	return tcp.fieldEncryptor.BlindIndex(transactionsTable, UserDocumentField, userDocument), nil
+}
+
+// CreditCardTokenLast4Index returns the blind index of the last 4 digits of a
+// card token, the one of every token ending with them.
+func (tcp *StandardTransactionCryptoProvider) CreditCardTokenLast4Index(last4 string) string {
+	return tcp.fieldEncryptor.BlindIndex(transactionsTable, CreditCardTokenField, last4)
+}
+
+func (tcp *StandardTransactionCryptoProvider) wrappingService(userDocumentIndex string) (KeyManagementService, error) {
+	if tcp.customerKeys == nil {
+		return tcp.kms, nil
+	}
+
+	return tcp.customerKeys.WrappingService(userDocumentIndex)
+}
+
+// unwrappingService returns the KMS that wrapped the transaction's data key,
+// telling the customer keys apart by the key ID in its envelope.
+func (tcp *StandardTransactionCryptoProvider) unwrappingService(transaction *entities.Transaction) (KeyManagementService, error) {
+	envelope, err := ParseEnvelope(transaction.DataKey)
+	if err != nil || envelope.KeyID != CustomerKeyID {
+		return tcp.kms, nil
+	}
+
+	if tcp.customerKeys == nil {
+		return nil, errors.New("the transaction's data key is wrapped by a customer key, but customer keys aren't used")
+	}
+
+	return tcp.customerKeys.UnwrappingService(transaction.UserDocumentIndex)
+}
+
+// sealValue returns the value as encrypted along with the other fields, and
+// its bucket when buckets are used.
+func (tcp *StandardTransactionCryptoProvider) sealValue(value float64) (string, *int64) {
+	encryptedValue := strconv.FormatFloat(value, 'f', -1, 64)
+
+	if tcp.valueBucketWidth <= 0 {
+		return encryptedValue, nil
+	}
+
+	bucket := int64(math.Floor(value / tcp.valueBucketWidth))
+
+	return encryptedValue, &bucket
+}
+
+// openValue moves the decrypted value back, the transactions whose value was
+// never encrypted keep the one they have.
+func openValue(transaction *entities.Transaction) error {
+	transaction.ValueBucket = nil
+
+	if transaction.EncryptedValue == "" {
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(transaction.EncryptedValue, 64)
+	if err != nil {
+		return fmt.Errorf("%w: the value isn't a number", ErrMalformedCiphertext)
+	}
+
+	transaction.Value = value
+	transaction.EncryptedValue = ""
 
 	return nil
 }
+
+// IsRowError tells whether decrypting a transaction failed because of the
+// transaction itself, being erased or corrupted, rather than of the service.
+func IsRowError(err error) bool {
+	return errors.Is(err, ErrErased) || DecryptionErrorReason(err) != ""
+}
+
+// IsErased tells whether the transaction had its encrypted fields blanked
+// when its customer was forgotten.
+func IsErased(transaction *entities.Transaction) bool {
+	return transaction.UserDocument == "" && transaction.CreditCardToken == "" && transaction.DataKey == ""
+}
+
+// DigitsOnly drops everything but the digits of value, such as the punctuation
+// of a document.
+func DigitsOnly(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+
+		return -1
+	}, value)
+}
+
+func isUserDocument(value string) bool {
+	digits := DigitsOnly(value)
+	if len(digits) != userDocumentDigits {
+		return false
+	}
+
+	for _, digit := range digits {
+		if digit < '0' || digit > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func lastFourDigits(value string) string {
+	digits := DigitsOnly(value)
+	if len(digits) < 4 {
+		return digits
+	}
+
+	return digits[len(digits)-4:]
+}
@@ -0,0 +1,517 @@
+package providers
+
+import (
+	"crypto-challenge/entities"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const blindIndexKey = "0d7f3c2b8a1e4f6d9c5b2a7e3f1d8c4b6a2e9f5d1c7b3a8e4f2d6c9b5a1e7f3d"
+
+func TestEncryptAndDecryptTransaction(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+	expected := newTransaction()
+
+	actual := *expected
+
+	// when
+	require.Nil(t, underTest.Encrypt(&actual))
+	require.Nil(t, underTest.Decrypt(&actual))
+
+	// then
+	assert.Equal(t, *expected, actual)
+}
+
+func TestEncryptTransaction_IndexesUserDocument(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+	transaction := newTransaction()
+
+	// when
+	require.Nil(t, underTest.Encrypt(transaction))
+
+	// then
+	assert.Len(t, transaction.UserDocumentIndex, 64)
+	formatted, err := underTest.UserDocumentIndex("502.776.134-33")
+	require.Nil(t, err)
+	assert.Equal(t, transaction.UserDocumentIndex, formatted)
+
+	other, err := underTest.UserDocumentIndex("50277613434")
+	require.Nil(t, err)
+	assert.NotEqual(t, transaction.UserDocumentIndex, other)
+}
+
+func TestEncryptTransaction_WithInvalidUserDocument(t *testing.T) {
+	for _, userDocument := range []string{"", "---", "5027761343", "502776134331"} {
+		t.Run(userDocument, func(t *testing.T) {
+			// given
+			underTest := newStandardTransactionCryptoProvider(t)
+			transaction := newTransaction()
+			transaction.UserDocument = userDocument
+
+			// when
+			err := underTest.Encrypt(transaction)
+
+			// then
+			assert.ErrorIs(t, err, ErrInvalidUserDocument)
+			assert.Equal(t, userDocument, transaction.UserDocument)
+			assert.Empty(t, transaction.UserDocumentIndex)
+
+			_, err = underTest.UserDocumentIndex(userDocument)
+			assert.ErrorIs(t, err, ErrInvalidUserDocument)
+		})
+	}
+}
+
+func TestEncryptTransaction_IndexesCreditCardTokenLast4(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+	transaction := newTransaction()
+	transaction.CreditCardToken = "4111 8392 0174 1111"
+
+	// when
+	require.Nil(t, underTest.Encrypt(transaction))
+
+	// then
+	assert.Len(t, transaction.CreditCardTokenLast4Index, 64)
+	assert.Equal(t, transaction.CreditCardTokenLast4Index, underTest.CreditCardTokenLast4Index("1111"))
+	assert.NotEqual(t, transaction.CreditCardTokenLast4Index, underTest.CreditCardTokenLast4Index("4111"))
+
+	require.Nil(t, underTest.Decrypt(transaction))
+	assert.Empty(t, transaction.CreditCardTokenLast4Index)
+}
+
+func TestEncryptTransaction_WithDeterministicField(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+	require.Nil(t, underTest.EncryptDeterministically(newKeyring(t, "d1", aesSivKey), CreditCardTokenField))
+
+	expected := newTransaction()
+	first, second := *expected, *expected
+	second.ID = uuid.NewString()
+
+	// when
+	require.Nil(t, underTest.Encrypt(&first))
+	require.Nil(t, underTest.Encrypt(&second))
+
+	// then
+	assert.Equal(t, first.CreditCardToken, second.CreditCardToken)
+	assert.NotEqual(t, first.UserDocument, second.UserDocument)
+
+	require.Nil(t, underTest.Decrypt(&first))
+	assert.Equal(t, *expected, first)
+}
+
+func TestEncryptTransaction_WithEncryptedValue(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+	underTest.EncryptValue(100)
+
+	expected := newTransaction()
+	actual := *expected
+
+	// when
+	require.Nil(t, underTest.Encrypt(&actual))
+
+	// then
+	assert.Zero(t, actual.Value)
+	assert.True(t, isEnvelopeOn(t, actual.EncryptedValue, "dek/encrypted_value"))
+	require.NotNil(t, actual.ValueBucket)
+	assert.Equal(t, int64(expected.Value/100), *actual.ValueBucket)
+
+	require.Nil(t, underTest.Decrypt(&actual))
+	assert.Equal(t, *expected, actual)
+}
+
+func TestEncryptTransaction_WithEncryptedValueWithoutBuckets(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+	underTest.EncryptValue(0)
+
+	transaction := newTransaction()
+
+	// when
+	require.Nil(t, underTest.Encrypt(transaction))
+
+	// then
+	assert.Nil(t, transaction.ValueBucket)
+	assert.NotEmpty(t, transaction.EncryptedValue)
+}
+
+func TestEncryptTransaction_WithErrorLeavesTransactionAsItWas(t *testing.T) {
+	// given
+	underTest := NewStandardTransactionCryptoProvider(unavailableKms{}, newBlindIndex(t), AlgorithmAesGcm256)
+	underTest.EncryptValue(100)
+
+	expected := newTransaction()
+	actual := *expected
+
+	// when
+	err := underTest.Encrypt(&actual)
+
+	// then
+	require.NotNil(t, err)
+	assert.Equal(t, *expected, actual)
+}
+
+func TestDecryptTransaction_WithValueInClear(t *testing.T) {
+	// given
+	encryptor := newStandardTransactionCryptoProvider(t)
+
+	expected := newTransaction()
+	actual := *expected
+	require.Nil(t, encryptor.Encrypt(&actual))
+
+	underTest := newStandardTransactionCryptoProvider(t)
+	underTest.EncryptValue(100)
+
+	// when
+	err := underTest.Decrypt(&actual)
+
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, *expected, actual)
+}
+
+func TestDecryptTransaction_WithEncryptedValueCopiedFromAnotherRow(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+	underTest.EncryptValue(0)
+
+	first, second := newTransaction(), newTransaction()
+	second.ID = uuid.NewString()
+	second.Value = 1
+
+	require.Nil(t, underTest.Encrypt(first))
+	require.Nil(t, underTest.Encrypt(second))
+
+	first.EncryptedValue = second.EncryptedValue
+
+	// when
+	err := underTest.Decrypt(first)
+
+	// then
+	assert.ErrorIs(t, err, ErrAuthenticationFailed)
+}
+
+func TestDecryptTransaction_WithCiphertextCopiedFromAnotherRow(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+
+	victim, attacker := newTransaction(), newTransaction()
+	require.Nil(t, underTest.Encrypt(victim))
+	require.Nil(t, underTest.Encrypt(attacker))
+
+	attacker.UserDocument = victim.UserDocument
+
+	// when
+	err := underTest.Decrypt(attacker)
+
+	// then
+	assert.NotNil(t, err)
+}
+
+func TestDecryptTransaction_WithCiphertextCopiedFromAnotherColumn(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+
+	transaction := newTransaction()
+	require.Nil(t, underTest.Encrypt(transaction))
+
+	transaction.CreditCardToken = transaction.UserDocument
+
+	// when
+	err := underTest.Decrypt(transaction)
+
+	// then
+	assert.NotNil(t, err)
+}
+
+func TestDecryptTransaction_RejectingV1CiphertextCopiedFromAnotherRow(t *testing.T) {
+	// given
+	masterKey := mustDecodeHex(t, secretKey)
+	victim, attacker := newTransaction(), newTransaction()
+
+	victim.UserDocument = encryptV1(t, "k1", masterKey, []byte("12345678909"))
+	attacker.UserDocument = victim.UserDocument
+	attacker.CreditCardToken = encryptV1(t, "k1", masterKey, []byte(attacker.CreditCardToken))
+
+	// Version 1 isn't bound to its row, the copy decrypts until rejected.
+	copied := *attacker
+	require.Nil(t, newStandardTransactionCryptoProvider(t).Decrypt(&copied))
+	require.Equal(t, "12345678909", copied.UserDocument)
+
+	kms := NewLocalKeyManagementService(newKeyring(t, "k1", secretKey), AlgorithmAesGcm256)
+	kms.RejectUnboundCiphertexts()
+
+	underTest := NewStandardTransactionCryptoProvider(kms, newBlindIndex(t), AlgorithmAesGcm256)
+	underTest.RejectUnboundCiphertexts()
+
+	// when
+	err := underTest.Decrypt(attacker)
+
+	// then
+	assert.ErrorIs(t, err, ErrAuthenticationFailed)
+}
+
+func TestEncryptTransaction_UsesDataKeyWrappedByMasterKey(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+	transaction := newTransaction()
+
+	// when
+	require.Nil(t, underTest.Encrypt(transaction))
+
+	// then
+	dataKeyEnvelope, err := ParseEnvelope(transaction.DataKey)
+	require.Nil(t, err)
+	assert.Equal(t, "k1", dataKeyEnvelope.KeyID)
+
+	userDocumentEnvelope, err := ParseEnvelope(transaction.UserDocument)
+	require.Nil(t, err)
+	assert.Equal(t, "dek/user_document", userDocumentEnvelope.KeyID)
+
+	creditCardTokenEnvelope, err := ParseEnvelope(transaction.CreditCardToken)
+	require.Nil(t, err)
+	assert.Equal(t, "dek/credit_card_token", creditCardTokenEnvelope.KeyID)
+}
+
+func TestDecryptTransaction_WithFieldsUnderDataKey(t *testing.T) {
+	// given
+	kms := NewLocalKeyManagementService(newKeyring(t, "k1", secretKey), AlgorithmAesGcm256)
+	underTest := NewStandardTransactionCryptoProvider(kms, newBlindIndex(t), AlgorithmAesGcm256)
+
+	expected := newTransaction()
+	transaction := *expected
+
+	dataKey, wrappedDataKey, err := kms.GenerateDataKey(fieldAdditionalData(transactionsTable, transaction.ID, dataKeyField))
+	require.Nil(t, err)
+
+	dataKeyCp := NewAesGcm256CryptoProvider(NewKeyring(DataKeyID, dataKey))
+
+	transaction.UserDocument, err = dataKeyCp.Encrypt([]byte(transaction.UserDocument),
+		fieldAdditionalData(transactionsTable, transaction.ID, UserDocumentField))
+	require.Nil(t, err)
+
+	transaction.CreditCardToken, err = dataKeyCp.Encrypt([]byte(transaction.CreditCardToken),
+		fieldAdditionalData(transactionsTable, transaction.ID, CreditCardTokenField))
+	require.Nil(t, err)
+
+	transaction.DataKey = wrappedDataKey
+
+	// when
+	require.Nil(t, underTest.Decrypt(&transaction))
+
+	// then
+	assert.Equal(t, *expected, transaction)
+}
+
+func TestDeriveKey(t *testing.T) {
+	// given
+	key := mustDecodeHex(t, secretKey)
+
+	// when
+	userDocumentKey, err := DeriveKey(key, KeyPurposeEncryption, UserDocumentField)
+	require.Nil(t, err)
+
+	creditCardTokenKey, err := DeriveKey(key, KeyPurposeEncryption, CreditCardTokenField)
+	require.Nil(t, err)
+
+	deterministicKey, err := DeriveKey(key, KeyPurposeDeterministicEncryption, UserDocumentField)
+	require.Nil(t, err)
+
+	sameUserDocumentKey, err := DeriveKey(key, KeyPurposeEncryption, UserDocumentField)
+	require.Nil(t, err)
+
+	// then
+	assert.Len(t, userDocumentKey, 32)
+	assert.Equal(t, userDocumentKey, sameUserDocumentKey)
+	assert.NotEqual(t, key, userDocumentKey)
+	assert.NotEqual(t, userDocumentKey, creditCardTokenKey)
+	assert.NotEqual(t, userDocumentKey, deterministicKey)
+}
+
+func TestRewrapDataKey(t *testing.T) {
+	// given
+	expected := newTransaction()
+
+	transaction := *expected
+	require.Nil(t, newStandardTransactionCryptoProvider(t).Encrypt(&transaction))
+
+	rotatedKeyring := newKeyring(t, "k2", anotherSecretKey)
+	require.Nil(t, rotatedKeyring.AddDecryptOnlyKey("k1", mustDecodeHex(t, secretKey)))
+	underTest := newStandardTransactionCryptoProviderFor(t, rotatedKeyring)
+
+	encryptedUserDocument := transaction.UserDocument
+
+	// when
+	require.Nil(t, underTest.RewrapDataKey(&transaction))
+
+	// then
+	assert.Equal(t, encryptedUserDocument, transaction.UserDocument)
+	assert.True(t, strings.HasPrefix(transaction.DataKey, "v2:k2:"))
+
+	require.Nil(t, newStandardTransactionCryptoProviderFor(t, newKeyring(t, "k2", anotherSecretKey)).
+		Decrypt(&transaction))
+	assert.Equal(t, *expected, transaction)
+}
+
+func TestDecryptTransaction_WithoutDataKey(t *testing.T) {
+	// given
+	keyring := newKeyring(t, "k1", secretKey)
+	masterKey := NewAesGcm256CryptoProvider(keyring)
+	underTest := newStandardTransactionCryptoProviderFor(t, keyring)
+
+	expected := newTransaction()
+	transaction := *expected
+
+	encryptedUserDocument, err := masterKey.Encrypt([]byte(transaction.UserDocument),
+		fieldAdditionalData(transactionsTable, transaction.ID, UserDocumentField))
+	require.Nil(t, err)
+
+	encryptedCreditCardToken, err := masterKey.Encrypt([]byte(transaction.CreditCardToken),
+		fieldAdditionalData(transactionsTable, transaction.ID, CreditCardTokenField))
+	require.Nil(t, err)
+
+	transaction.UserDocument = encryptedUserDocument
+	transaction.CreditCardToken = encryptedCreditCardToken
+
+	// when
+	require.Nil(t, underTest.Decrypt(&transaction))
+
+	// then
+	assert.Equal(t, *expected, transaction)
+}
+
+func TestEncryptManyAndDecryptMany(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+	underTest.UseWorkers(4)
+
+	expected := make([]entities.Transaction, 50)
+	transactions := make([]*entities.Transaction, len(expected))
+
+	for i := range expected {
+		expected[i] = *newTransaction()
+		expected[i].UserDocument = fmt.Sprintf("%011d", i)
+
+		transaction := expected[i]
+		transactions[i] = &transaction
+	}
+
+	// when
+	require.Nil(t, underTest.EncryptMany(transactions))
+
+	for i, transaction := range transactions {
+		require.NotEqual(t, expected[i].UserDocument, transaction.UserDocument)
+	}
+
+	rowErrs, err := underTest.DecryptMany(transactions)
+	require.Nil(t, err)
+
+	// then
+	for i, transaction := range transactions {
+		assert.Nil(t, rowErrs[i])
+		assert.Equal(t, expected[i], *transaction)
+	}
+}
+
+func TestDecryptMany_WithErasedTransaction(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+
+	readable := newTransaction()
+	expected := *readable
+	require.Nil(t, underTest.Encrypt(readable))
+
+	erased := &entities.Transaction{ID: uuid.NewString(), Value: 1299.80}
+
+	// when
+	rowErrs, err := underTest.DecryptMany([]*entities.Transaction{readable, erased})
+
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, []error{nil, ErrErased}, rowErrs)
+	assert.Equal(t, expected, *readable)
+}
+
+func TestDecryptMany_WithTamperedTransaction(t *testing.T) {
+	// given
+	underTest := newStandardTransactionCryptoProvider(t)
+
+	expected := newTransaction()
+	readable := *expected
+	transactions := []*entities.Transaction{&readable, newTransaction(), newTransaction()}
+	require.Nil(t, underTest.EncryptMany(transactions))
+
+	transactions[1].UserDocument = transactions[0].UserDocument
+	transactions[2].CreditCardToken = "v2:k1:aes-256-gcm:zz:00"
+
+	tampered := *transactions[1]
+
+	// when
+	rowErrs, err := underTest.DecryptMany(transactions)
+
+	// then
+	require.Nil(t, err)
+	assert.Nil(t, rowErrs[0])
+	assert.ErrorIs(t, rowErrs[1], ErrAuthenticationFailed)
+	assert.ErrorIs(t, rowErrs[2], ErrMalformedCiphertext)
+	assert.Equal(t, *expected, readable)
+	assert.Equal(t, tampered, *transactions[1])
+}
+
+func TestDecryptMany_WithServiceError(t *testing.T) {
+	// given
+	encrypting := newStandardTransactionCryptoProvider(t)
+	require.Nil(t, encrypting.EncryptDeterministically(newKeyring(t, "d1", aesSivKey), UserDocumentField))
+
+	transactions := []*entities.Transaction{newTransaction()}
+	require.Nil(t, encrypting.EncryptMany(transactions))
+
+	// when
+	rowErrs, err := newStandardTransactionCryptoProvider(t).DecryptMany(transactions)
+
+	// then
+	assert.NotNil(t, err)
+	assert.Nil(t, rowErrs)
+}
+
+// unavailableKms fails to generate data keys, like a KMS that can't be
+// reached.
+type unavailableKms struct {
+	KeyManagementService
+}
+
+func (unavailableKms) GenerateDataKey([]byte) ([]byte, string, error) {
+	return nil, "", errors.New("the KMS is unavailable")
+}
+
+func newStandardTransactionCryptoProvider(t testing.TB) *StandardTransactionCryptoProvider {
+	return newStandardTransactionCryptoProviderFor(t, newKeyring(t, "k1", secretKey))
+}
+
+func newStandardTransactionCryptoProviderFor(t testing.TB, keyring *Keyring) *StandardTransactionCryptoProvider {
+	return NewStandardTransactionCryptoProvider(NewLocalKeyManagementService(keyring, AlgorithmAesGcm256),
+		newBlindIndex(t), AlgorithmAesGcm256)
+}
+
+func newBlindIndex(t testing.TB) *HmacSha256BlindIndex {
+	return NewHmacSha256BlindIndex(mustDecodeHex(t, blindIndexKey))
+}
+
+func newTransaction() *entities.Transaction {
+	return &entities.Transaction{
+		ID:              uuid.NewString(),
+		UserDocument:    "50277613433",
+		CreditCardToken: "937",
+		Value:           1299.80,
+	}
+}
@@ -0,0 +1,115 @@
+package providers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"sync"
+)
+
+var ErrKeyShareAlreadySubmitted = errors.New("the key share was already submitted")
+
+type SealStatus struct {
+	Sealed    bool `json:"sealed"`
+	Progress  int  `json:"progress"`
+	Threshold int  `json:"threshold"`
+}
+
+// Unsealer collects the shares of the primary key until there are enough to
+// rebuild it.
+type Unsealer interface {
+	SubmitKeyShare(encoded string) (*SealStatus, error)
+	Status() *SealStatus
+}
+
+// ShamirUnsealer rebuilds the primary key from its Shamir shares, then hands
+// it, in locked memory, to onUnseal. The shares are discarded when they don't
+// rebuild the key or onUnseal fails, so the operators start over.
+type ShamirUnsealer struct {
+	mu       sync.Mutex
+	shares   []KeyShare
+	unsealed bool
+	onUnseal func(primaryKey []byte) error
+}
+
+func NewShamirUnsealer(onUnseal func(primaryKey []byte) error) *ShamirUnsealer {
+	return &ShamirUnsealer{onUnseal: onUnseal}
+}
+
+func (u *ShamirUnsealer) SubmitKeyShare(encoded string) (*SealStatus, error) {
+	share, err := ParseKeyShare(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.unsealed {
+		wipe(share.Value)
+		return u.status(), nil
+	}
+
+	for _, submitted := range u.shares {
+		if submitted.Threshold != share.Threshold || subtle.ConstantTimeCompare(submitted.Check, share.Check) != 1 {
+			wipe(share.Value)
+			return nil, ErrKeySharesMismatch
+		}
+
+		if submitted.Index == share.Index {
+			wipe(share.Value)
+			return nil, ErrKeyShareAlreadySubmitted
+		}
+	}
+
+	u.shares = append(u.shares, share)
+
+	if len(u.shares) < u.shares[0].Threshold {
+		return u.status(), nil
+	}
+
+	defer u.discardShares()
+
+	primaryKey, err := CombineKeyShares(u.shares)
+	if err != nil {
+		return nil, err
+	}
+
+	lockKey(primaryKey)
+
+	if err := u.onUnseal(primaryKey); err != nil {
+		wipe(primaryKey)
+		return nil, err
+	}
+
+	u.unsealed = true
+
+	return u.status(), nil
+}
+
+func (u *ShamirUnsealer) Status() *SealStatus {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.status()
+}
+
+func (u *ShamirUnsealer) status() *SealStatus {
+	if u.unsealed {
+		return &SealStatus{Sealed: false}
+	}
+
+	status := &SealStatus{Sealed: true, Progress: len(u.shares)}
+	if len(u.shares) > 0 {
+		status.Threshold = u.shares[0].Threshold
+	}
+
+	return status
+}
+
+func (u *ShamirUnsealer) discardShares() {
+	for _, share := range u.shares {
+		wipe(share.Value)
+	}
+
+	u.shares = nil
+}
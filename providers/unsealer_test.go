@@ -0,0 +1,106 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShamirUnsealer_UnsealsOnceTheThresholdIsReached(t *testing.T) {
+	// given
+	shares, err := SplitKey(mustDecodeHex(t, secretKey), 5, 3)
+	require.Nil(t, err)
+
+	var unsealedWith []byte
+	underTest := NewShamirUnsealer(func(primaryKey []byte) error {
+		unsealedWith = append([]byte{}, primaryKey...)
+		return nil
+	})
+
+	// when
+	first, err := underTest.SubmitKeyShare(shares[4])
+	require.Nil(t, err)
+
+	second, err := underTest.SubmitKeyShare(shares[1])
+	require.Nil(t, err)
+
+	third, err := underTest.SubmitKeyShare(shares[2])
+	require.Nil(t, err)
+
+	// then
+	assert.Equal(t, &SealStatus{Sealed: true, Progress: 1, Threshold: 3}, first)
+	assert.Equal(t, &SealStatus{Sealed: true, Progress: 2, Threshold: 3}, second)
+	assert.Equal(t, &SealStatus{Sealed: false}, third)
+	assert.Equal(t, &SealStatus{Sealed: false}, underTest.Status())
+	assert.Equal(t, mustDecodeHex(t, secretKey), unsealedWith)
+}
+
+func TestShamirUnsealer_StartsSealed(t *testing.T) {
+	// given
+	underTest := NewShamirUnsealer(func([]byte) error { return nil })
+
+	// when
+	actual := underTest.Status()
+
+	// then
+	assert.Equal(t, &SealStatus{Sealed: true}, actual)
+}
+
+func TestShamirUnsealer_WithSameShareTwice(t *testing.T) {
+	// given
+	shares, err := SplitKey(mustDecodeHex(t, secretKey), 3, 2)
+	require.Nil(t, err)
+
+	underTest := NewShamirUnsealer(func([]byte) error { return nil })
+
+	_, err = underTest.SubmitKeyShare(shares[0])
+	require.Nil(t, err)
+
+	// when
+	_, err = underTest.SubmitKeyShare(shares[0])
+
+	// then
+	assert.ErrorIs(t, err, ErrKeyShareAlreadySubmitted)
+	assert.Equal(t, &SealStatus{Sealed: true, Progress: 1, Threshold: 2}, underTest.Status())
+}
+
+func TestShamirUnsealer_WithShareOfAnotherKey(t *testing.T) {
+	// given
+	shares, err := SplitKey(mustDecodeHex(t, secretKey), 3, 2)
+	require.Nil(t, err)
+
+	otherShares, err := SplitKey(mustDecodeHex(t, anotherSecretKey), 3, 2)
+	require.Nil(t, err)
+
+	underTest := NewShamirUnsealer(func([]byte) error { return nil })
+
+	_, err = underTest.SubmitKeyShare(shares[0])
+	require.Nil(t, err)
+
+	// when
+	_, err = underTest.SubmitKeyShare(otherShares[1])
+
+	// then
+	assert.ErrorIs(t, err, ErrKeySharesMismatch)
+	assert.Equal(t, &SealStatus{Sealed: true, Progress: 1, Threshold: 2}, underTest.Status())
+}
+
+func TestShamirUnsealer_DiscardsTheSharesWhenUnsealingFails(t *testing.T) {
+	// given
+	shares, err := SplitKey(mustDecodeHex(t, secretKey), 3, 2)
+	require.Nil(t, err)
+
+	underTest := NewShamirUnsealer(func([]byte) error { return errors.New("unseal error") })
+
+	_, err = underTest.SubmitKeyShare(shares[0])
+	require.Nil(t, err)
+
+	// when
+	_, err = underTest.SubmitKeyShare(shares[1])
+
+	// then
+	assert.EqualError(t, err, "unseal error")
+	assert.Equal(t, &SealStatus{Sealed: true}, underTest.Status())
+}
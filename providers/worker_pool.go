@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// forEachConcurrently calls fn with every index from 0 to n-1 on up to the
+// given number of goroutines, GOMAXPROCS when it isn't positive. No call
+// starts after the first error, which is the one returned.
+func forEachConcurrently(n, workers int, fn func(i int) error) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	if workers > n {
+		workers = n
+	}
+
+	var (
+		next     atomic.Int64
+		failed   atomic.Bool
+		firstErr error
+		once     sync.Once
+		wg       sync.WaitGroup
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+
+				if err := fn(i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						failed.Store(true)
+					})
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
@@ -0,0 +1,84 @@
+package providers
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEachConcurrently_CallsEveryIndex(t *testing.T) {
+	// given
+	called := make([]atomic.Int32, 100)
+
+	// when
+	err := forEachConcurrently(len(called), 8, func(i int) error {
+		called[i].Add(1)
+		return nil
+	})
+
+	// then
+	assert.Nil(t, err)
+
+	for i := range called {
+		assert.Equal(t, int32(1), called[i].Load(), i)
+	}
+}
+
+func TestForEachConcurrently_BoundsTheWorkers(t *testing.T) {
+	// given
+	var running, maxRunning atomic.Int32
+
+	// when
+	err := forEachConcurrently(100, 3, func(i int) error {
+		current := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			previous := maxRunning.Load()
+			if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+
+		return nil
+	})
+
+	// then
+	assert.Nil(t, err)
+	assert.LessOrEqual(t, maxRunning.Load(), int32(3))
+}
+
+func TestForEachConcurrently_StopsAtTheFirstError(t *testing.T) {
+	// given
+	expected := errors.New("failed")
+
+	var calls atomic.Int32
+
+	// when
+	err := forEachConcurrently(1000, 1, func(i int) error {
+		calls.Add(1)
+
+		if i == 10 {
+			return expected
+		}
+
+		return nil
+	})
+
+	// then
+	assert.Equal(t, expected, err)
+	assert.Equal(t, int32(11), calls.Load())
+}
+
+func TestForEachConcurrently_WithoutItems(t *testing.T) {
+	// when
+	err := forEachConcurrently(0, 0, func(i int) error {
+		t.Fatal("unexpected call")
+		return nil
+	})
+
+	// then
+	assert.Nil(t, err)
+}
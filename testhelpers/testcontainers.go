@@ -17,6 +17,21 @@ import (
 )
 
 func SetupMySqlContainer(cfg *config.AppConfig, migrationsFolderPath string) (testcontainers.Container, *func(), *context.Context) {
+	migrationFilePaths, err := filepath.Glob(filepath.Join(migrationsFolderPath, "*.sql"))
+	if err != nil {
+		log.Fatal("Could not list the migration files.", err)
+	}
+
+	migrationFiles := make([]testcontainers.ContainerFile, 0, len(migrationFilePaths))
+
+	for _, migrationFilePath := range migrationFilePaths {
+		migrationFiles = append(migrationFiles, testcontainers.ContainerFile{
+			HostFilePath:      migrationFilePath,
+			ContainerFilePath: "/docker-entrypoint-initdb.d/" + filepath.Base(migrationFilePath),
+			FileMode:          0o755,
+		})
+	}
+
 	ctx := context.Background()
 	req := testcontainers.ContainerRequest{
 		Image:        "mysql@sha256:eeabfa5cd6a2091bf35eb9eae6ae48aab8231fd760f5a61cd0129df454333b1d",
@@ -32,13 +47,7 @@ func SetupMySqlContainer(cfg *config.AppConfig, migrationsFolderPath string) (te
 
 			return dbCfg.FormatDSN()
 		}).WithPollInterval(time.Millisecond * 500),
-		Files: []testcontainers.ContainerFile{
-			{
-				HostFilePath:      filepath.Join(migrationsFolderPath, "create-transactions-table.sql"),
-				ContainerFilePath: "/docker-entrypoint-initdb.d/create-transactions-table.sql",
-				FileMode:          0o755,
-			},
-		},
+		Files: migrationFiles,
 		Env: map[string]string{
 			"MYSQL_USER":                 cfg.Database.User,
 			"MYSQL_PASSWORD":             cfg.Database.Password,
@@ -73,11 +82,12 @@ func GetMySqlContainerDB(t *testing.T, mySqlC testcontainers.Container, ctxMySql
 	t.Logf("Container endpoint: %s\n", endpoint)
 
 	dbCfg := mysql.Config{
-		User:   cfg.Database.User,
-		Passwd: cfg.Database.Password,
-		Net:    "tcp",
-		Addr:   endpoint,
-		DBName: cfg.Database.DbName,
+		User:      cfg.Database.User,
+		Passwd:    cfg.Database.Password,
+		Net:       "tcp",
+		Addr:      endpoint,
+		DBName:    cfg.Database.DbName,
+		ParseTime: true,
 	}
 
 	db, err := sql.Open("mysql", dbCfg.FormatDSN())